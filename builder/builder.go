@@ -2,20 +2,31 @@ package builder
 
 import (
 	"context"
+	"crypto/ecdh"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 
 	"github.com/docker/model-distribution/internal/gguf"
 	"github.com/docker/model-distribution/internal/mutate"
 	"github.com/docker/model-distribution/internal/partial"
+	"github.com/docker/model-distribution/signing"
 	"github.com/docker/model-distribution/types"
 )
 
 // Builder builds a model artifact
 type Builder struct {
-	model types.ModelArtifact
+	model      types.ModelArtifact
+	signer     signing.Signer
+	recipients []*ecdh.PublicKey
+	provenance *types.Provenance
 }
 
+// spdxIDPattern matches SPDX license identifiers, including LicenseRef- identifiers
+var spdxIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+-]*$`)
+
 // FromGGUF returns a *Builder that builds a model artifacts from a GGUF file
 func FromGGUF(path string) (*Builder, error) {
 	mdl, err := gguf.NewModel(path)
@@ -33,15 +44,11 @@ func (b *Builder) WithLicense(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("license layer from %q: %w", path, err)
 	}
-	return &Builder{
-		model: mutate.AppendLayers(b.model, licenseLayer),
-	}, nil
+	return b.withModel(mutate.AppendLayers(b.model, licenseLayer)), nil
 }
 
 func (b *Builder) WithContextSize(size uint64) *Builder {
-	return &Builder{
-		model: mutate.ContextSize(b.model, size),
-	}
+	return b.withModel(mutate.ContextSize(b.model, size))
 }
 
 // WithMultimodalProjector adds a Multimodal projector file to the artifact
@@ -50,9 +57,7 @@ func (b *Builder) WithMultimodalProjector(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("mmproj layer from %q: %w", path, err)
 	}
-	return &Builder{
-		model: mutate.AppendLayers(b.model, mmprojLayer),
-	}, nil
+	return b.withModel(mutate.AppendLayers(b.model, mmprojLayer)), nil
 }
 
 // WithChatTemplateFile adds a Jinja chat template file to the artifact which takes precedence over template from GGUF.
@@ -61,9 +66,46 @@ func (b *Builder) WithChatTemplateFile(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("chat template layer from %q: %w", path, err)
 	}
-	return &Builder{
-		model: mutate.AppendLayers(b.model, templateLayer),
-	}, nil
+	return b.withModel(mutate.AppendLayers(b.model, templateLayer)), nil
+}
+
+// WithSigner signs the artifact with signer when it is built. The target must implement SignatureTarget.
+func (b *Builder) WithSigner(signer signing.Signer) *Builder {
+	nb := *b
+	nb.signer = signer
+	return &nb
+}
+
+// WithEncryption encrypts the GGUF and multimodal projector layers of the artifact for the given recipients when it is
+// built. Any of the recipients' private keys can decrypt the layers.
+func (b *Builder) WithEncryption(recipients ...*ecdh.PublicKey) *Builder {
+	nb := *b
+	nb.recipients = append(append([]*ecdh.PublicKey{}, b.recipients...), recipients...)
+	return &nb
+}
+
+// WithProvenance records build provenance in the artifact's descriptor. The builder host and the names and digests of
+// the source files are filled in when the artifact is built, p provides the tool, the recipe and the SPDX identifiers
+// of the licenses. The digests of encrypted files are not recorded.
+func (b *Builder) WithProvenance(p types.Provenance) (*Builder, error) {
+	if p.Tool.Name == "" {
+		return nil, fmt.Errorf("provenance requires a tool name")
+	}
+	for _, id := range p.Licenses {
+		if !spdxIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid SPDX license identifier %q", id)
+		}
+	}
+	nb := *b
+	nb.provenance = &p
+	return &nb, nil
+}
+
+// withModel returns a copy of b that builds mdl
+func (b *Builder) withModel(mdl types.ModelArtifact) *Builder {
+	nb := *b
+	nb.model = mdl
+	return &nb
 }
 
 // Target represents a build target
@@ -71,7 +113,77 @@ type Target interface {
 	Write(context.Context, types.ModelArtifact, io.Writer) error
 }
 
-// Build finalizes the artifact and writes it to the given target, reporting progress to the given writer
+// SignatureTarget is a Target that can store a detached signature of an artifact written to it
+type SignatureTarget interface {
+	Target
+	WriteSignature(context.Context, types.ModelArtifact, signing.Signer) error
+}
+
+// Build finalizes the artifact and writes it to the given target, reporting progress to the given writer. If the
+// builder has a signer, the signature is written to the target after the artifact. If the builder has encryption
+// recipients, the artifact's layers are encrypted before it is written and signed.
 func (b *Builder) Build(ctx context.Context, target Target, pw io.Writer) error {
-	return target.Write(ctx, b.model, pw)
+	var st SignatureTarget
+	if b.signer != nil {
+		var ok bool
+		if st, ok = target.(SignatureTarget); !ok {
+			return fmt.Errorf("target %T does not support signatures", target)
+		}
+	}
+	mdl := b.model
+	if b.provenance != nil {
+		p, err := b.buildProvenance()
+		if err != nil {
+			return fmt.Errorf("recording provenance: %w", err)
+		}
+		mdl = mutate.Provenance(mdl, p)
+	}
+	if len(b.recipients) > 0 {
+		var err error
+		if mdl, err = mutate.EncryptLayers(mdl, b.recipients); err != nil {
+			return fmt.Errorf("encrypting model: %w", err)
+		}
+	}
+	if err := target.Write(ctx, mdl, pw); err != nil {
+		return err
+	}
+	if st != nil {
+		if err := st.WriteSignature(ctx, mdl, b.signer); err != nil {
+			return fmt.Errorf("writing signature: %w", err)
+		}
+	}
+	return nil
+}
+
+// buildProvenance completes the builder's provenance with the host and the source files of the artifact
+func (b *Builder) buildProvenance() (types.Provenance, error) {
+	p := *b.provenance
+	if p.Host == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return types.Provenance{}, fmt.Errorf("get host name: %w", err)
+		}
+		p.Host = host
+	}
+	layers, err := b.model.Layers()
+	if err != nil {
+		return types.Provenance{}, fmt.Errorf("get layers: %w", err)
+	}
+	p.Sources = nil
+	for _, l := range layers {
+		layer, ok := l.(*partial.Layer)
+		if !ok {
+			continue
+		}
+		source := types.Source{
+			Name:      filepath.Base(layer.Path),
+			MediaType: string(layer.Descriptor.MediaType),
+			Digest:    layer.Descriptor.Digest.String(),
+		}
+		if len(b.recipients) > 0 && (layer.Descriptor.MediaType == types.MediaTypeGGUF || layer.Descriptor.MediaType == types.MediaTypeMultimodalProjector) {
+			source.Digest = ""
+		}
+		p.Sources = append(p.Sources, source)
+	}
+	return p, nil
 }
@@ -2,11 +2,15 @@ package builder_test
 
 import (
 	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
 	"io"
 	"path/filepath"
 	"testing"
 
 	"github.com/docker/model-distribution/builder"
+	"github.com/docker/model-distribution/signing"
 	"github.com/docker/model-distribution/types"
 )
 
@@ -142,6 +146,135 @@ func TestWithMultimodalProjectorChaining(t *testing.T) {
 	// but we can verify the layers were added with correct media types above
 }
 
+func TestBuilderWithSigner(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := signing.NewSigner(key)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	b, err := builder.FromGGUF(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create builder from GGUF: %v", err)
+	}
+	b = b.WithSigner(signer).WithContextSize(2048)
+
+	// Targets without signature support are rejected before anything is written
+	target := &fakeTarget{}
+	if err := b.Build(t.Context(), target, nil); err == nil {
+		t.Fatalf("Expected error for target without signature support")
+	}
+	if target.artifact != nil {
+		t.Fatalf("Expected nothing to be written to the target")
+	}
+
+	sigTarget := &fakeSignatureTarget{}
+	if err := b.Build(t.Context(), sigTarget, nil); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	if sigTarget.signed != sigTarget.artifact {
+		t.Fatalf("Expected the built artifact to be signed")
+	}
+}
+
+func TestBuilderWithEncryption(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	b, err := builder.FromGGUF(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create builder from GGUF: %v", err)
+	}
+	b = b.WithEncryption(key.PublicKey())
+	b, err = b.WithLicense(filepath.Join("..", "assets", "license.txt"))
+	if err != nil {
+		t.Fatalf("Failed to add license to model: %v", err)
+	}
+
+	target := &fakeTarget{}
+	if err := b.Build(t.Context(), target, nil); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	manifest, err := target.artifact.Manifest()
+	if err != nil {
+		t.Fatalf("Failed to get manifest: %v", err)
+	}
+	if len(manifest.Layers) != 2 {
+		t.Fatalf("Expected 2 layers, got %d", len(manifest.Layers))
+	}
+	if manifest.Layers[0].MediaType != types.MediaTypeGGUFEncrypted {
+		t.Fatalf("Expected media type %s, got %s", types.MediaTypeGGUFEncrypted, manifest.Layers[0].MediaType)
+	}
+	if manifest.Layers[1].MediaType != types.MediaTypeLicense {
+		t.Fatalf("Expected media type %s, got %s", types.MediaTypeLicense, manifest.Layers[1].MediaType)
+	}
+}
+
+func TestBuilderWithProvenance(t *testing.T) {
+	b, err := builder.FromGGUF(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create builder from GGUF: %v", err)
+	}
+	b, err = b.WithLicense(filepath.Join("..", "assets", "license.txt"))
+	if err != nil {
+		t.Fatalf("Failed to add license to model: %v", err)
+	}
+
+	if _, err := b.WithProvenance(types.Provenance{}); err == nil {
+		t.Fatalf("Expected error for provenance without tool")
+	}
+	if _, err := b.WithProvenance(types.Provenance{Tool: types.Tool{Name: "test"}, Licenses: []string{"not a license"}}); err == nil {
+		t.Fatalf("Expected error for invalid SPDX identifier")
+	}
+
+	b, err = b.WithProvenance(types.Provenance{
+		Tool:     types.Tool{Name: "test", Version: "v1.0.0"},
+		Recipe:   &types.Recipe{URI: "https://github.com/example/recipes", Commit: "0123456789abcdef"},
+		Licenses: []string{"Apache-2.0", "LicenseRef-custom"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add provenance: %v", err)
+	}
+
+	for _, encrypted := range []bool{false, true} {
+		bb := b
+		if encrypted {
+			key, err := ecdh.X25519().GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatalf("Failed to generate key: %v", err)
+			}
+			bb = b.WithEncryption(key.PublicKey())
+		}
+		target := &fakeTarget{}
+		if err := bb.Build(t.Context(), target, nil); err != nil {
+			t.Fatalf("Failed to build model: %v", err)
+		}
+		desc, err := target.artifact.Descriptor()
+		if err != nil {
+			t.Fatalf("Failed to get descriptor: %v", err)
+		}
+		p := desc.Provenance
+		if p == nil {
+			t.Fatalf("Expected provenance in descriptor")
+		}
+		if p.Tool.Name != "test" || p.Tool.Version != "v1.0.0" || p.Host == "" || p.Recipe == nil || p.Recipe.Commit != "0123456789abcdef" {
+			t.Fatalf("Unexpected provenance %+v", p)
+		}
+		if len(p.Licenses) != 2 || p.Licenses[0] != "Apache-2.0" {
+			t.Fatalf("Unexpected licenses %v", p.Licenses)
+		}
+		if len(p.Sources) != 2 || p.Sources[0].Name != "dummy.gguf" || p.Sources[1].Name != "license.txt" {
+			t.Fatalf("Unexpected sources %+v", p.Sources)
+		}
+		if (p.Sources[0].Digest == "") != encrypted || p.Sources[1].Digest == "" {
+			t.Fatalf("Unexpected source digests %+v (encrypted: %v)", p.Sources, encrypted)
+		}
+	}
+}
+
 var _ builder.Target = &fakeTarget{}
 
 type fakeTarget struct {
@@ -152,3 +285,15 @@ func (ft *fakeTarget) Write(ctx context.Context, artifact types.ModelArtifact, w
 	ft.artifact = artifact
 	return nil
 }
+
+var _ builder.SignatureTarget = &fakeSignatureTarget{}
+
+type fakeSignatureTarget struct {
+	fakeTarget
+	signed types.ModelArtifact
+}
+
+func (ft *fakeSignatureTarget) WriteSignature(ctx context.Context, artifact types.ModelArtifact, signer signing.Signer) error {
+	ft.signed = artifact
+	return nil
+}
@@ -2,16 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/compression"
 
 	"github.com/docker/model-distribution/builder"
 	"github.com/docker/model-distribution/distribution"
+	"github.com/docker/model-distribution/encryption"
+	"github.com/docker/model-distribution/oci"
+	"github.com/docker/model-distribution/progress"
 	"github.com/docker/model-distribution/registry"
+	"github.com/docker/model-distribution/signing"
 	"github.com/docker/model-distribution/tarball"
+	"github.com/docker/model-distribution/types"
 )
 
 // stringSliceFlag is a flag that can be specified multiple times to collect multiple string values
@@ -32,15 +46,35 @@ const (
 )
 
 var (
-	storePath string
-	showHelp  bool
-	showVer   bool
+	storePath    string
+	showHelp     bool
+	showVer      bool
+	offline      bool
+	progressMode string
+	insecure     stringSliceFlag
+	caCert       string
+	clientCert   string
+	clientKey    string
+	verify       string
+	verifyKeys   stringSliceFlag
+	admission    string
+	decryptKeys  stringSliceFlag
 )
 
 func init() {
 	flag.StringVar(&storePath, "store-path", defaultStorePath, "Path to the model store")
 	flag.BoolVar(&showHelp, "help", false, "Show help")
 	flag.BoolVar(&showVer, "version", false, "Show version")
+	flag.BoolVar(&offline, "offline", false, "Disable all registry access and only use the local store")
+	flag.StringVar(&progressMode, "progress", "auto", "Progress output: bar, json or auto (bar on a terminal, json otherwise)")
+	flag.Var(&insecure, "insecure-registry", "Registry host to reach without TLS verification or over plain HTTP (can be specified multiple times)")
+	flag.StringVar(&caCert, "ca-cert", "", "PEM file of certificate authorities to verify registry certificates with")
+	flag.StringVar(&clientCert, "client-cert", "", "PEM file of the certificate to present to registries requiring TLS client authentication")
+	flag.StringVar(&clientKey, "client-key", "", "PEM file of the private key of --client-cert")
+	flag.StringVar(&verify, "verify", string(distribution.VerifyOff), "Signature verification policy of pulls: off, optional or required")
+	flag.Var(&verifyKeys, "verify-key", "PEM file of a public key trusted to sign models (can be specified multiple times)")
+	flag.StringVar(&admission, "admission-policy", "", "JSON or YAML file of rules that pulled models must satisfy")
+	flag.Var(&decryptKeys, "decryption-key", "PEM file of an X25519 or ECDSA private key to decrypt encrypted models with (can be specified multiple times)")
 }
 
 func main() {
@@ -56,6 +90,11 @@ func main() {
 		return
 	}
 
+	if progressMode != "auto" && progressMode != "bar" && progressMode != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid progress output %q, must be bar, json or auto\n", progressMode)
+		os.Exit(1)
+	}
+
 	// Create absolute path for store
 	absStorePath, err := filepath.Abs(storePath)
 	if err != nil {
@@ -67,6 +106,7 @@ func main() {
 	clientOpts := []distribution.Option{
 		distribution.WithStoreRootPath(absStorePath),
 		distribution.WithUserAgent("model-distribution-tool/" + version),
+		distribution.WithOffline(offline),
 	}
 
 	if username := os.Getenv("DOCKER_USERNAME"); username != "" {
@@ -75,6 +115,41 @@ func main() {
 		}
 	}
 
+	pool, cert, err := tlsSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	clientOpts = append(clientOpts, distribution.WithInsecureRegistries(insecure...), distribution.WithCACertPool(pool))
+	if cert != nil {
+		clientOpts = append(clientOpts, distribution.WithClientCertificate(*cert))
+	}
+
+	verifyOpt, err := verificationSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	clientOpts = append(clientOpts, verifyOpt)
+
+	if admission != "" {
+		rules, err := distribution.LoadAdmissionRules(admission)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		clientOpts = append(clientOpts, distribution.WithAdmissionPolicy(rules))
+	}
+
+	for _, path := range decryptKeys {
+		key, err := encryption.LoadPrivateKey(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading decryption key: %v\n", err)
+			os.Exit(1)
+		}
+		clientOpts = append(clientOpts, distribution.WithDecryptionKeys(key))
+	}
+
 	client, err := distribution.NewClient(clientOpts...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
@@ -100,14 +175,36 @@ func main() {
 		exitCode = cmdGet(client, args)
 	case "get-path":
 		exitCode = cmdGetPath(client, args)
+	case "inspect":
+		exitCode = cmdInspect(client, args)
 	case "rm":
 		exitCode = cmdRm(client, args)
 	case "tag":
 		exitCode = cmdTag(client, args)
 	case "load":
 		exitCode = cmdLoad(client, args)
+	case "save":
+		exitCode = cmdSave(client, args)
 	case "bundle":
 		exitCode = cmdBundle(client, args)
+	case "tags":
+		exitCode = cmdTags(client, args)
+	case "copy":
+		exitCode = cmdCopy(client, args)
+	case "rm-remote":
+		exitCode = cmdRmRemote(client, args)
+	case "ratelimit":
+		exitCode = cmdRateLimit(client, args)
+	case "referrers":
+		exitCode = cmdReferrers(client, args)
+	case "attach":
+		exitCode = cmdAttach(client, args)
+	case "fetch-referrer":
+		exitCode = cmdFetchReferrer(client, args)
+	case "login":
+		exitCode = cmdLogin(client, args)
+	case "logout":
+		exitCode = cmdLogout(client, args)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
 		printUsage()
@@ -117,40 +214,175 @@ func main() {
 	os.Exit(exitCode)
 }
 
+// tlsSettings loads the CA pool and client certificate given by the TLS flags. Both are nil if not set.
+func tlsSettings() (*x509.CertPool, *tls.Certificate, error) {
+	var pool *x509.CertPool
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading CA certificates: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", caCert)
+		}
+	}
+	if (clientCert == "") != (clientKey == "") {
+		return nil, nil, fmt.Errorf("--client-cert and --client-key must be used together")
+	}
+	if clientCert == "" {
+		return pool, nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+	return pool, &cert, nil
+}
+
+// loadRecipients loads the public keys of encryption recipients from the PEM files at paths
+func loadRecipients(paths []string) ([]*ecdh.PublicKey, error) {
+	var recipients []*ecdh.PublicKey
+	for _, path := range paths {
+		key, err := encryption.LoadPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading encryption recipient: %w", err)
+		}
+		recipients = append(recipients, key)
+	}
+	return recipients, nil
+}
+
+// verificationSettings returns the client option for the signature verification policy and keys given by the
+// --verify and --verify-key flags
+func verificationSettings() (distribution.Option, error) {
+	policy, err := distribution.ParseVerificationPolicy(verify)
+	if err != nil {
+		return nil, err
+	}
+	var keys []crypto.PublicKey
+	for _, path := range verifyKeys {
+		key, err := signing.LoadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return distribution.WithVerificationPolicy(policy, keys...), nil
+}
+
+// progressOutput returns the writer to report progress on stdout to, according to the --progress flag
+func progressOutput() io.Writer {
+	switch progressMode {
+	case "bar":
+		return progress.NewTerminalWriter(os.Stdout)
+	case "json":
+		return os.Stdout
+	}
+	if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		return progress.NewTerminalWriter(os.Stdout)
+	}
+	return os.Stdout
+}
+
 func printUsage() {
 	fmt.Println("Usage: model-distribution-tool [options] <command> [arguments]")
 	fmt.Println("\nOptions:")
 	flag.PrintDefaults()
 	fmt.Println("\nCommands:")
-	fmt.Println("  pull <reference>                Pull a model from a registry")
-	fmt.Println("  package <source> <reference>    Package a model file as an OCI artifact and push it to a registry (use --licenses to add license files, --mmproj for multimodal projector)")
-	fmt.Println("  push <tag>                      Push a model from the content store to the registry")
+	fmt.Println("  pull <reference>                Pull a model from a registry (use --policy to skip the registry for local models, --verify to check signatures, --referrers to store attached artifacts)")
+	fmt.Println("  package <source> <reference>    Package a model file as an OCI artifact and push it to a registry (use --licenses to add license files, --mmproj for multimodal projector, --sign-key to sign it, --encrypt-for to encrypt it, --oci-layout to write an OCI image layout, --oci-archive to write --file as an OCI image layout archive, --compress to compress it)")
+	fmt.Println("  load <path>                     Load the models in an archive or a model in an OCI image layout directory (use --tag to tag it, --oci-ref to select a model in the layout)")
+	fmt.Println("  save <reference>...             Save models with their tags from the content store to a single archive given by --output (use --compress to compress it)")
+	fmt.Println("  push <tag>                      Push a model from the content store to the registry (use --tag for extra tags, --source to push another local model, --sign-key to sign it, --encrypt-for to encrypt it, --dry-run to list blobs to upload)")
 	fmt.Println("  list                            List all models")
 	fmt.Println("  get <reference>                 Get a model by reference")
 	fmt.Println("  get-path <reference>            Get the local file path for a model")
+	fmt.Println("  inspect <reference>             Show the config and build provenance of a model (use --json for JSON output)")
 	fmt.Println("  rm <reference>                  Remove a model by reference")
 	fmt.Println("  bundle <reference>              Create a runtime bundle for model")
+	fmt.Println("  tags <repository>               List the tags of a repository in a registry (use --details to show digests and sizes)")
+	fmt.Println("  copy <source> <destination>     Copy a model between registries without using the local store (use --all-tags or --tag to mirror repositories)")
+	fmt.Println("  rm-remote <reference>           Delete a model from its registry, including every tag pointing to it")
+	fmt.Println("  ratelimit <reference>           Show the pull rate limit the registry applies to a model")
+	fmt.Println("  referrers <reference>           List the artifacts, e.g. SBOMs or model cards, that refer to a model in a registry (use --type to filter)")
+	fmt.Println("  attach <reference> <file>...    Attach files to a model in a registry as an artifact of the type given by --type")
+	fmt.Println("  fetch-referrer <ref> <digest>   Download the files of an artifact that refers to a model (use --dir to choose the directory)")
+	fmt.Println("  login <registry>                Save credentials for a registry with the store (use --username with --password-stdin, --identity-token, --registry-token or --helper)")
+	fmt.Println("  logout <registry>               Remove the credentials saved for a registry")
 	fmt.Println("\nExamples:")
 	fmt.Println("  model-distribution-tool --store-path ./models pull registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool --offline pull registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool --progress=json pull registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool --insecure-registry localhost:5000 push localhost:5000/models/llama:v1.0")
 	fmt.Println("  model-distribution-tool package ./model.gguf registry.example.com/models/llama:v1.0 --licenses ./license1.txt --licenses ./license2.txt")
 	fmt.Println("  model-distribution-tool package ./model.gguf registry.example.com/models/llama:v1.0 --mmproj ./model.mmproj")
+	fmt.Println("  model-distribution-tool package ./model.gguf --tag registry.example.com/models/llama:v1.0 --license-id Apache-2.0 --recipe https://github.com/example/recipes --recipe-commit 1a2b3c4")
+	fmt.Println("  model-distribution-tool package ./model.gguf --oci-layout ./layout --oci-ref llama-v1.0")
+	fmt.Println("  model-distribution-tool package ./model.gguf --file ./llama.tar --oci-archive --oci-ref llama-v1.0")
+	fmt.Println("  model-distribution-tool save -o ./models.tar registry.example.com/models/llama:v1.0 registry.example.com/models/phi:v2")
+	fmt.Println("  model-distribution-tool save --compress zstd -o ./models.tar.zst registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool load ./models.tar")
+	fmt.Println("  model-distribution-tool load --oci-ref llama-v1.0 --tag registry.example.com/models/llama:v1.0 ./layout")
+	fmt.Println("  model-distribution-tool inspect registry.example.com/models/llama:v1.0")
 	fmt.Println("  model-distribution-tool push registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool push --source sha256:abc123... --tag latest registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool push --sign-key ./signing-key.pem registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool --admission-policy ./policy.yaml pull registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool --verify required --verify-key ./signing-key.pub pull registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool push --encrypt-for ./recipient.pub registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool --decryption-key ./recipient-key.pem bundle registry.example.com/models/llama:v1.0")
 	fmt.Println("  model-distribution-tool list")
 	fmt.Println("  model-distribution-tool rm registry.example.com/models/llama:v1.0")
 	fmt.Println("  model-distribution-tool bundle registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool tags --details registry.example.com/models/llama")
+	fmt.Println("  model-distribution-tool copy registry.example.com/models/llama:v1.0 mirror.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool copy --all-tags registry.example.com/models/llama mirror.example.com/models/llama")
+	fmt.Println("  model-distribution-tool rm-remote registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool ratelimit docker.io/ai/smollm2:latest")
+	fmt.Println("  model-distribution-tool attach --type application/vnd.docker.ai.model.card registry.example.com/models/llama:v1.0 ./README.md")
+	fmt.Println("  model-distribution-tool referrers --type application/spdx+json registry.example.com/models/llama:v1.0")
+	fmt.Println("  model-distribution-tool fetch-referrer --dir ./card registry.example.com/models/llama:v1.0 sha256:abc123...")
+	fmt.Println("  model-distribution-tool pull --referrers application/vnd.docker.ai.model.card registry.example.com/models/llama:v1.0")
+	fmt.Println("  echo $TOKEN | model-distribution-tool login --username ci --password-stdin registry.example.com")
+	fmt.Println("  model-distribution-tool login --helper desktop docker.io")
 }
 
 func cmdPull(client *distribution.Client, args []string) int {
+	var (
+		policy    string
+		referrers stringSliceFlag
+	)
+	fs := flag.NewFlagSet("pull", flag.ExitOnError)
+	fs.StringVar(&policy, "policy", string(distribution.PullAlways), "Pull policy: always, missing or never")
+	fs.Var(&referrers, "referrers", "Also store the artifacts of the given type that refer to the model (can be specified multiple times)")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: missing reference argument\n")
-		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool pull <reference>\n")
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool pull [--policy=always|missing|never] [--referrers <artifact-type>...] <reference>\n")
+		return 1
+	}
+
+	pullPolicy, err := distribution.ParsePullPolicy(policy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
 	}
 
 	reference := args[0]
 	ctx := context.Background()
 
-	if err := client.PullModel(ctx, reference, os.Stdout); err != nil {
+	opts := []distribution.PullOption{
+		distribution.WithPullPolicy(pullPolicy),
+		distribution.WithPullReferrers(referrers...),
+	}
+	if err := client.PullModel(ctx, reference, progressOutput(), opts...); err != nil {
 		fmt.Fprintf(os.Stderr, "Error pulling model: %v\n", err)
 		return 1
 	}
@@ -165,17 +397,37 @@ func cmdPackage(args []string) int {
 		licensePaths stringSliceFlag
 		contextSize  uint64
 		file         string
+		ociLayout    string
+		ociArchive   bool
+		ociRef       string
+		compress     string
 		tag          string
 		mmproj       string
 		chatTemplate string
+		signKey      string
+		encryptFor   stringSliceFlag
+		provenance   bool
+		licenseIDs   stringSliceFlag
+		recipe       string
+		recipeCommit string
 	)
 
 	fs.Var(&licensePaths, "licenses", "Paths to license files (can be specified multiple times)")
 	fs.Uint64Var(&contextSize, "context-size", 0, "Context size in tokens")
 	fs.StringVar(&mmproj, "mmproj", "", "Path to Multimodal Projector file")
 	fs.StringVar(&file, "file", "", "Write archived model to the given file")
+	fs.StringVar(&ociLayout, "oci-layout", "", "Write model to the OCI image layout in the given directory")
+	fs.BoolVar(&ociArchive, "oci-archive", false, "Write the --file archive as an OCI image layout, e.g. for skopeo's oci-archive transport")
+	fs.StringVar(&ociRef, "oci-ref", "", "Ref name of the model in the OCI image layout, e.g. latest (requires --oci-layout or --oci-archive)")
+	fs.StringVar(&compress, "compress", "", "Compress the --file archive with gzip or zstd")
 	fs.StringVar(&tag, "tag", "", "Push model to the given registry tag")
 	fs.StringVar(&chatTemplate, "chat-template", "", "Jinja chat template file")
+	fs.StringVar(&signKey, "sign-key", "", "Sign the model with the ed25519 or ECDSA private key in the given PEM file (requires --tag)")
+	fs.Var(&encryptFor, "encrypt-for", "Encrypt the GGUF and multimodal projector files for the X25519 or ECDSA public key in the given PEM file (can be specified multiple times)")
+	fs.BoolVar(&provenance, "provenance", false, "Record the build provenance (tool, host and source files) in the model descriptor")
+	fs.Var(&licenseIDs, "license-id", "SPDX identifier of a model license to record in the provenance, e.g. Apache-2.0 (can be specified multiple times)")
+	fs.StringVar(&recipe, "recipe", "", "Location of the build recipe to record in the provenance, e.g. a git repository URL")
+	fs.StringVar(&recipeCommit, "recipe-commit", "", "Git commit of the build recipe to record in the provenance")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool package [OPTIONS] <path-to-gguf>\n\n")
@@ -194,8 +446,28 @@ func cmdPackage(args []string) int {
 		fs.Usage()
 		return 1
 	}
-	if file == "" && tag == "" {
-		fmt.Fprintf(os.Stderr, "Error: one of --file or --tag is required\n")
+	if file == "" && ociLayout == "" && tag == "" {
+		fmt.Fprintf(os.Stderr, "Error: one of --file, --oci-layout or --tag is required\n")
+		fs.Usage()
+		return 1
+	}
+	if compress != "" && file == "" {
+		fmt.Fprintf(os.Stderr, "Error: --compress requires --file\n")
+		fs.Usage()
+		return 1
+	}
+	if ociArchive && file == "" {
+		fmt.Fprintf(os.Stderr, "Error: --oci-archive requires --file\n")
+		fs.Usage()
+		return 1
+	}
+	if ociRef != "" && ociLayout == "" && !ociArchive {
+		fmt.Fprintf(os.Stderr, "Error: --oci-ref requires --oci-layout or --oci-archive\n")
+		fs.Usage()
+		return 1
+	}
+	if signKey != "" && tag == "" {
+		fmt.Fprintf(os.Stderr, "Error: --sign-key requires --tag\n")
 		fs.Usage()
 		return 1
 	}
@@ -227,15 +499,36 @@ func cmdPackage(args []string) int {
 		}
 	}
 
+	pool, cert, err := tlsSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	registryClientOpts = append(registryClientOpts, registry.WithInsecureRegistries(insecure...), registry.WithCACertPool(pool))
+	if cert != nil {
+		registryClientOpts = append(registryClientOpts, registry.WithClientCertificate(*cert))
+	}
+
 	// Create registry client once with all options
 	registryClient := registry.NewClient(registryClientOpts...)
 
-	var (
-		target builder.Target
-		err    error
-	)
+	var target builder.Target
+	var refNames []string
+	if ociRef != "" {
+		refNames = append(refNames, ociRef)
+	}
 	if file != "" {
-		target = tarball.NewFileTarget(file)
+		opts := []tarball.TargetOption{tarball.WithCompression(compression.Compression(compress))}
+		if ociArchive {
+			opts = append(opts, tarball.WithOCILayout(refNames...))
+		}
+		if _, err := tarball.NewTarget(io.Discard, opts...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		target = tarball.NewFileTarget(file, opts...)
+	} else if ociLayout != "" {
+		target = oci.NewTarget(ociLayout, refNames...)
 	} else {
 		target, err = registryClient.NewTarget(tag)
 		if err != nil {
@@ -284,13 +577,48 @@ func cmdPackage(args []string) int {
 		}
 	}
 
+	if signKey != "" {
+		signer, err := signing.LoadSigner(signKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading signing key: %v\n", err)
+			return 1
+		}
+		builder = builder.WithSigner(signer)
+	}
+
+	if len(encryptFor) > 0 {
+		recipients, err := loadRecipients(encryptFor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		builder = builder.WithEncryption(recipients...)
+	}
+
+	if provenance || len(licenseIDs) > 0 || recipe != "" || recipeCommit != "" {
+		p := types.Provenance{
+			Tool:     types.Tool{Name: "model-distribution-tool", Version: version},
+			Licenses: licenseIDs,
+		}
+		if recipe != "" || recipeCommit != "" {
+			p.Recipe = &types.Recipe{URI: recipe, Commit: recipeCommit}
+		}
+		builder, err = builder.WithProvenance(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording provenance: %v\n", err)
+			return 1
+		}
+	}
+
 	// Push the image
-	if err := builder.Build(ctx, target, os.Stdout); err != nil {
+	if err := builder.Build(ctx, target, progressOutput()); err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing model to registry: %v\n", err)
 		return 1
 	}
 	if tag != "" {
 		fmt.Printf("Successfully packaged and pushed model: %s\n", tag)
+	} else if ociLayout != "" {
+		fmt.Printf("Successfully packaged model to OCI image layout: %s\n", ociLayout)
 	} else {
 		fmt.Printf("Successfully packaged model to file: %s\n", file)
 	}
@@ -300,11 +628,13 @@ func cmdPackage(args []string) int {
 func cmdLoad(client *distribution.Client, args []string) int {
 	fs := flag.NewFlagSet("load", flag.ExitOnError)
 	var (
-		tag string
+		tag    string
+		ociRef string
 	)
 	fs.StringVar(&tag, "tag", "", "Apply tag to the loaded model")
+	fs.StringVar(&ociRef, "oci-ref", "", "Ref name or digest of the model to load from an OCI image layout directory")
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool load [OPTIONS] <path-to-archive>\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool load [OPTIONS] <path-to-archive-or-oci-layout>\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		fs.PrintDefaults()
 	}
@@ -322,37 +652,172 @@ func cmdLoad(client *distribution.Client, args []string) int {
 	}
 	path := args[0]
 
-	f, err := os.Open(path)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening model file: %v\n", err)
+	var ids []string
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		id, err := client.LoadFromOCILayout(path, ociRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+			return 1
+		}
+		ids = append(ids, id)
+	} else {
+		if ociRef != "" {
+			fmt.Fprintf(os.Stderr, "Error: --oci-ref requires an OCI image layout directory\n")
+			return 1
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening model file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		ids, err = client.LoadModels(context.Background(), f, progressOutput())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+			return 1
+		}
+	}
+	for _, id := range ids {
+		fmt.Fprintln(os.Stdout, "Loaded model:", id)
+	}
+	if tag == "" {
+		return 0
+	}
+	if len(ids) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --tag cannot be applied to an archive with %d models\n", len(ids))
 		return 1
 	}
-	defer f.Close()
+	if err := client.Tag(ids[0], tag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error tagging model: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(os.Stdout, "Tagged model:", tag)
+	return 0
+}
 
-	id, err := client.LoadModel(f, os.Stdout)
+func cmdSave(client *distribution.Client, args []string) int {
+	fs := flag.NewFlagSet("save", flag.ExitOnError)
+	var (
+		output   string
+		compress string
+	)
+	fs.StringVar(&output, "o", "", "Write the archive to the given file")
+	fs.StringVar(&output, "output", "", "Write the archive to the given file")
+	fs.StringVar(&compress, "compress", "", "Compress the archive with gzip or zstd")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool save -o <file> <reference>...\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: missing reference argument\n")
+		fs.Usage()
+		return 1
+	}
+	if output == "" {
+		fmt.Fprintf(os.Stderr, "Error: --output is required\n")
+		fs.Usage()
+		return 1
+	}
+
+	compressionOpt := tarball.WithCompression(compression.Compression(compress))
+	if _, err := tarball.NewTarget(io.Discard, compressionOpt); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	f, err := os.Create(output)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error creating archive: %v\n", err)
 		return 1
 	}
-	fmt.Fprintln(os.Stdout, "Loaded model:", id)
-	if err := client.Tag(id, tag); err != nil {
-		fmt.Fprintf(os.Stderr, "Error tagging model: %v\n", err)
+	err = client.SaveModels(context.Background(), args, f, progressOutput(), compressionOpt)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
-	fmt.Fprintln(os.Stdout, "Tagged model:", tag)
+	if err != nil {
+		os.Remove(output)
+		fmt.Fprintf(os.Stderr, "Error saving models: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Successfully saved %s to %s\n", strings.Join(args, ", "), output)
 	return 0
 }
 
 func cmdPush(client *distribution.Client, args []string) int {
+	var (
+		source     string
+		tags       stringSliceFlag
+		dryRun     bool
+		signKey    string
+		encryptFor stringSliceFlag
+	)
+	fs := flag.NewFlagSet("push", flag.ExitOnError)
+	fs.StringVar(&source, "source", "", "Local model to push, by tag or ID (defaults to the destination tag)")
+	fs.Var(&tags, "tag", "Extra tag to push in the destination repository (can be specified multiple times)")
+	fs.BoolVar(&dryRun, "dry-run", false, "List the blobs that would be uploaded without pushing")
+	fs.StringVar(&signKey, "sign-key", "", "Sign the model with the ed25519 or ECDSA private key in the given PEM file")
+	fs.Var(&encryptFor, "encrypt-for", "Encrypt the GGUF and multimodal projector files for the X25519 or ECDSA public key in the given PEM file (can be specified multiple times)")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: missing tag argument\n")
-		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool push <tag>\n")
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool push [--source <reference>] [--tag <tag>...] [--sign-key <file>] [--encrypt-for <file>...] [--dry-run] <tag>\n")
 		return 1
 	}
 
 	tag := args[0]
 	ctx := context.Background()
+	opts := []distribution.PushOption{
+		distribution.WithPushSource(source),
+		distribution.WithPushTags(tags...),
+	}
+	if signKey != "" {
+		signer, err := signing.LoadSigner(signKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading signing key: %v\n", err)
+			return 1
+		}
+		opts = append(opts, distribution.WithPushSigner(signer))
+	}
+	if len(encryptFor) > 0 {
+		recipients, err := loadRecipients(encryptFor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		opts = append(opts, distribution.WithPushEncryption(recipients...))
+	}
+
+	if dryRun {
+		blobs, err := client.PushDryRun(ctx, tag, opts...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking blobs to push: %v\n", err)
+			return 1
+		}
+		if len(blobs) == 0 {
+			fmt.Println("No blobs to upload")
+			return 0
+		}
+		for _, blob := range blobs {
+			fmt.Printf("%s\t%s\t%.2f MB\n", blob.Digest, blob.MediaType, float64(blob.Size)/1024/1024)
+		}
+		return 0
+	}
 
-	if err := client.PushModel(ctx, tag, os.Stdout); err != nil {
+	if err := client.PushModel(ctx, tag, progressOutput(), opts...); err != nil {
 		fmt.Fprintf(os.Stderr, "Error pushing model: %v\n", err)
 		return 1
 	}
@@ -440,6 +905,100 @@ func cmdGet(client *distribution.Client, args []string) int {
 	return 0
 }
 
+func cmdInspect(client *distribution.Client, args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	var asJSON bool
+	fs.BoolVar(&asJSON, "json", false, "Print the config and descriptor as JSON")
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: missing reference argument\n")
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool inspect [--json] <reference>\n")
+		return 1
+	}
+
+	reference := args[0]
+	model, err := client.GetModel(reference)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting model: %v\n", err)
+		return 1
+	}
+	id, err := model.ID()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting model ID: %v\n", err)
+		return 1
+	}
+	cfg, err := model.Config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading model config: %v\n", err)
+		return 1
+	}
+	desc, err := model.Descriptor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading model descriptor: %v\n", err)
+		return 1
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			ID         string           `json:"id"`
+			Tags       []string         `json:"tags"`
+			Config     types.Config     `json:"config"`
+			Descriptor types.Descriptor `json:"descriptor"`
+		}{id, model.Tags(), cfg, desc}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding model: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Printf("ID: %s\n", id)
+	fmt.Printf("Tags: %s\n", strings.Join(model.Tags(), ", "))
+	fmt.Printf("Format: %s\n", cfg.Format)
+	fmt.Printf("Architecture: %s\n", cfg.Architecture)
+	fmt.Printf("Parameters: %s\n", cfg.Parameters)
+	fmt.Printf("Quantization: %s\n", cfg.Quantization)
+	fmt.Printf("Size: %s\n", cfg.Size)
+	if desc.Created != nil {
+		fmt.Printf("Created: %s\n", desc.Created.Format(time.RFC3339))
+	}
+
+	p := desc.Provenance
+	if p == nil {
+		fmt.Println("Provenance: none")
+		return 0
+	}
+	fmt.Println("Provenance:")
+	fmt.Printf("  Tool: %s %s\n", p.Tool.Name, p.Tool.Version)
+	fmt.Printf("  Host: %s\n", p.Host)
+	if p.Recipe != nil && p.Recipe.URI != "" {
+		fmt.Printf("  Recipe: %s\n", p.Recipe.URI)
+	}
+	if p.Recipe != nil && p.Recipe.Commit != "" {
+		fmt.Printf("  Recipe commit: %s\n", p.Recipe.Commit)
+	}
+	if len(p.Licenses) > 0 {
+		fmt.Printf("  Licenses: %s\n", strings.Join(p.Licenses, ", "))
+	}
+	if len(p.Sources) > 0 {
+		fmt.Println("  Sources:")
+		for _, src := range p.Sources {
+			digest := src.Digest
+			if digest == "" {
+				digest = "(encrypted)"
+			}
+			fmt.Printf("    %s\t%s\t%s\n", src.Name, digest, src.MediaType)
+		}
+	}
+	return 0
+}
+
 func cmdGetPath(client *distribution.Client, args []string) int {
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: missing reference argument\n")
@@ -525,3 +1084,287 @@ func cmdBundle(client *distribution.Client, args []string) int {
 	fmt.Fprint(os.Stdout, bundle.RootDir())
 	return 0
 }
+
+func cmdTags(client *distribution.Client, args []string) int {
+	var details bool
+	fs := flag.NewFlagSet("tags", flag.ExitOnError)
+	fs.BoolVar(&details, "details", false, "Show the digest and size of each tag")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: missing repository argument\n")
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool tags [--details] <repository>\n")
+		return 1
+	}
+
+	repository := args[0]
+	ctx := context.Background()
+
+	tags, err := client.ListRemoteTags(ctx, repository, details)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing tags: %v\n", err)
+		return 1
+	}
+
+	if len(tags) == 0 {
+		fmt.Println("No tags found")
+		return 0
+	}
+
+	for _, tag := range tags {
+		if details {
+			fmt.Printf("%s\t%s\t%.2f MB\n", tag.Name, tag.Digest, float64(tag.Size)/1024/1024)
+		} else {
+			fmt.Println(tag.Name)
+		}
+	}
+	return 0
+}
+
+func cmdCopy(client *distribution.Client, args []string) int {
+	var (
+		allTags bool
+		tags    stringSliceFlag
+	)
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	fs.BoolVar(&allTags, "all-tags", false, "Mirror every tag of the source repository to the destination repository")
+	fs.Var(&tags, "tag", "Mirror the given tag of the source repository to the destination repository (can be specified multiple times)")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "Error: missing source or destination argument\n")
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool copy [--all-tags | --tag <tag>...] <source> <destination>\n")
+		return 1
+	}
+
+	source := args[0]
+	destination := args[1]
+	ctx := context.Background()
+
+	if !allTags && len(tags) == 0 {
+		if err := client.Copy(ctx, source, destination, progressOutput()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error copying model: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Successfully copied model %s to %s\n", source, destination)
+		return 0
+	}
+
+	copied, err := client.Mirror(ctx, source, destination, tags, progressOutput())
+	for _, tag := range copied {
+		fmt.Printf("Copied tag: %s\n", tag)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error mirroring models: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Successfully mirrored %d tags from %s to %s\n", len(copied), source, destination)
+	return 0
+}
+
+func cmdRmRemote(client *distribution.Client, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool rm-remote <reference>\n")
+		return 1
+	}
+
+	reference := args[0]
+	digest, err := client.DeleteRemote(context.Background(), reference)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error deleting model from registry: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Successfully deleted model %s from registry: %s\n", reference, digest)
+	return 0
+}
+
+func cmdRateLimit(client *distribution.Client, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool ratelimit <reference>\n")
+		return 1
+	}
+
+	status, err := client.RateLimitStatus(context.Background(), args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking rate limit: %v\n", err)
+		return 1
+	}
+
+	if !status.Enforced {
+		fmt.Println("No rate limit reported")
+		return 0
+	}
+	fmt.Printf("Remaining: %d of %d", status.Remaining, status.Limit)
+	if status.Window > 0 {
+		fmt.Printf(" per %s", status.Window)
+	}
+	fmt.Println()
+	if status.Source != "" {
+		fmt.Printf("Source: %s\n", status.Source)
+	}
+	return 0
+}
+
+func cmdReferrers(client *distribution.Client, args []string) int {
+	var artifactType string
+	fs := flag.NewFlagSet("referrers", flag.ExitOnError)
+	fs.StringVar(&artifactType, "type", "", "Only list artifacts of the given artifact type")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool referrers [--type <artifact-type>] <reference>\n")
+		return 1
+	}
+
+	descs, err := client.Referrers(context.Background(), args[0], artifactType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing referrers: %v\n", err)
+		return 1
+	}
+	if len(descs) == 0 {
+		fmt.Println("No referrers found")
+		return 0
+	}
+	for _, desc := range descs {
+		fmt.Printf("%s\t%s\n", desc.Digest, desc.ArtifactType)
+	}
+	return 0
+}
+
+func cmdAttach(client *distribution.Client, args []string) int {
+	var artifactType string
+	fs := flag.NewFlagSet("attach", flag.ExitOnError)
+	fs.StringVar(&artifactType, "type", "", "Artifact type of the attached files, e.g. application/spdx+json")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) < 2 || artifactType == "" {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool attach --type <artifact-type> <reference> <file>...\n")
+		return 1
+	}
+
+	digest, err := client.Attach(context.Background(), args[0], artifactType, args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error attaching files: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Successfully attached %s to %s: %s\n", artifactType, args[0], digest)
+	return 0
+}
+
+func cmdFetchReferrer(client *distribution.Client, args []string) int {
+	var dir string
+	fs := flag.NewFlagSet("fetch-referrer", flag.ExitOnError)
+	fs.StringVar(&dir, "dir", ".", "Directory to write the artifact's files to")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool fetch-referrer [--dir <directory>] <reference> <digest>\n")
+		return 1
+	}
+
+	paths, err := client.FetchReferrer(context.Background(), args[0], args[1], dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching referrer: %v\n", err)
+		return 1
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	return 0
+}
+
+func cmdLogin(client *distribution.Client, args []string) int {
+	var (
+		cred          registry.Credential
+		passwordStdin bool
+	)
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	fs.StringVar(&cred.Username, "username", "", "Username")
+	fs.BoolVar(&passwordStdin, "password-stdin", false, "Read the password from stdin")
+	fs.StringVar(&cred.IdentityToken, "identity-token", "", "Identity token to exchange for registry tokens")
+	fs.StringVar(&cred.RegistryToken, "registry-token", "", "Bearer token to send to the registry as is")
+	fs.StringVar(&cred.Helper, "helper", "", "Name of the credential helper to get credentials from, e.g. desktop for docker-credential-desktop")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool login [OPTIONS] <registry>\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		return 1
+	}
+	args = fs.Args()
+
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: missing registry argument\n")
+		fs.Usage()
+		return 1
+	}
+
+	if passwordStdin {
+		password, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading password: %v\n", err)
+			return 1
+		}
+		cred.Password = strings.TrimRight(string(password), "\r\n")
+	}
+	if (cred.Username == "") != (cred.Password == "") {
+		fmt.Fprintf(os.Stderr, "Error: --username and --password-stdin must be used together\n")
+		return 1
+	}
+	if cred.Username == "" && cred.IdentityToken == "" && cred.RegistryToken == "" && cred.Helper == "" {
+		fmt.Fprintf(os.Stderr, "Error: no credentials given\n")
+		fs.Usage()
+		return 1
+	}
+
+	if err := client.Login(context.Background(), args[0], cred); err != nil {
+		fmt.Fprintf(os.Stderr, "Error logging in: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Login succeeded: %s\n", args[0])
+	return 0
+}
+
+func cmdLogout(client *distribution.Client, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: model-distribution-tool logout <registry>\n")
+		return 1
+	}
+
+	if err := client.Logout(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error logging out: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Removed credentials for %s\n", args[0])
+	return 0
+}
@@ -134,6 +134,21 @@ func TestMainGet(t *testing.T) {
 	}
 }
 
+// TestMainInspect tests the inspect command
+func TestMainInspect(t *testing.T) {
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if exitCode := cmdInspect(client, []string{}); exitCode != 1 {
+		t.Errorf("Inspect command with invalid arguments should fail")
+	}
+	if exitCode := cmdInspect(client, []string{"--json", "missing/model:v1"}); exitCode != 1 {
+		t.Errorf("Inspect command for a missing model should fail")
+	}
+}
+
 // TestMainGetPath tests the get-path command
 func TestMainGetPath(t *testing.T) {
 	// Create a temporary directory for the test
@@ -177,3 +192,212 @@ func TestMainPush(t *testing.T) {
 		t.Errorf("Push command with invalid arguments should fail")
 	}
 }
+
+// TestMainLoad tests the load command
+func TestMainLoad(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the load command with invalid arguments
+	exitCode := cmdLoad(client, []string{})
+	if exitCode != 1 {
+		t.Errorf("Load command with invalid arguments should fail")
+	}
+
+	// Test the load command with a directory that is not an OCI image layout
+	exitCode = cmdLoad(client, []string{"--oci-ref", "latest", tempDir})
+	if exitCode != 1 {
+		t.Errorf("Load command with a directory that is not an OCI image layout should fail")
+	}
+}
+
+// TestMainSave tests the save command
+func TestMainSave(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the save command with invalid arguments
+	exitCode := cmdSave(client, []string{})
+	if exitCode != 1 {
+		t.Errorf("Save command with invalid arguments should fail")
+	}
+
+	// Test the save command with an unsupported compression
+	exitCode = cmdSave(client, []string{"-o", filepath.Join(tempDir, "models.tar.lz4"), "--compress", "lz4", "some/model:v1"})
+	if exitCode != 1 {
+		t.Errorf("Save command with an unsupported compression should fail")
+	}
+
+	// Test the save command with a model that is not in the store
+	output := filepath.Join(tempDir, "models.tar")
+	exitCode = cmdSave(client, []string{"-o", output, "some/missing:model"})
+	if exitCode != 1 {
+		t.Errorf("Save command with a missing model should fail")
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Save command should remove the incomplete archive")
+	}
+}
+
+// TestMainTags tests the tags command
+func TestMainTags(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the tags command with invalid arguments
+	exitCode := cmdTags(client, []string{})
+	if exitCode != 1 {
+		t.Errorf("Tags command with invalid arguments should fail")
+	}
+}
+
+// TestMainCopy tests the copy command
+func TestMainCopy(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the copy command with invalid arguments
+	exitCode := cmdCopy(client, []string{"only-source"})
+	if exitCode != 1 {
+		t.Errorf("Copy command with invalid arguments should fail")
+	}
+}
+
+// TestMainRmRemote tests the rm-remote command
+func TestMainRmRemote(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the rm-remote command with invalid arguments
+	exitCode := cmdRmRemote(client, []string{})
+	if exitCode != 1 {
+		t.Errorf("Rm-remote command with invalid arguments should fail")
+	}
+}
+
+// TestMainRateLimit tests the ratelimit command
+func TestMainRateLimit(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the ratelimit command with invalid arguments
+	exitCode := cmdRateLimit(client, []string{})
+	if exitCode != 1 {
+		t.Errorf("Ratelimit command with invalid arguments should fail")
+	}
+}
+
+// TestMainLogin tests the login and logout commands
+func TestMainLogin(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the login command without credentials
+	exitCode := cmdLogin(client, []string{"registry.example.com"})
+	if exitCode != 1 {
+		t.Errorf("Login command without credentials should fail")
+	}
+
+	// Test the logout command without saved credentials
+	exitCode = cmdLogout(client, []string{"registry.example.com"})
+	if exitCode != 1 {
+		t.Errorf("Logout command without saved credentials should fail")
+	}
+}
+
+// TestMainReferrers tests the referrers, attach and fetch-referrer commands
+func TestMainReferrers(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a client for testing
+	client, err := distribution.NewClient(distribution.WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test the commands with invalid arguments
+	if exitCode := cmdReferrers(client, []string{}); exitCode != 1 {
+		t.Errorf("Referrers command with invalid arguments should fail")
+	}
+	if exitCode := cmdAttach(client, []string{"registry.example.com/models/llama:v1.0", "README.md"}); exitCode != 1 {
+		t.Errorf("Attach command without artifact type should fail")
+	}
+	if exitCode := cmdFetchReferrer(client, []string{"registry.example.com/models/llama:v1.0"}); exitCode != 1 {
+		t.Errorf("Fetch-referrer command with invalid arguments should fail")
+	}
+}
@@ -0,0 +1,200 @@
+package distribution
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/docker/model-distribution/types"
+)
+
+// AdmissionRequest describes a model that is about to be pulled. It is built from the manifest and config only, before
+// any layer is downloaded.
+type AdmissionRequest struct {
+	// Reference is the reference being pulled
+	Reference string
+	// Registry is the host of the registry the reference points to, e.g. index.docker.io
+	Registry string
+	// Digest is the digest of the model's manifest
+	Digest string
+	// Config is the model config
+	Config types.Config
+	// Layers are the descriptors of the model's layers, with their media types and sizes
+	Layers []v1.Descriptor
+	// Size is the combined size of the config and layers in bytes
+	Size int64
+	// Signed is true if the model has a valid signature by one of the client's trusted keys
+	Signed bool
+}
+
+// Licenses returns the descriptors of the model's license layers
+func (r AdmissionRequest) Licenses() []v1.Descriptor {
+	var licenses []v1.Descriptor
+	for _, layer := range r.Layers {
+		if layer.MediaType == types.MediaTypeLicense {
+			licenses = append(licenses, layer)
+		}
+	}
+	return licenses
+}
+
+// AdmissionPolicy decides whether a model may be pulled. Admit returns a *PolicyDeniedError to deny the pull.
+type AdmissionPolicy interface {
+	Admit(ctx context.Context, req AdmissionRequest) error
+}
+
+// AdmissionPolicyFunc adapts a function to an AdmissionPolicy
+type AdmissionPolicyFunc func(ctx context.Context, req AdmissionRequest) error
+
+// Admit calls f(ctx, req)
+func (f AdmissionPolicyFunc) Admit(ctx context.Context, req AdmissionRequest) error {
+	return f(ctx, req)
+}
+
+// WithAdmissionPolicy evaluates the policy before every pull that contacts the registry
+func WithAdmissionPolicy(policy AdmissionPolicy) Option {
+	return func(o *options) {
+		if policy != nil {
+			o.admission = policy
+		}
+	}
+}
+
+// AdmissionRules is a declarative AdmissionPolicy. Empty rules allow everything.
+type AdmissionRules struct {
+	// AllowedRegistries are the registry hosts models may be pulled from. Entries may use path.Match patterns, e.g.
+	// "*.example.com". Docker Hub may be given as docker.io.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty" yaml:"allowedRegistries,omitempty"`
+	// MaxSize is the maximum combined size of a model's config and layers in bytes
+	MaxSize int64 `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	// AllowedFormats are the allowed model formats, e.g. gguf
+	AllowedFormats []string `json:"allowedFormats,omitempty" yaml:"allowedFormats,omitempty"`
+	// AllowedArchitectures are the allowed model architectures, e.g. llama
+	AllowedArchitectures []string `json:"allowedArchitectures,omitempty" yaml:"allowedArchitectures,omitempty"`
+	// RequireLicense denies models without a license layer
+	RequireLicense bool `json:"requireLicense,omitempty" yaml:"requireLicense,omitempty"`
+	// DeniedDigests are manifest digests that may not be pulled
+	DeniedDigests []string `json:"deniedDigests,omitempty" yaml:"deniedDigests,omitempty"`
+	// RequireSignature denies models without a valid signature by one of the client's trusted keys
+	RequireSignature bool `json:"requireSignature,omitempty" yaml:"requireSignature,omitempty"`
+}
+
+// LoadAdmissionRules reads admission rules from a JSON or YAML file. Unknown fields are rejected.
+func LoadAdmissionRules(path string) (*AdmissionRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading admission rules: %w", err)
+	}
+	return ParseAdmissionRules(data)
+}
+
+// ParseAdmissionRules parses admission rules in JSON or YAML. Unknown fields are rejected.
+func ParseAdmissionRules(data []byte) (*AdmissionRules, error) {
+	// JSON is valid YAML, so both are parsed as YAML
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	rules := &AdmissionRules{}
+	if err := dec.Decode(rules); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing admission rules: %w", err)
+	}
+	for _, pattern := range rules.AllowedRegistries {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid allowed registry %q: %w", pattern, err)
+		}
+	}
+	if rules.MaxSize < 0 {
+		return nil, fmt.Errorf("invalid max size %d", rules.MaxSize)
+	}
+	return rules, nil
+}
+
+// Admit evaluates every rule against the request and returns a *PolicyDeniedError listing all violations
+func (r *AdmissionRules) Admit(_ context.Context, req AdmissionRequest) error {
+	var reasons []string
+	if len(r.AllowedRegistries) > 0 && !r.registryAllowed(req.Registry) {
+		reasons = append(reasons, fmt.Sprintf("registry %q is not allowed", req.Registry))
+	}
+	if r.MaxSize > 0 && req.Size > r.MaxSize {
+		reasons = append(reasons, fmt.Sprintf("size %d bytes exceeds the maximum of %d bytes", req.Size, r.MaxSize))
+	}
+	if len(r.AllowedFormats) > 0 && !slices.Contains(r.AllowedFormats, string(req.Config.Format)) {
+		reasons = append(reasons, fmt.Sprintf("format %q is not allowed", req.Config.Format))
+	}
+	if len(r.AllowedArchitectures) > 0 && !slices.Contains(r.AllowedArchitectures, req.Config.Architecture) {
+		reasons = append(reasons, fmt.Sprintf("architecture %q is not allowed", req.Config.Architecture))
+	}
+	if r.RequireLicense && len(req.Licenses()) == 0 {
+		reasons = append(reasons, "model has no license")
+	}
+	if slices.Contains(r.DeniedDigests, req.Digest) {
+		reasons = append(reasons, fmt.Sprintf("digest %s is denied", req.Digest))
+	}
+	if r.RequireSignature && !req.Signed {
+		reasons = append(reasons, "model is not signed by a trusted key")
+	}
+	if len(reasons) > 0 {
+		return &PolicyDeniedError{Reference: req.Reference, Reasons: reasons}
+	}
+	return nil
+}
+
+func (r *AdmissionRules) registryAllowed(registry string) bool {
+	for _, pattern := range r.AllowedRegistries {
+		if reg, err := name.NewRegistry(pattern); err == nil {
+			pattern = reg.RegistryStr()
+		}
+		if ok, _ := path.Match(pattern, registry); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// admit builds the admission request for a remote model and evaluates the client's admission policy
+func (c *Client) admit(ctx context.Context, reference string, model types.ModelArtifact, signed bool) error {
+	if c.admission == nil {
+		return nil
+	}
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return &ReferenceError{Reference: reference, Err: err}
+	}
+	digest, err := model.Digest()
+	if err != nil {
+		return fmt.Errorf("getting model digest: %w", err)
+	}
+	manifest, err := model.Manifest()
+	if err != nil {
+		return fmt.Errorf("getting model manifest: %w", err)
+	}
+	cfg, err := model.Config()
+	if err != nil {
+		return fmt.Errorf("getting model config: %w", err)
+	}
+	req := AdmissionRequest{
+		Reference: reference,
+		Registry:  ref.Context().RegistryStr(),
+		Digest:    digest.String(),
+		Config:    cfg,
+		Layers:    manifest.Layers,
+		Size:      manifest.Config.Size,
+		Signed:    signed,
+	}
+	for _, layer := range manifest.Layers {
+		req.Size += layer.Size
+	}
+	if err := c.admission.Admit(ctx, req); err != nil {
+		c.log.Warnln("Model denied by admission policy:", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package distribution
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/internal/partial"
+	"github.com/docker/model-distribution/signing"
+	"github.com/docker/model-distribution/types"
+)
+
+func TestParseAdmissionRules(t *testing.T) {
+	expected := &AdmissionRules{
+		AllowedRegistries:    []string{"docker.io", "*.example.com"},
+		MaxSize:              1024,
+		AllowedFormats:       []string{"gguf"},
+		AllowedArchitectures: []string{"llama"},
+		RequireLicense:       true,
+		DeniedDigests:        []string{"sha256:0000000000000000000000000000000000000000000000000000000000000001"},
+		RequireSignature:     true,
+	}
+	yamlRules := `
+allowedRegistries:
+  - docker.io
+  - "*.example.com"
+maxSize: 1024
+allowedFormats: [gguf]
+allowedArchitectures: [llama]
+requireLicense: true
+deniedDigests:
+  - sha256:0000000000000000000000000000000000000000000000000000000000000001
+requireSignature: true
+`
+	jsonRules := `{
+  "allowedRegistries": ["docker.io", "*.example.com"],
+  "maxSize": 1024,
+  "allowedFormats": ["gguf"],
+  "allowedArchitectures": ["llama"],
+  "requireLicense": true,
+  "deniedDigests": ["sha256:0000000000000000000000000000000000000000000000000000000000000001"],
+  "requireSignature": true
+}`
+	for name, data := range map[string]string{"yaml": yamlRules, "json": jsonRules} {
+		t.Run(name, func(t *testing.T) {
+			rules, err := ParseAdmissionRules([]byte(data))
+			if err != nil {
+				t.Fatalf("Failed to parse rules: %v", err)
+			}
+			if !reflect.DeepEqual(rules, expected) {
+				t.Fatalf("Expected %+v, got %+v", expected, rules)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		rules, err := ParseAdmissionRules(nil)
+		if err != nil {
+			t.Fatalf("Failed to parse rules: %v", err)
+		}
+		if !reflect.DeepEqual(rules, &AdmissionRules{}) {
+			t.Fatalf("Expected empty rules, got %+v", rules)
+		}
+	})
+
+	for name, data := range map[string]string{
+		"unknown field":    "maxsize: 1024",
+		"invalid pattern":  "allowedRegistries: ['[']",
+		"negative size":    "maxSize: -1",
+		"invalid document": "allowedFormats: gguf",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseAdmissionRules([]byte(data)); err == nil {
+				t.Fatalf("Expected error for %q", data)
+			}
+		})
+	}
+}
+
+func TestAdmissionPolicy(t *testing.T) {
+	recorder := &requestRecorder{method: http.MethodGet, path: "/blobs/"}
+	server := httptest.NewServer(recorder.wrap(registry.New()))
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	licensedRef := registryURL.Host + "/licensed/model:v1"
+	unlicensedRef := registryURL.Host + "/unlicensed/model:v1"
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := signing.NewSigner(key)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	// Push a signed model with a license and an unsigned model without one
+	pusher, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	license, err := partial.NewLayer(filepath.Join("..", "assets", "license.txt"), types.MediaTypeLicense)
+	if err != nil {
+		t.Fatalf("Failed to create license layer: %v", err)
+	}
+	licensed := mutate.AppendLayers(mdl, license)
+	if err := pusher.store.Write(licensed, []string{licensedRef}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := pusher.store.Write(mdl, []string{unlicensedRef}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := pusher.PushModel(t.Context(), licensedRef, nil, WithPushSigner(signer)); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	if err := pusher.PushModel(t.Context(), unlicensedRef, nil); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	unlicensedDigest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	cfg, err := mdl.Config()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+	recorder.reset()
+
+	for _, tc := range []struct {
+		name      string
+		reference string
+		rules     AdmissionRules
+		reasons   []string
+	}{
+		{
+			name:      "empty rules",
+			reference: unlicensedRef,
+		},
+		{
+			name:      "all rules satisfied",
+			reference: licensedRef,
+			rules: AdmissionRules{
+				AllowedRegistries:    []string{"registry.example.com", "127.0.0.1:*"},
+				MaxSize:              1 << 30,
+				AllowedFormats:       []string{string(cfg.Format)},
+				AllowedArchitectures: []string{cfg.Architecture},
+				RequireLicense:       true,
+				DeniedDigests:        []string{unlicensedDigest.String()},
+				RequireSignature:     true,
+			},
+		},
+		{
+			name:      "registry not allowed",
+			reference: unlicensedRef,
+			rules:     AdmissionRules{AllowedRegistries: []string{"docker.io"}},
+			reasons:   []string{"registry " + `"` + registryURL.Host + `"` + " is not allowed"},
+		},
+		{
+			name:      "every violation is reported",
+			reference: unlicensedRef,
+			rules: AdmissionRules{
+				MaxSize:              1,
+				AllowedFormats:       []string{"safetensors"},
+				AllowedArchitectures: []string{"other"},
+				RequireLicense:       true,
+				DeniedDigests:        []string{unlicensedDigest.String()},
+				RequireSignature:     true,
+			},
+			reasons: []string{"size", "format", "architecture", "license", "digest", "signed"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := tc.rules
+			client, err := NewClient(
+				WithStoreRootPath(t.TempDir()),
+				WithAdmissionPolicy(&rules),
+				WithVerificationPolicy(VerifyOff, pub),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			err = client.PullModel(t.Context(), tc.reference, nil)
+			if len(tc.reasons) == 0 {
+				if err != nil {
+					t.Fatalf("Failed to pull model: %v", err)
+				}
+				recorder.reset()
+				return
+			}
+			if !errors.Is(err, ErrPolicyDenied) {
+				t.Fatalf("Expected %v, got %v", ErrPolicyDenied, err)
+			}
+			var denied *PolicyDeniedError
+			if !errors.As(err, &denied) {
+				t.Fatalf("Expected *PolicyDeniedError, got %T", err)
+			}
+			if len(denied.Reasons) != len(tc.reasons) {
+				t.Fatalf("Expected %d reasons, got %q", len(tc.reasons), denied.Reasons)
+			}
+			for i, reason := range tc.reasons {
+				if !strings.Contains(denied.Reasons[i], reason) {
+					t.Fatalf("Expected reason %d to mention %q, got %q", i, reason, denied.Reasons[i])
+				}
+			}
+
+			// Only the config may have been downloaded
+			manifest, err := mdl.Manifest()
+			if err != nil {
+				t.Fatalf("Failed to get manifest: %v", err)
+			}
+			for _, u := range recorder.reset() {
+				if blob := path.Base(u.Path); blob != manifest.Config.Digest.String() {
+					t.Fatalf("Expected no layers to be downloaded, got %s", blob)
+				}
+			}
+			models, err := client.ListModels()
+			if err != nil {
+				t.Fatalf("Failed to list models: %v", err)
+			}
+			if len(models) != 0 {
+				t.Fatalf("Expected nothing to be written to the store, got %d models", len(models))
+			}
+		})
+	}
+
+	t.Run("custom policy", func(t *testing.T) {
+		var seen AdmissionRequest
+		client, err := NewClient(WithStoreRootPath(t.TempDir()), WithAdmissionPolicy(AdmissionPolicyFunc(
+			func(ctx context.Context, req AdmissionRequest) error {
+				seen = req
+				return &PolicyDeniedError{Reference: req.Reference, Reasons: []string{"custom"}}
+			},
+		)))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), licensedRef, nil); !errors.Is(err, ErrPolicyDenied) {
+			t.Fatalf("Expected %v, got %v", ErrPolicyDenied, err)
+		}
+		if seen.Registry != registryURL.Host || seen.Reference != licensedRef || seen.Signed {
+			t.Fatalf("Unexpected request %+v", seen)
+		}
+		licenses := seen.Licenses()
+		if len(licenses) != 1 || licenses[0].Digest != mustDigest(t, license) {
+			t.Fatalf("Expected the license layer, got %+v", licenses)
+		}
+		if seen.Size <= licenses[0].Size || seen.Config.Format != cfg.Format {
+			t.Fatalf("Unexpected size %d or config %+v", seen.Size, seen.Config)
+		}
+	})
+}
+
+func mustDigest(t *testing.T, layer v1.Layer) v1.Hash {
+	t.Helper()
+	digest, err := layer.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	return digest
+}
@@ -2,15 +2,23 @@ package distribution
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/sirupsen/logrus"
 
-	"github.com/docker/model-distribution/internal/progress"
 	"github.com/docker/model-distribution/internal/store"
+	"github.com/docker/model-distribution/progress"
 	"github.com/docker/model-distribution/registry"
 	"github.com/docker/model-distribution/tarball"
 	"github.com/docker/model-distribution/types"
@@ -21,6 +29,13 @@ type Client struct {
 	store    *store.LocalStore
 	log      *logrus.Entry
 	registry *registry.Client
+	offline  bool
+	// verification is the default signature verification policy of pulls
+	verification VerificationPolicy
+	verifyKeys   []crypto.PublicKey
+	admission    AdmissionPolicy
+	decryptKeys  []*ecdh.PrivateKey
+	loadLimits   []tarball.ReaderOption
 }
 
 // GetStorePath returns the root path where models are stored
@@ -39,6 +54,22 @@ type options struct {
 	userAgent     string
 	username      string
 	password      string
+	offline       bool
+	mirrors       map[string][]string
+	chunkSize     int64
+	concurrency   int
+	retries       int
+	rlRetries     int
+	rlMaxWait     time.Duration
+	credentials   map[string]registry.Credential
+	insecure      []string
+	rootCAs       *x509.CertPool
+	clientCerts   []tls.Certificate
+	verification  VerificationPolicy
+	verifyKeys    []crypto.PublicKey
+	admission     AdmissionPolicy
+	decryptKeys   []*ecdh.PrivateKey
+	loadLimits    []tarball.ReaderOption
 }
 
 // WithStoreRootPath sets the store root path
@@ -87,11 +118,126 @@ func WithRegistryAuth(username, password string) Option {
 	}
 }
 
+// WithRegistryCredentials sets the credentials to use for each registry host, e.g. "docker.io". They take precedence
+// over the credentials saved with Login and over WithRegistryAuth, which applies to every other registry.
+func WithRegistryCredentials(credentials map[string]registry.Credential) Option {
+	return func(o *options) {
+		if len(credentials) > 0 {
+			o.credentials = credentials
+		}
+	}
+}
+
+// WithInsecureRegistries marks registries, given by host (e.g. "localhost:5000"), as insecure. They are reached over
+// HTTPS without verifying their certificate, falling back to plain HTTP.
+func WithInsecureRegistries(hosts ...string) Option {
+	return func(o *options) {
+		o.insecure = append(o.insecure, hosts...)
+	}
+}
+
+// WithCACertPool sets the certificate authorities registry certificates are verified with, instead of the system's.
+// TLS options only apply if the transport is an *http.Transport, as the default one is.
+func WithCACertPool(pool *x509.CertPool) Option {
+	return func(o *options) {
+		if pool != nil {
+			o.rootCAs = pool
+		}
+	}
+}
+
+// WithClientCertificate sets a certificate to present to registries that require TLS client authentication.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(o *options) {
+		if cert.Certificate != nil {
+			o.clientCerts = append(o.clientCerts, cert)
+		}
+	}
+}
+
+// WithRegistryMirrors sets the mirrors to read models from for each registry host, in order of preference.
+// The registry itself is used if none of its mirrors can serve a model.
+func WithRegistryMirrors(mirrors map[string][]string) Option {
+	return func(o *options) {
+		if len(mirrors) > 0 {
+			o.mirrors = mirrors
+		}
+	}
+}
+
+// WithOffline disables all registry access. Operations that need the registry fail with ErrOffline and
+// pulls are only satisfied from the local store.
+func WithOffline(offline bool) Option {
+	return func(o *options) {
+		o.offline = offline
+	}
+}
+
+// WithUploadChunkSize sets the size of the chunks blobs are uploaded in when pushing models.
+func WithUploadChunkSize(size int64) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.chunkSize = size
+		}
+	}
+}
+
+// WithUploadConcurrency sets the number of blobs that are uploaded in parallel when pushing models.
+func WithUploadConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithUploadRetries sets the number of times an interrupted blob upload is resumed before the push fails.
+func WithUploadRetries(n int) Option {
+	return func(o *options) {
+		if n >= 0 {
+			o.retries = n
+		}
+	}
+}
+
+// WithRateLimitRetries sets the number of times a request rejected by a registry's rate limit is retried after the
+// delay the registry asks for.
+func WithRateLimitRetries(n int) Option {
+	return func(o *options) {
+		if n >= 0 {
+			o.rlRetries = n
+		}
+	}
+}
+
+// WithRateLimitMaxWait sets the total time a registry request may spend waiting for rate limits to reset.
+func WithRateLimitMaxWait(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.rlMaxWait = d
+		}
+	}
+}
+
+// WithLoadLimits sets the limits within which archives are read by LoadModel, e.g. tarball.WithMaxBlobSize. Archives
+// exceeding them are rejected with an error wrapping tarball.ErrLimitExceeded.
+func WithLoadLimits(limits ...tarball.ReaderOption) Option {
+	return func(o *options) {
+		o.loadLimits = append(o.loadLimits, limits...)
+	}
+}
+
 func defaultOptions() *options {
 	return &options{
-		logger:    logrus.NewEntry(logrus.StandardLogger()),
-		transport: registry.DefaultTransport,
-		userAgent: registry.DefaultUserAgent,
+		logger:       logrus.NewEntry(logrus.StandardLogger()),
+		transport:    registry.DefaultTransport,
+		userAgent:    registry.DefaultUserAgent,
+		chunkSize:    registry.DefaultUploadChunkSize,
+		concurrency:  1,
+		retries:      registry.DefaultUploadRetries,
+		rlRetries:    registry.DefaultRateLimitRetries,
+		rlMaxWait:    registry.DefaultRateLimitMaxWait,
+		verification: VerifyOff,
 	}
 }
 
@@ -105,6 +251,9 @@ func NewClient(opts ...Option) (*Client, error) {
 	if options.storeRootPath == "" {
 		return nil, fmt.Errorf("store root path is required")
 	}
+	if options.verification == VerifyRequired && len(options.verifyKeys) == 0 {
+		return nil, fmt.Errorf("verification policy %q requires at least one public key", VerifyRequired)
+	}
 
 	s, err := store.New(store.Options{
 		RootPath: options.storeRootPath,
@@ -117,24 +266,80 @@ func NewClient(opts ...Option) (*Client, error) {
 	registryOpts := []registry.ClientOption{
 		registry.WithTransport(options.transport),
 		registry.WithUserAgent(options.userAgent),
+		registry.WithMirrors(options.mirrors),
+		registry.WithUploadChunkSize(options.chunkSize),
+		registry.WithUploadConcurrency(options.concurrency),
+		registry.WithUploadRetries(options.retries),
+		registry.WithRateLimitRetries(options.rlRetries),
+		registry.WithRateLimitMaxWait(options.rlMaxWait),
+		registry.WithInsecureRegistries(options.insecure...),
+		registry.WithCACertPool(options.rootCAs),
+	}
+	for _, cert := range options.clientCerts {
+		registryOpts = append(registryOpts, registry.WithClientCertificate(cert))
 	}
 
 	// Add auth if credentials are provided
 	if options.username != "" && options.password != "" {
 		registryOpts = append(registryOpts, registry.WithAuthConfig(options.username, options.password))
 	}
+	saved, err := readCredentials(credentialsPath(s.RootPath()))
+	if err != nil {
+		return nil, fmt.Errorf("reading saved credentials: %w", err)
+	}
+	registryOpts = append(registryOpts, registry.WithCredentials(saved), registry.WithCredentials(options.credentials))
 
 	options.logger.Infoln("Successfully initialized store")
 	return &Client{
-		store:    s,
-		log:      options.logger,
-		registry: registry.NewClient(registryOpts...),
+		store:        s,
+		log:          options.logger,
+		registry:     registry.NewClient(registryOpts...),
+		offline:      options.offline,
+		verification: options.verification,
+		verifyKeys:   options.verifyKeys,
+		admission:    options.admission,
+		decryptKeys:  options.decryptKeys,
+		loadLimits:   options.loadLimits,
 	}, nil
 }
 
 // PullModel pulls a model from a registry and returns the local file path
-func (c *Client) PullModel(ctx context.Context, reference string, progressWriter io.Writer) error {
+func (c *Client) PullModel(ctx context.Context, reference string, progressWriter io.Writer, opts ...PullOption) (err error) {
 	c.log.Infoln("Starting model pull:", reference)
+	pw := c.startPhase(progressWriter, progress.PhasePull, reference)
+	var message string
+	defer func() { c.endPhase(pw, progress.PhasePull, reference, message, err) }()
+
+	options := defaultPullOptions()
+	options.verification = c.verification
+	for _, opt := range opts {
+		opt(options)
+	}
+	if _, err := ParsePullPolicy(string(options.policy)); err != nil {
+		return err
+	}
+	if c.offline {
+		options.policy = PullNever
+	}
+
+	if options.policy != PullAlways {
+		localModel, err := c.store.Read(reference)
+		if err == nil {
+			c.log.Infoln("Model found in local store, skipping registry:", reference)
+			cfg, err := localModel.Config()
+			if err != nil {
+				return fmt.Errorf("getting cached model config: %w", err)
+			}
+			message = fmt.Sprintf("Using cached model: %s", cfg.Size)
+			return nil
+		}
+		if !errors.Is(err, ErrModelNotFound) {
+			return fmt.Errorf("reading model from store: %w", err)
+		}
+		if options.policy == PullNever {
+			return &OfflineError{Operation: "pull", Reference: reference}
+		}
+	}
 
 	remoteModel, err := c.registry.Model(ctx, reference)
 	if err != nil {
@@ -154,6 +359,23 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 	}
 	c.log.Infoln("Remote model digest:", remoteDigest.String())
 
+	// Verify signatures and evaluate the admission policy before any layer is downloaded or anything is written to
+	// the store. Signatures are checked for the admission policy even if verification is off.
+	verification := options.verification
+	if verification == VerifyOff && c.admission != nil {
+		verification = VerifyOptional
+	}
+	signed, err := c.verifyModel(ctx, reference, remoteDigest, verification)
+	if err != nil {
+		return err
+	}
+	if err := c.admit(ctx, reference, remoteModel, signed); err != nil {
+		return err
+	}
+	if err := c.checkDecryptable(reference, remoteModel); err != nil {
+		return err
+	}
+
 	// Check if model exists in local store
 	localModel, err := c.store.Read(remoteDigest.String())
 	if err == nil {
@@ -162,49 +384,211 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 		if err != nil {
 			return fmt.Errorf("getting cached model config: %w", err)
 		}
-
-		err = progress.WriteSuccess(progressWriter, fmt.Sprintf("Using cached model: %s", cfg.Size))
-		if err != nil {
-			c.log.Warnf("Writing progress: %v", err)
-			// If we fail to write progress, don't try again
-			progressWriter = nil
-		}
+		message = fmt.Sprintf("Using cached model: %s", cfg.Size)
 
 		// Ensure model has the correct tag
-		if err := c.store.AddTags(remoteDigest.String(), []string{reference}); err != nil {
+		if err := c.store.AddTags(remoteDigest.String(), tagsForReference(reference)); err != nil {
 			return fmt.Errorf("tagging model: %w", err)
 		}
-		return nil
+		return c.pullReferrers(ctx, reference, remoteDigest, options.referrers)
 	} else {
 		c.log.Infoln("Model not found in local store, pulling from remote:", reference)
 	}
 
 	// Model doesn't exist in local store or digests don't match, pull from remote
 
-	if err = c.store.Write(remoteModel, []string{reference}, progressWriter); err != nil {
-		if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error())); writeErr != nil {
-			c.log.Warnf("Failed to write error message: %v", writeErr)
-			// If we fail to write error message, don't try again
-			progressWriter = nil
-		}
+	if err = c.store.WriteContext(ctx, remoteModel, tagsForReference(reference), pw); err != nil {
 		return fmt.Errorf("writing image to store: %w", err)
 	}
-
-	if err := progress.WriteSuccess(progressWriter, "Model pulled successfully"); err != nil {
-		c.log.Warnf("Failed to write success message: %v", err)
-		// If we fail to write success message, don't try again
-		progressWriter = nil
+	if err := c.pullReferrers(ctx, reference, remoteDigest, options.referrers); err != nil {
+		return err
 	}
 
+	message = "Model pulled successfully"
 	return nil
 }
 
-// LoadModel loads the model from the reader to the store
+// ModelUpdate describes a local tag that points to a different digest in the registry than in the store
+type ModelUpdate struct {
+	Tag          string `json:"tag"`
+	LocalDigest  string `json:"localDigest"`
+	RemoteDigest string `json:"remoteDigest"`
+}
+
+// CheckUpdates resolves every local tag against its registry and returns the tags for which a different model is
+// available. No model content is downloaded. Tags that cannot be resolved are skipped and reported in the returned
+// error, alongside any updates that were found.
+func (c *Client) CheckUpdates(ctx context.Context) ([]ModelUpdate, error) {
+	c.log.Infoln("Checking for model updates")
+	if err := c.checkOnline("check updates", ""); err != nil {
+		return nil, err
+	}
+	models, err := c.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing models: %w", err)
+	}
+
+	var (
+		updates []ModelUpdate
+		errs    []error
+	)
+	for _, mdl := range models {
+		for _, tag := range mdl.Tags {
+			if err := ctx.Err(); err != nil {
+				return updates, err
+			}
+			remoteDigest, err := c.registry.Digest(ctx, tag)
+			if err != nil {
+				c.log.Warnf("Failed to resolve tag %s: %v", tag, err)
+				errs = append(errs, fmt.Errorf("resolving %q: %w", tag, err))
+				continue
+			}
+			if remoteDigest.String() == mdl.ID {
+				continue
+			}
+			c.log.Infoln("Update available for", tag, "remote digest:", remoteDigest.String())
+			updates = append(updates, ModelUpdate{
+				Tag:          tag,
+				LocalDigest:  mdl.ID,
+				RemoteDigest: remoteDigest.String(),
+			})
+		}
+	}
+
+	c.log.Infoln("Finished checking for model updates, count:", len(updates))
+	return updates, errors.Join(errs...)
+}
+
+// UpdateAll pulls every local tag for which CheckUpdates reports an update and returns the updates that were pulled.
+// Failures to check or pull individual tags do not stop the remaining updates and are reported in the returned error.
+func (c *Client) UpdateAll(ctx context.Context, progressWriter io.Writer) ([]ModelUpdate, error) {
+	updates, checkErr := c.CheckUpdates(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	errs := []error{checkErr}
+	var pulled []ModelUpdate
+	for _, update := range updates {
+		if err := c.PullModel(ctx, update.Tag, progressWriter); err != nil {
+			errs = append(errs, fmt.Errorf("pulling %q: %w", update.Tag, err))
+			continue
+		}
+		pulled = append(pulled, update)
+	}
+	return pulled, errors.Join(errs...)
+}
+
+// ListRemoteTags lists the tags of a repository in a registry. If withDetails is true, each tag is
+// resolved to include the digest and size of the model it points to.
+func (c *Client) ListRemoteTags(ctx context.Context, repository string, withDetails bool) ([]registry.RemoteTag, error) {
+	c.log.Infoln("Listing remote tags:", repository)
+	if err := c.checkOnline("list tags of", repository); err != nil {
+		return nil, err
+	}
+	tags, err := c.registry.ListTags(ctx, repository)
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+
+	result := make([]registry.RemoteTag, 0, len(tags))
+	for _, tag := range tags {
+		if !withDetails {
+			result = append(result, registry.RemoteTag{Name: tag})
+			continue
+		}
+		details, err := c.registry.TagDetails(ctx, repository+":"+tag)
+		if err != nil {
+			return nil, fmt.Errorf("getting details for tag %q: %w", tag, err)
+		}
+		result = append(result, details)
+	}
+
+	c.log.Infoln("Successfully listed remote tags, count:", len(result))
+	return result, nil
+}
+
+// DeleteRemote deletes the model the reference points to from its registry and returns the digest of the deleted
+// manifest. A tag is resolved to its digest first, so every tag pointing to the same model is removed from the
+// repository. The local store is not modified. Registries that do not allow deletes fail with an error matching
+// registry.ErrUnsupported or registry.ErrDenied.
+func (c *Client) DeleteRemote(ctx context.Context, reference string) (string, error) {
+	c.log.Infoln("Deleting model from registry:", reference)
+	if err := c.checkOnline("delete", reference); err != nil {
+		return "", err
+	}
+	digest, err := c.registry.DeleteManifest(ctx, reference)
+	if err != nil {
+		c.log.Errorln("Failed to delete model from registry:", err, "reference:", reference)
+		return "", fmt.Errorf("deleting model from registry: %w", err)
+	}
+	c.log.Infoln("Successfully deleted model from registry:", digest.String())
+	return digest.String(), nil
+}
+
+// RateLimitStatus returns the rate limit the registry of the reference applies to pulls, as reported by the registry.
+// Checking the status does not count against the limit on registries like Docker Hub.
+func (c *Client) RateLimitStatus(ctx context.Context, reference string) (registry.RateLimit, error) {
+	if err := c.checkOnline("check rate limit for", reference); err != nil {
+		return registry.RateLimit{}, err
+	}
+	status, err := c.registry.RateLimitStatus(ctx, reference)
+	if err != nil {
+		return registry.RateLimit{}, fmt.Errorf("checking rate limit: %w", err)
+	}
+	return status, nil
+}
+
+// LoadModel loads the models from the reader to the store and applies the tags recorded in the archive. It returns
+// the ID of the first model in the archive. Progress is reported for every blob in the archive, blobs already in the
+// store are reported as skipped, and the result is reported as success or error like PullModel.
 func (c *Client) LoadModel(r io.Reader, progressWriter io.Writer) (string, error) {
+	return c.LoadModelContext(context.Background(), r, progressWriter)
+}
+
+// LoadModelContext is like LoadModel but stops reading from r once ctx is done. If the load fails or is cancelled,
+// any blobs it wrote that are not referenced by another model are removed from the store.
+func (c *Client) LoadModelContext(ctx context.Context, r io.Reader, progressWriter io.Writer) (string, error) {
+	ids, err := c.LoadModels(ctx, r, progressWriter)
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// LoadModels is like LoadModelContext but returns the IDs of all models in the archive, in archive order.
+func (c *Client) LoadModels(ctx context.Context, r io.Reader, progressWriter io.Writer) (ids []string, err error) {
 	c.log.Infoln("Starting model load")
+	pw := c.startPhase(progressWriter, progress.PhaseLoad, "")
+	defer func() {
+		var message string
+		if err == nil {
+			message = "Model loaded successfully"
+			if len(ids) > 1 {
+				message = fmt.Sprintf("%d models loaded successfully", len(ids))
+			}
+		}
+		c.endPhase(pw, progress.PhaseLoad, strings.Join(ids, ", "), message, err)
+	}()
 
-	tr := tarball.NewReader(r)
+	var written []v1.Hash
+	defer func() {
+		if err == nil {
+			return
+		}
+		if cleanupErr := c.store.CleanupBlobs(written); cleanupErr != nil {
+			c.log.Warnf("Failed to clean up blobs after failed load: %v", cleanupErr)
+		}
+	}()
+
+	// The archive is streamed, so the total grows with the size of every blob found in it
+	tracker := progress.NewTracker(pw, 0)
+	tr := tarball.NewReader(r, c.loadLimits...)
 	for {
+		if err := ctx.Err(); err != nil {
+			c.log.Infof("Model load cancelled: %v", err)
+			return nil, fmt.Errorf("model load cancelled: %w", err)
+		}
 		diffID, err := tr.Next()
 		if err == io.EOF {
 			break
@@ -212,34 +596,113 @@ func (c *Client) LoadModel(r io.Reader, progressWriter io.Writer) (string, error
 		if err != nil {
 			if errors.Is(err, io.ErrUnexpectedEOF) {
 				c.log.Infof("Model load interrupted (likely cancelled): %v", err)
-				return "", fmt.Errorf("model load interrupted: %w", err)
+				return nil, fmt.Errorf("model load interrupted: %w", err)
 			}
-			return "", fmt.Errorf("reading blob from stream: %w", err)
+			return nil, fmt.Errorf("reading blob from stream: %w", err)
+		}
+		layer := progress.Layer{ID: diffID.String(), Size: tr.Size()}
+		tracker.AddTotal(layer.Size)
+		if c.store.HasBlob(diffID) {
+			c.log.Infoln("Blob already in store:", diffID)
+			tracker.Skip(layer)
+			continue
 		}
 		c.log.Infoln("Loading blob:", diffID)
-		if err := c.store.WriteBlob(diffID, tr); err != nil {
-			return "", fmt.Errorf("writing blob: %w", err)
+		lt := tracker.Start(layer)
+		if err := c.store.WriteBlobContext(ctx, diffID, lt.Reader(tr)); err != nil {
+			return nil, fmt.Errorf("writing blob: %w", err)
 		}
+		lt.Done()
+		written = append(written, diffID)
 		c.log.Infoln("Loaded blob:", diffID)
 	}
 
-	manifest, digest, err := tr.Manifest()
+	manifests, err := tr.Manifests()
 	if err != nil {
-		return "", fmt.Errorf("read manifest: %w", err)
+		return nil, fmt.Errorf("read manifest: %w", err)
 	}
-	c.log.Infoln("Loading manifest:", digest.String())
-	if err := c.store.WriteManifest(digest, manifest); err != nil {
-		return "", fmt.Errorf("write manifest: %w", err)
+	for _, manifest := range manifests {
+		c.log.Infoln("Loading manifest:", manifest.Digest.String())
+		if err := c.store.WriteManifest(manifest.Digest, manifest.Raw); err != nil {
+			return nil, fmt.Errorf("write manifest: %w", err)
+		}
+		c.log.Infoln("Loaded model with ID:", manifest.Digest.String())
+		ids = append(ids, manifest.Digest.String())
+	}
+	for _, manifest := range manifests {
+		if err := c.store.AddTags(manifest.Digest.String(), c.archiveTags(manifest.Tags)); err != nil {
+			return nil, fmt.Errorf("tagging model: %w", err)
+		}
+	}
+
+	return ids, nil
+}
+
+// archiveTags returns the tags recorded in an archive that are references with an explicit tag. Other ref names, e.g.
+// "latest" in OCI image layouts written by other tools, are skipped.
+func (c *Client) archiveTags(refNames []string) []string {
+	var tags []string
+	for _, refName := range refNames {
+		if _, err := name.NewTag(refName); err != nil || !hasExplicitTag(refName) {
+			c.log.Warnf("Ignoring ref name %q in archive that is not a tagged reference", refName)
+			continue
+		}
+		tags = append(tags, refName)
 	}
-	c.log.Infoln("Loaded model with ID:", digest.String())
+	return tags
+}
 
-	if err := progress.WriteSuccess(progressWriter, "Model loaded successfully"); err != nil {
-		c.log.Warnf("Failed to write success message: %v", err)
-		// If we fail to write success message, don't try again
-		progressWriter = nil
+// archiveTag returns the tag to record in an archive for a model saved by reference, with an explicit tag so that it is
+// recognized when the archive is loaded. It returns "" for IDs and digest references.
+func archiveTag(reference string) string {
+	if strings.HasPrefix(reference, "sha256:") {
+		return ""
+	}
+	tag, err := name.NewTag(reference)
+	if err != nil {
+		return ""
 	}
+	if !hasExplicitTag(reference) {
+		return reference + ":" + tag.TagStr()
+	}
+	return reference
+}
 
-	return digest.String(), nil
+// hasExplicitTag returns true if the last path component of reference has a tag
+func hasExplicitTag(reference string) bool {
+	return strings.Contains(reference[strings.LastIndex(reference, "/")+1:], ":")
+}
+
+// SaveModels writes the models with the given references from the store to w as a single archive, reporting progress
+// to progressWriter. Models referenced by tag carry the tag in the archive, so that LoadModel applies it again. The
+// options configure the archive, e.g. its compression.
+func (c *Client) SaveModels(ctx context.Context, references []string, w io.Writer, progressWriter io.Writer, opts ...tarball.TargetOption) error {
+	var models []tarball.Model
+	positions := make(map[string]int)
+	for _, reference := range references {
+		mdl, err := c.store.Read(reference)
+		if err != nil {
+			return fmt.Errorf("get model %q: %w", reference, err)
+		}
+		id, err := mdl.ID()
+		if err != nil {
+			return fmt.Errorf("get model ID: %w", err)
+		}
+		i, ok := positions[id]
+		if !ok {
+			i = len(models)
+			positions[id] = i
+			models = append(models, tarball.Model{Artifact: mdl})
+		}
+		if tag := archiveTag(reference); tag != "" {
+			models[i].Tags = append(models[i].Tags, tag)
+		}
+	}
+	target, err := tarball.NewTarget(w, opts...)
+	if err != nil {
+		return fmt.Errorf("create target: %w", err)
+	}
+	return target.WriteModels(ctx, models, progressWriter)
 }
 
 // ListModels returns all available models
@@ -298,6 +761,14 @@ type DeleteModelResponse []DeleteModelAction
 
 // DeleteModel deletes a model
 func (c *Client) DeleteModel(reference string, force bool) (*DeleteModelResponse, error) {
+	return c.DeleteModelContext(context.Background(), reference, force)
+}
+
+// DeleteModelContext is like DeleteModel but returns without modifying the store if ctx is already done.
+func (c *Client) DeleteModelContext(ctx context.Context, reference string, force bool) (*DeleteModelResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return &DeleteModelResponse{}, err
+	}
 	mdl, err := c.store.Read(reference)
 	if err != nil {
 		return &DeleteModelResponse{}, err
@@ -334,7 +805,7 @@ func (c *Client) DeleteModel(reference string, force bool) (*DeleteModelResponse
 	}
 
 	c.log.Infoln("Deleting model:", id)
-	deletedID, tags, err := c.store.Delete(id)
+	deletedID, tags, err := c.store.DeleteContext(ctx, id)
 	if err != nil {
 		c.log.Errorln("Failed to delete model:", err, "tag:", reference)
 		return &DeleteModelResponse{}, fmt.Errorf("deleting model: %w", err)
@@ -353,34 +824,34 @@ func (c *Client) Tag(source string, target string) error {
 	return c.store.AddTags(source, []string{target})
 }
 
-// PushModel pushes a tagged model from the content store to the registry.
-func (c *Client) PushModel(ctx context.Context, tag string, progressWriter io.Writer) (err error) {
-	// Parse the tag
-	target, err := c.registry.NewTarget(tag)
-	if err != nil {
-		return fmt.Errorf("new tag: %w", err)
+// PushModel pushes a tagged model from the content store to the registry. By default the model tagged with tag is
+// pushed; use WithPushSource to push another local model, e.g. by ID, and WithPushTags to push extra tags.
+func (c *Client) PushModel(ctx context.Context, tag string, progressWriter io.Writer, opts ...PushOption) (err error) {
+	if err := c.checkOnline("push", tag); err != nil {
+		return err
 	}
 
-	// Get the model from the store
-	mdl, err := c.store.Read(tag)
+	options := newPushOptions(opts)
+	mdl, target, err := c.preparePush(tag, options)
 	if err != nil {
-		return fmt.Errorf("reading model: %w", err)
+		return err
 	}
 
 	// Push the model
 	c.log.Infoln("Pushing model:", tag)
 	if err := target.Write(ctx, mdl, progressWriter); err != nil {
 		c.log.Errorln("Failed to push image:", err, "reference:", tag)
-		if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error())); writeErr != nil {
-			c.log.Warnf("Failed to write error message: %v", writeErr)
-		}
 		return fmt.Errorf("pushing image: %w", err)
 	}
+	if options.signer != nil {
+		c.log.Infoln("Signing model:", tag)
+		if err := target.WriteSignature(ctx, mdl, options.signer); err != nil {
+			c.log.Errorln("Failed to sign image:", err, "reference:", tag)
+			return fmt.Errorf("signing image: %w", err)
+		}
+	}
 
 	c.log.Infoln("Successfully pushed model:", tag)
-	if err := progress.WriteSuccess(progressWriter, "Model pushed successfully"); err != nil {
-		c.log.Warnf("Failed to write success message: %v", err)
-	}
 
 	return nil
 }
@@ -394,9 +865,19 @@ func (c *Client) ResetStore() error {
 	return nil
 }
 
-// GetBundle returns a types.Bundle containing the model, creating one as necessary
+// GetBundle returns a types.Bundle containing the model, creating one as necessary. Encrypted layers are decrypted into
+// the bundle with the client's decryption keys.
 func (c *Client) GetBundle(ref string) (types.ModelBundle, error) {
-	return c.store.BundleForModel(ref)
+	return c.store.BundleForModel(ref, c.decryptKeys...)
+}
+
+// tagsForReference returns the tags to apply to a model pulled by the given reference. Digest references do not
+// result in a tag.
+func tagsForReference(reference string) []string {
+	if _, err := name.NewDigest(reference); err == nil {
+		return nil
+	}
+	return []string{reference}
 }
 
 func checkCompat(image types.ModelArtifact) error {
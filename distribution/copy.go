@@ -0,0 +1,107 @@
+package distribution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/docker/model-distribution/progress"
+)
+
+// Copy copies a model from one registry reference to another without writing it to the local store.
+// Blobs are streamed from the source to the destination. Blobs that already exist at the destination are skipped,
+// and blobs are mounted across repositories when the registry supports it. The manifest is copied verbatim, so the
+// model keeps its digest.
+func (c *Client) Copy(ctx context.Context, srcRef, dstRef string, progressWriter io.Writer) error {
+	c.log.Infoln("Copying model, source:", srcRef, "destination:", dstRef)
+	if err := c.checkOnline("copy", srcRef); err != nil {
+		return err
+	}
+
+	pw := c.startPhase(progressWriter, progress.PhaseCopy, dstRef)
+	if err := c.copy(ctx, srcRef, dstRef, pw); err != nil {
+		c.log.Errorln("Failed to copy model:", err, "source:", srcRef, "destination:", dstRef)
+		c.endPhase(pw, progress.PhaseCopy, dstRef, "", err)
+		return err
+	}
+
+	c.log.Infoln("Successfully copied model to:", dstRef)
+	c.endPhase(pw, progress.PhaseCopy, dstRef, "Model copied successfully", nil)
+	return nil
+}
+
+func (c *Client) copy(ctx context.Context, srcRef, dstRef string, progressWriter io.Writer) error {
+	target, err := c.registry.NewTarget(dstRef)
+	if err != nil {
+		return fmt.Errorf("new tag: %w", err)
+	}
+
+	mdl, err := c.registry.Model(ctx, srcRef)
+	if err != nil {
+		return fmt.Errorf("reading model from registry: %w", err)
+	}
+	srcDigest, err := mdl.Digest()
+	if err != nil {
+		return fmt.Errorf("getting source digest: %w", err)
+	}
+
+	if err := target.Write(ctx, mdl, progressWriter); err != nil {
+		return fmt.Errorf("copying model: %w", err)
+	}
+
+	dstDigest, err := c.registry.Digest(ctx, dstRef)
+	if err != nil {
+		return fmt.Errorf("resolving destination digest: %w", err)
+	}
+	if dstDigest != srcDigest {
+		return fmt.Errorf("destination digest %s does not match source digest %s", dstDigest, srcDigest)
+	}
+	return nil
+}
+
+// Mirror copies the given tags from the source repository to the destination repository, keeping tag names.
+// If no tags are given, every tag in the source repository is mirrored. Failures to copy individual tags do not
+// stop the remaining copies. The tags that were copied are returned along with any errors.
+func (c *Client) Mirror(ctx context.Context, srcRepo, dstRepo string, tags []string, progressWriter io.Writer) ([]string, error) {
+	c.log.Infoln("Mirroring models, source:", srcRepo, "destination:", dstRepo)
+	if err := c.checkOnline("mirror", srcRepo); err != nil {
+		return nil, err
+	}
+
+	src, err := name.NewRepository(srcRepo)
+	if err != nil {
+		return nil, &ReferenceError{Reference: srcRepo, Err: err}
+	}
+	dst, err := name.NewRepository(dstRepo)
+	if err != nil {
+		return nil, &ReferenceError{Reference: dstRepo, Err: err}
+	}
+
+	if len(tags) == 0 {
+		tags, err = c.registry.ListTags(ctx, srcRepo)
+		if err != nil {
+			return nil, fmt.Errorf("listing tags: %w", err)
+		}
+	}
+
+	var (
+		copied []string
+		errs   []error
+	)
+	for _, tag := range tags {
+		if err := ctx.Err(); err != nil {
+			return copied, err
+		}
+		if err := c.Copy(ctx, src.Tag(tag).String(), dst.Tag(tag).String(), progressWriter); err != nil {
+			errs = append(errs, fmt.Errorf("copying tag %q: %w", tag, err))
+			continue
+		}
+		copied = append(copied, tag)
+	}
+
+	c.log.Infoln("Finished mirroring models, count:", len(copied))
+	return copied, errors.Join(errs...)
+}
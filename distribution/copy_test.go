@@ -0,0 +1,156 @@
+package distribution
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+)
+
+func TestCopy(t *testing.T) {
+	recorder := &requestRecorder{method: http.MethodPost}
+	otherRecorder := &requestRecorder{method: http.MethodPost}
+	server := httptest.NewServer(recorder.wrap(registry.New()))
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	otherServer := httptest.NewServer(otherRecorder.wrap(registry.New()))
+	defer otherServer.Close()
+	otherURL, err := url.Parse(otherServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	client, err := NewClient(WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	srcRepo := registryURL.Host + "/source/model"
+	for _, tag := range []string{"v1", "v2"} {
+		ref, err := name.ParseReference(srcRepo + ":" + tag)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, mdl); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	}
+	recorder.reset()
+
+	assertDigest := func(t *testing.T, reference string) {
+		t.Helper()
+		ref, err := name.ParseReference(reference)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		desc, err := remote.Head(ref)
+		if err != nil {
+			t.Fatalf("Failed to resolve %q: %v", reference, err)
+		}
+		if desc.Digest != digest {
+			t.Fatalf("Expected digest %s for %q, got %s", digest, reference, desc.Digest)
+		}
+	}
+
+	t.Run("same registry", func(t *testing.T) {
+		dst := registryURL.Host + "/destination/model:v1"
+		if err := client.Copy(t.Context(), srcRepo+":v1", dst, nil); err != nil {
+			t.Fatalf("Failed to copy model: %v", err)
+		}
+		assertDigest(t, dst)
+
+		// The test registry shares blobs between repositories, so nothing needs to be uploaded
+		if uploads := recorder.reset(); len(uploads) != 0 {
+			t.Fatalf("Expected no blob uploads, got %d", len(uploads))
+		}
+	})
+
+	t.Run("other registry", func(t *testing.T) {
+		dst := otherURL.Host + "/destination/model:v1"
+		if err := client.Copy(t.Context(), srcRepo+":v1", dst, nil); err != nil {
+			t.Fatalf("Failed to copy model: %v", err)
+		}
+		assertDigest(t, dst)
+
+		var mounts int
+		for _, u := range otherRecorder.reset() {
+			if q := u.Query(); q.Get("mount") != "" && q.Get("from") == "source/model" {
+				mounts++
+			}
+		}
+		if mounts == 0 {
+			t.Fatalf("Expected layer uploads to request a mount from source/model")
+		}
+	})
+
+	t.Run("existing blobs are skipped", func(t *testing.T) {
+		dst := otherURL.Host + "/destination/model:v1-again"
+		if err := client.Copy(t.Context(), srcRepo+":v1", dst, nil); err != nil {
+			t.Fatalf("Failed to copy model: %v", err)
+		}
+		assertDigest(t, dst)
+		if uploads := otherRecorder.reset(); len(uploads) != 0 {
+			t.Fatalf("Expected no blob uploads, got %d", len(uploads))
+		}
+	})
+
+	t.Run("local store is untouched", func(t *testing.T) {
+		models, err := client.ListModels()
+		if err != nil {
+			t.Fatalf("Failed to list models: %v", err)
+		}
+		if len(models) != 0 {
+			t.Fatalf("Expected no models in local store, got %d", len(models))
+		}
+	})
+
+	t.Run("mirror all tags", func(t *testing.T) {
+		dstRepo := otherURL.Host + "/mirror/model"
+		copied, err := client.Mirror(t.Context(), srcRepo, dstRepo, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to mirror models: %v", err)
+		}
+		slices.Sort(copied)
+		if !slices.Equal(copied, []string{"v1", "v2"}) {
+			t.Fatalf("Expected tags v1 and v2 to be mirrored, got %v", copied)
+		}
+		assertDigest(t, dstRepo+":v1")
+		assertDigest(t, dstRepo+":v2")
+	})
+
+	t.Run("mirror selected tags", func(t *testing.T) {
+		dstRepo := otherURL.Host + "/mirror-selected/model"
+		copied, err := client.Mirror(t.Context(), srcRepo, dstRepo, []string{"v2", "missing"}, nil)
+		if err == nil {
+			t.Fatalf("Expected error for missing tag")
+		}
+		if !slices.Equal(copied, []string{"v2"}) {
+			t.Fatalf("Expected tag v2 to be mirrored, got %v", copied)
+		}
+		assertDigest(t, dstRepo+":v2")
+	})
+}
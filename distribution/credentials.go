@@ -0,0 +1,110 @@
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/docker/model-distribution/registry"
+)
+
+// credentialsFile is the name of the file in the store root that holds the credentials saved with Login
+const credentialsFile = "credentials.json"
+
+// ErrNotLoggedIn is returned by Logout for registries without saved credentials
+var ErrNotLoggedIn = errors.New("not logged in")
+
+// Login verifies that the registry accepts the credentials and saves them with the store, so that clients using the
+// store authenticate to the registry with them. Clients created before the login keep their credentials.
+// Passwords and tokens are saved in plain text, readable only by the current user; save the name of a credential
+// helper instead to keep secrets out of the store.
+func (c *Client) Login(ctx context.Context, host string, cred registry.Credential) error {
+	c.log.Infoln("Logging in to registry:", host)
+	if err := c.checkOnline("log in to", host); err != nil {
+		return err
+	}
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		return &ReferenceError{Reference: host, Err: err}
+	}
+	if err := c.registry.CheckCredentials(ctx, host, cred); err != nil {
+		return fmt.Errorf("checking credentials: %w", err)
+	}
+
+	path := credentialsPath(c.store.RootPath())
+	creds, err := readCredentials(path)
+	if err != nil {
+		return fmt.Errorf("reading saved credentials: %w", err)
+	}
+	creds[reg.RegistryStr()] = cred
+	if err := writeCredentials(path, creds); err != nil {
+		return fmt.Errorf("saving credentials: %w", err)
+	}
+	c.log.Infoln("Successfully logged in to registry:", reg.RegistryStr())
+	return nil
+}
+
+// Logout removes the credentials saved with Login for the registry. It returns ErrNotLoggedIn if there are none.
+func (c *Client) Logout(host string) error {
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		return &ReferenceError{Reference: host, Err: err}
+	}
+	path := credentialsPath(c.store.RootPath())
+	creds, err := readCredentials(path)
+	if err != nil {
+		return fmt.Errorf("reading saved credentials: %w", err)
+	}
+	if _, ok := creds[reg.RegistryStr()]; !ok {
+		return fmt.Errorf("%w to %q", ErrNotLoggedIn, reg.RegistryStr())
+	}
+	delete(creds, reg.RegistryStr())
+	if err := writeCredentials(path, creds); err != nil {
+		return fmt.Errorf("saving credentials: %w", err)
+	}
+	c.log.Infoln("Logged out of registry:", reg.RegistryStr())
+	return nil
+}
+
+// credentialsPath returns the path of the credentials file of the store
+func credentialsPath(storeRoot string) string {
+	return filepath.Join(storeRoot, credentialsFile)
+}
+
+// readCredentials reads saved credentials, keyed by registry host. A missing file holds no credentials.
+func readCredentials(path string) (map[string]registry.Credential, error) {
+	creds := make(map[string]registry.Credential)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return creds, nil
+}
+
+// writeCredentials atomically replaces the credentials file, which only the current user can read
+func writeCredentials(path string, creds map[string]registry.Credential) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package distribution
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	mdregistry "github.com/docker/model-distribution/registry"
+)
+
+// basicAuthRegistry serves a registry to clients sending the given basic auth credentials
+func basicAuthRegistry(t *testing.T, username, password string) string {
+	t.Helper()
+	reg := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	return registryURL.Host
+}
+
+// tokenRegistry serves a registry to clients sending bearer tokens issued by its token service in exchange for the
+// given basic auth credentials
+type tokenRegistry struct {
+	host      string
+	expiresIn int
+
+	mu        sync.Mutex
+	tokens    map[string]bool
+	exchanges int
+}
+
+func newTokenRegistry(t *testing.T, username, password string, expiresIn int) *tokenRegistry {
+	t.Helper()
+	tr := &tokenRegistry{expiresIn: expiresIn, tokens: make(map[string]bool)}
+	reg := registry.New()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			tr.mu.Lock()
+			tr.exchanges++
+			token := fmt.Sprintf("token-%d", tr.exchanges)
+			tr.tokens[token] = true
+			tr.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"token":%q,"expires_in":%d}`, token, tr.expiresIn)
+			return
+		}
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		tr.mu.Lock()
+		valid := tr.tokens[token]
+		tr.mu.Unlock()
+		if !valid {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	tr.host = registryURL.Host
+	return tr
+}
+
+func (tr *tokenRegistry) exchangeCount() int {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.exchanges
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	pushWithAuth := func(t *testing.T, reference string, auth authn.Authenticator) {
+		t.Helper()
+		ref, err := name.ParseReference(reference)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, mdl, remote.WithAuth(auth)); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	}
+
+	t.Run("per registry", func(t *testing.T) {
+		// Pull from one registry and push to another with different credentials in the same client
+		src := basicAuthRegistry(t, "alice", "alice-password")
+		dst := newTokenRegistry(t, "bob", "bob-password", 300)
+		pushWithAuth(t, src+"/models/model:v1", &authn.Basic{Username: "alice", Password: "alice-password"})
+
+		client, err := NewClient(WithStoreRootPath(t.TempDir()), WithRegistryCredentials(map[string]mdregistry.Credential{
+			src:      {Username: "alice", Password: "alice-password"},
+			dst.host: {Username: "bob", Password: "bob-password"},
+		}))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), src+"/models/model:v1", nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if err := client.PushModel(t.Context(), dst.host+"/models/model:v1", nil, WithPushSource(src+"/models/model:v1")); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	})
+
+	t.Run("tokens are cached until they expire", func(t *testing.T) {
+		// Tokens are refreshed 10 seconds before they expire, so these are valid for a second
+		reg := newTokenRegistry(t, "bob", "bob-password", 11)
+		pushWithAuth(t, reg.host+"/models/model:v1", &authn.Basic{Username: "bob", Password: "bob-password"})
+		exchanges := reg.exchangeCount()
+
+		client, err := NewClient(WithStoreRootPath(t.TempDir()), WithRegistryCredentials(map[string]mdregistry.Credential{
+			reg.host: {Username: "bob", Password: "bob-password"},
+		}))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := client.registry.Digest(t.Context(), reg.host+"/models/model:v1"); err != nil {
+				t.Fatalf("Failed to resolve digest: %v", err)
+			}
+		}
+		if got := reg.exchangeCount() - exchanges; got != 1 {
+			t.Errorf("Expected a single token exchange, got %d", got)
+		}
+
+		time.Sleep(1100 * time.Millisecond)
+		if _, err := client.registry.Digest(t.Context(), reg.host+"/models/model:v1"); err != nil {
+			t.Fatalf("Failed to resolve digest: %v", err)
+		}
+		if got := reg.exchangeCount() - exchanges; got != 2 {
+			t.Errorf("Expected the expired token to be refreshed, got %d exchanges", got)
+		}
+	})
+
+	t.Run("credential helper", func(t *testing.T) {
+		reg := basicAuthRegistry(t, "carol", "carol-password")
+		pushWithAuth(t, reg+"/models/model:v1", &authn.Basic{Username: "carol", Password: "carol-password"})
+
+		binDir := t.TempDir()
+		helper := "#!/bin/sh\nread server\necho \"{\\\"ServerURL\\\":\\\"$server\\\",\\\"Username\\\":\\\"carol\\\",\\\"Secret\\\":\\\"carol-password\\\"}\"\n"
+		if err := os.WriteFile(filepath.Join(binDir, "docker-credential-test"), []byte(helper), 0o755); err != nil {
+			t.Fatalf("Failed to write credential helper: %v", err)
+		}
+		t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+		client, err := NewClient(WithStoreRootPath(t.TempDir()), WithRegistryCredentials(map[string]mdregistry.Credential{
+			reg: {Helper: "test"},
+		}))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), reg+"/models/model:v1", nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+	})
+
+	t.Run("login and logout", func(t *testing.T) {
+		reg := basicAuthRegistry(t, "dave", "dave-password")
+		pushWithAuth(t, reg+"/models/model:v1", &authn.Basic{Username: "dave", Password: "dave-password"})
+		storeDir := t.TempDir()
+
+		client, err := NewClient(WithStoreRootPath(storeDir))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		err = client.Login(t.Context(), reg, mdregistry.Credential{Username: "dave", Password: "wrong"})
+		if !errors.Is(err, mdregistry.ErrUnauthorized) {
+			t.Fatalf("Expected ErrUnauthorized for wrong password, got %v", err)
+		}
+		if err := client.Login(t.Context(), reg, mdregistry.Credential{Username: "dave", Password: "dave-password"}); err != nil {
+			t.Fatalf("Failed to log in: %v", err)
+		}
+		info, err := os.Stat(filepath.Join(storeDir, "credentials.json"))
+		if err != nil {
+			t.Fatalf("Expected credentials file: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("Expected credentials file mode 0600, got %o", perm)
+		}
+
+		// Clients using the store authenticate with the saved credentials
+		loggedIn, err := NewClient(WithStoreRootPath(storeDir))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := loggedIn.PullModel(t.Context(), reg+"/models/model:v1", nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+
+		if err := loggedIn.Logout(reg); err != nil {
+			t.Fatalf("Failed to log out: %v", err)
+		}
+		if err := loggedIn.Logout(reg); !errors.Is(err, ErrNotLoggedIn) {
+			t.Errorf("Expected ErrNotLoggedIn, got %v", err)
+		}
+		loggedOut, err := NewClient(WithStoreRootPath(storeDir))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := loggedOut.registry.Digest(t.Context(), reg+"/models/model:v1"); !errors.Is(err, mdregistry.ErrUnauthorized) {
+			t.Errorf("Expected ErrUnauthorized after logout, got %v", err)
+		}
+	})
+}
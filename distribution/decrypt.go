@@ -0,0 +1,39 @@
+package distribution
+
+import (
+	"crypto/ecdh"
+	"fmt"
+
+	"github.com/docker/model-distribution/encryption"
+	"github.com/docker/model-distribution/types"
+)
+
+// WithDecryptionKeys sets the private keys used to decrypt encrypted model layers. Layers are stored encrypted and
+// decrypted when a bundle is created by GetBundle.
+func WithDecryptionKeys(keys ...*ecdh.PrivateKey) Option {
+	return func(o *options) {
+		o.decryptKeys = append(o.decryptKeys, keys...)
+	}
+}
+
+// checkDecryptable fails if the model has encrypted layers and the client has decryption keys, none of which is a
+// recipient of the layers. It only inspects the manifest, so it runs before any layer is downloaded.
+func (c *Client) checkDecryptable(reference string, model types.ModelArtifact) error {
+	manifest, err := model.Manifest()
+	if err != nil {
+		return fmt.Errorf("getting model manifest: %w", err)
+	}
+	for _, layer := range manifest.Layers {
+		if !encryption.IsEncrypted(layer.MediaType) {
+			continue
+		}
+		if len(c.decryptKeys) == 0 {
+			c.log.Warnln("Model has encrypted layers but no decryption key is configured:", reference)
+			return nil
+		}
+		if !encryption.HasRecipient(layer.Annotations, c.decryptKeys) {
+			return fmt.Errorf("layer %s of %s: %w", layer.Digest, reference, encryption.ErrNoRecipient)
+		}
+	}
+	return nil
+}
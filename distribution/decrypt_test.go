@@ -0,0 +1,120 @@
+package distribution
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/docker/model-distribution/encryption"
+	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/types"
+)
+
+func TestEncryptedModel(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	tag := registryURL.Host + "/encrypted/model:v1"
+
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	// Push an encrypted copy of a plaintext local model
+	pusher, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := pusher.store.Write(mdl, []string{tag}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := pusher.PushModel(t.Context(), tag, nil, WithPushEncryption(key.PublicKey())); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	remote, err := pusher.registry.Model(t.Context(), tag)
+	if err != nil {
+		t.Fatalf("Failed to read model from registry: %v", err)
+	}
+	manifest, err := remote.Manifest()
+	if err != nil {
+		t.Fatalf("Failed to get manifest: %v", err)
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != types.MediaTypeGGUFEncrypted {
+		t.Fatalf("Expected one encrypted GGUF layer, got %+v", manifest.Layers)
+	}
+
+	t.Run("decrypt at bundle time", func(t *testing.T) {
+		client, err := NewClient(WithStoreRootPath(t.TempDir()), WithDecryptionKeys(other, key))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		bundle, err := client.GetBundle(tag)
+		if err != nil {
+			t.Fatalf("Failed to get bundle: %v", err)
+		}
+		expected, err := os.ReadFile(testGGUFFile)
+		if err != nil {
+			t.Fatalf("Failed to read GGUF file: %v", err)
+		}
+		actual, err := os.ReadFile(bundle.GGUFPath())
+		if err != nil {
+			t.Fatalf("Failed to read bundled GGUF file: %v", err)
+		}
+		if !bytes.Equal(actual, expected) {
+			t.Fatalf("Expected the bundled GGUF file to be decrypted")
+		}
+	})
+
+	t.Run("not a recipient", func(t *testing.T) {
+		client, err := NewClient(WithStoreRootPath(t.TempDir()), WithDecryptionKeys(other))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), tag, nil); !errors.Is(err, encryption.ErrNoRecipient) {
+			t.Fatalf("Expected %v, got %v", encryption.ErrNoRecipient, err)
+		}
+	})
+
+	t.Run("no decryption key", func(t *testing.T) {
+		client, err := NewClient(WithStoreRootPath(t.TempDir()))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if _, err := client.GetBundle(tag); !errors.Is(err, encryption.ErrNoRecipient) {
+			t.Fatalf("Expected %v, got %v", encryption.ErrNoRecipient, err)
+		}
+		digest, err := remote.Digest()
+		if err != nil {
+			t.Fatalf("Failed to get digest: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(client.GetStorePath(), "bundles", digest.Algorithm, digest.Hex)); !os.IsNotExist(err) {
+			t.Fatalf("Expected no bundle to be left behind, got %v", err)
+		}
+	})
+}
@@ -3,11 +3,20 @@ package distribution
 import (
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"slices"
+	"strings"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
 	"github.com/docker/model-distribution/internal/gguf"
+	mdregistry "github.com/docker/model-distribution/registry"
 )
 
 func TestDeleteModel(t *testing.T) {
@@ -178,3 +187,118 @@ func TestDeleteModel(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteRemote(t *testing.T) {
+	// Reject deletes in the "readonly" repository the way registries without delete support do, and deny them in
+	// the "protected" repository
+	reg := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			switch {
+			case strings.Contains(r.URL.Path, "/readonly/"):
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			case strings.Contains(r.URL.Path, "/protected/"):
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":[{"code":"DENIED","message":"requested access to the resource is denied"}]}`))
+				return
+			}
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	push := func(t *testing.T, reference string) {
+		t.Helper()
+		ref, err := name.ParseReference(reference)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, mdl); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	}
+
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	t.Run("by tag", func(t *testing.T) {
+		tag := registryURL.Host + "/delete/model:v1"
+		push(t, tag)
+		deleted, err := client.DeleteRemote(t.Context(), tag)
+		if err != nil {
+			t.Fatalf("Failed to delete model: %v", err)
+		}
+		if deleted != digest.String() {
+			t.Errorf("Expected deleted digest %s, got %s", digest, deleted)
+		}
+		ref, err := name.ParseReference(registryURL.Host + "/delete/model@" + digest.String())
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if _, err := remote.Head(ref); err == nil {
+			t.Errorf("Expected manifest to be deleted")
+		}
+	})
+
+	t.Run("by digest", func(t *testing.T) {
+		push(t, registryURL.Host+"/delete/model:v2")
+		deleted, err := client.DeleteRemote(t.Context(), registryURL.Host+"/delete/model@"+digest.String())
+		if err != nil {
+			t.Fatalf("Failed to delete model: %v", err)
+		}
+		if deleted != digest.String() {
+			t.Errorf("Expected deleted digest %s, got %s", digest, deleted)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.DeleteRemote(t.Context(), registryURL.Host+"/delete/missing:v1")
+		if !errors.Is(err, mdregistry.ErrModelNotFound) {
+			t.Errorf("Expected ErrModelNotFound, got %v", err)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		tag := registryURL.Host + "/readonly/model:v1"
+		push(t, tag)
+		_, err := client.DeleteRemote(t.Context(), tag)
+		if !errors.Is(err, mdregistry.ErrUnsupported) {
+			t.Errorf("Expected ErrUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		tag := registryURL.Host + "/protected/model:v1"
+		push(t, tag)
+		_, err := client.DeleteRemote(t.Context(), tag)
+		if !errors.Is(err, mdregistry.ErrDenied) {
+			t.Errorf("Expected ErrDenied, got %v", err)
+		}
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		offline, err := NewClient(WithStoreRootPath(t.TempDir()), WithOffline(true))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := offline.DeleteRemote(t.Context(), registryURL.Host+"/delete/model:v1"); !errors.Is(err, ErrOffline) {
+			t.Errorf("Expected ErrOffline, got %v", err)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package distribution
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/docker/model-distribution/internal/store"
 	"github.com/docker/model-distribution/registry"
@@ -17,6 +18,11 @@ var (
 		types.MediaTypeModelConfigV01,
 	))
 	ErrConflict = errors.New("resource conflict")
+	ErrOffline  = errors.New("registry access disabled in offline mode")
+	// ErrVerificationFailed is returned when a pulled model does not satisfy the signature verification policy
+	ErrVerificationFailed = errors.New("signature verification failed")
+	// ErrPolicyDenied is returned when the admission policy denies a pull
+	ErrPolicyDenied = errors.New("denied by admission policy")
 )
 
 // ReferenceError represents an error related to an invalid model reference
@@ -37,3 +43,56 @@ func (e *ReferenceError) Unwrap() error {
 func (e *ReferenceError) Is(target error) bool {
 	return target == ErrInvalidReference
 }
+
+// OfflineError represents an operation that requires registry access while the client is offline
+type OfflineError struct {
+	Operation string
+	Reference string
+}
+
+func (e *OfflineError) Error() string {
+	if e.Reference == "" {
+		return fmt.Sprintf("cannot %s: %v", e.Operation, ErrOffline)
+	}
+	return fmt.Sprintf("cannot %s %q: %v", e.Operation, e.Reference, ErrOffline)
+}
+
+// Is implements error matching for OfflineError
+func (e *OfflineError) Is(target error) bool {
+	return target == ErrOffline
+}
+
+// VerificationError represents a model that does not satisfy the signature verification policy
+type VerificationError struct {
+	Reference string
+	Digest    string
+	Err       error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("%v for %q (%s): %v", ErrVerificationFailed, e.Reference, e.Digest, e.Err)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// Is implements error matching for VerificationError
+func (e *VerificationError) Is(target error) bool {
+	return target == ErrVerificationFailed
+}
+
+// PolicyDeniedError represents a pull denied by the admission policy
+type PolicyDeniedError struct {
+	Reference string
+	Reasons   []string
+}
+
+func (e *PolicyDeniedError) Error() string {
+	return fmt.Sprintf("pulling %q %v: %s", e.Reference, ErrPolicyDenied, strings.Join(e.Reasons, "; "))
+}
+
+// Is implements error matching for PolicyDeniedError
+func (e *PolicyDeniedError) Is(target error) bool {
+	return target == ErrPolicyDenied
+}
@@ -0,0 +1,173 @@
+package distribution
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	mdregistry "github.com/docker/model-distribution/registry"
+)
+
+func TestRegistryErrors(t *testing.T) {
+	// Fail requests to a repository with the response configured for it, and serve everything else from a registry
+	type failure struct {
+		method string
+		path   string
+		status int
+		header map[string]string
+		body   string
+	}
+	failures := map[string]failure{
+		"unauthorized": {status: http.StatusUnauthorized, body: `{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`},
+		"denied":       {status: http.StatusForbidden},
+		"limited": {
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": "120"},
+			body:   `{"errors":[{"code":"TOOMANYREQUESTS","message":"pull rate limit exceeded"}]}`,
+		},
+		"toolarge": {method: http.MethodPut, path: "/blobs/uploads/", status: http.StatusBadRequest, body: `{"errors":[{"code":"SIZE_INVALID","message":"size mismatch"}]}`},
+		"orphan":   {method: http.MethodPut, path: "/manifests/", status: http.StatusBadRequest, body: `{"errors":[{"code":"MANIFEST_BLOB_UNKNOWN","message":"blob unknown to registry"}]}`},
+	}
+	reg := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repo, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/")
+		f, ok := failures[repo]
+		if !ok || (f.method != "" && f.method != r.Method) || !strings.Contains(r.URL.Path, f.path) {
+			reg.ServeHTTP(w, r)
+			return
+		}
+		for k, v := range f.header {
+			w.Header().Set(k, v)
+		}
+		if f.body != "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(f.status)
+		_, _ = w.Write([]byte(f.body))
+	}))
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	host := registryURL.Host
+
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(mdl, []string{"local/model:v1"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		run       func() error
+		target    error
+		operation string
+		code      string
+		status    int
+	}{
+		{
+			name:      "pull unauthorized",
+			run:       func() error { return client.PullModel(t.Context(), host+"/unauthorized/model:v1", nil) },
+			target:    mdregistry.ErrUnauthorized,
+			operation: "pull",
+			code:      "UNAUTHORIZED",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "pull not found",
+			run:       func() error { return client.PullModel(t.Context(), host+"/missing/model:v1", nil) },
+			target:    mdregistry.ErrModelNotFound,
+			operation: "pull",
+			code:      "NAME_UNKNOWN",
+			status:    http.StatusNotFound,
+		},
+		{
+			name:      "pull rate limited",
+			run:       func() error { return client.PullModel(t.Context(), host+"/limited/model:v1", nil) },
+			target:    mdregistry.ErrRateLimited,
+			operation: "pull",
+			code:      "TOOMANYREQUESTS",
+			status:    http.StatusTooManyRequests,
+		},
+		{
+			name: "inspect denied without error envelope",
+			run: func() error {
+				_, err := client.registry.Digest(t.Context(), host+"/denied/model:v1")
+				return err
+			},
+			target:    mdregistry.ErrDenied,
+			operation: "inspect",
+			code:      "DENIED",
+			status:    http.StatusForbidden,
+		},
+		{
+			name: "inspect not found without error envelope",
+			run: func() error {
+				_, err := client.registry.Digest(t.Context(), host+"/missing/model:v1")
+				return err
+			},
+			target:    mdregistry.ErrModelNotFound,
+			operation: "inspect",
+			code:      "MANIFEST_UNKNOWN",
+			status:    http.StatusNotFound,
+		},
+		{
+			name: "push size invalid",
+			run: func() error {
+				return client.PushModel(t.Context(), host+"/toolarge/model:v1", nil, WithPushSource("local/model:v1"))
+			},
+			target:    mdregistry.ErrSizeInvalid,
+			operation: "push",
+			code:      "SIZE_INVALID",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name: "push blob unknown",
+			run: func() error {
+				return client.PushModel(t.Context(), host+"/orphan/model:v1", nil, WithPushSource("local/model:v1"))
+			},
+			target:    mdregistry.ErrBlobUnknown,
+			operation: "push",
+			code:      "MANIFEST_BLOB_UNKNOWN",
+			status:    http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.run()
+			if !errors.Is(err, tc.target) {
+				t.Fatalf("Expected error matching %v, got %v", tc.target, err)
+			}
+			var regErr *mdregistry.Error
+			if !errors.As(err, &regErr) {
+				t.Fatalf("Expected *registry.Error, got %T", err)
+			}
+			if regErr.Operation != tc.operation {
+				t.Errorf("Expected operation %q, got %q", tc.operation, regErr.Operation)
+			}
+			if regErr.Code != tc.code {
+				t.Errorf("Expected code %q, got %q", tc.code, regErr.Code)
+			}
+			if regErr.StatusCode != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, regErr.StatusCode)
+			}
+			if tc.target == mdregistry.ErrRateLimited && regErr.RetryAfter != 2*time.Minute {
+				t.Errorf("Expected retry after 2m, got %s", regErr.RetryAfter)
+			}
+		})
+	}
+}
@@ -1,11 +1,24 @@
 package distribution
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/compression"
+
 	"github.com/docker/model-distribution/builder"
+	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/internal/progress"
+	"github.com/docker/model-distribution/oci"
 	"github.com/docker/model-distribution/tarball"
 )
 
@@ -53,3 +66,441 @@ func TestLoadModel(t *testing.T) {
 		t.Fatalf("Failed to get model: %v", err)
 	}
 }
+
+func TestLoadModelOCILayoutArchive(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	var buf bytes.Buffer
+	target, err := tarball.NewTarget(&buf, tarball.WithOCILayout("v1"))
+	if err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+	bldr, err := builder.FromGGUF(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create builder: %v", err)
+	}
+	if err := bldr.Build(t.Context(), target, nil); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	id, err := client.LoadModel(&buf, nil)
+	if err != nil {
+		t.Fatalf("LoadModel exited with error: %v", err)
+	}
+	model, err := client.GetModel(id)
+	if err != nil {
+		t.Fatalf("Failed to get model: %v", err)
+	}
+	if _, err := model.Config(); err != nil {
+		t.Fatalf("Failed to get model config: %v", err)
+	}
+}
+
+func TestLoadModelCompressed(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	pr, pw := io.Pipe()
+	target, err := tarball.NewTarget(pw, tarball.WithCompression(compression.ZStd))
+	if err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+	bldr, err := builder.FromGGUF(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create builder: %v", err)
+	}
+	go func() {
+		pw.CloseWithError(bldr.Build(t.Context(), target, nil))
+	}()
+
+	// The archive is decompressed as it is streamed
+	id, err := client.LoadModel(pr, nil)
+	if err != nil {
+		t.Fatalf("LoadModel exited with error: %v", err)
+	}
+	if _, err := client.GetModel(id); err != nil {
+		t.Fatalf("Failed to get model: %v", err)
+	}
+}
+
+func TestLoadModelCancelled(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	client, err := NewClient(WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Build the archive up front so it can be replayed with a cancellation part way through
+	var buf bytes.Buffer
+	target, err := tarball.NewTarget(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+	bldr, err := builder.FromGGUF(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create builder: %v", err)
+	}
+	if err := bldr.Build(t.Context(), target, nil); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	r := &cancelAfterReader{r: bytes.NewReader(buf.Bytes()), remaining: buf.Len() / 2, cancel: cancel}
+	if _, err := client.LoadModelContext(ctx, r, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	// Ensure no blobs or incomplete files were left behind
+	err = filepath.WalkDir(filepath.Join(tempDir, "blobs"), func(path string, d os.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			t.Errorf("Unexpected file left in store: %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk blobs directory: %v", err)
+	}
+	models, err := client.ListModels()
+	if err != nil {
+		t.Fatalf("Failed to list models: %v", err)
+	}
+	if len(models) != 0 {
+		t.Fatalf("Expected no models, got %d", len(models))
+	}
+}
+
+func TestDeleteModelCancelled(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	client, err := NewClient(WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(mdl, []string{"some/model:latest"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := client.DeleteModelContext(ctx, "some/model:latest", false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if _, err := client.GetModel("some/model:latest"); err != nil {
+		t.Fatalf("Expected model to be kept: %v", err)
+	}
+}
+
+// cancelAfterReader cancels the context once the given number of bytes has been read
+type cancelAfterReader struct {
+	r         io.Reader
+	remaining int
+	cancel    context.CancelFunc
+}
+
+func (c *cancelAfterReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		c.cancel()
+	}
+	n, err := c.r.Read(p[:min(len(p), max(c.remaining, 1))])
+	c.remaining -= n
+	return n, err
+}
+
+func TestLoadModelProgress(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	client, err := NewClient(WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var archive bytes.Buffer
+	target, err := tarball.NewTarget(&archive)
+	if err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+	bldr, err := builder.FromGGUF(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create builder: %v", err)
+	}
+	if err := bldr.Build(t.Context(), target, nil); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	ggufInfo, err := os.Stat(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to stat model file: %v", err)
+	}
+
+	load := func(t *testing.T, r io.Reader) ([]progress.Message, error) {
+		var buf bytes.Buffer
+		_, err := client.LoadModel(r, &buf)
+		var messages []progress.Message
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			var msg progress.Message
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				t.Fatalf("Failed to parse JSON progress message: %v, line: %s", err, scanner.Text())
+			}
+			messages = append(messages, msg)
+		}
+		return messages, err
+	}
+
+	t.Run("new blobs", func(t *testing.T) {
+		messages, err := load(t, bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to load model: %v", err)
+		}
+		if len(messages) < 3 {
+			t.Fatalf("Expected progress for the model and config blobs and a success message, got %d messages", len(messages))
+		}
+		var sawModel bool
+		for _, msg := range messages[:len(messages)-1] {
+			if msg.Type != "progress" || msg.Layer.ID == "" {
+				t.Fatalf("Expected layer progress message, got %+v", msg)
+			}
+			if msg.Layer.Size == uint64(ggufInfo.Size()) && msg.Layer.Current == msg.Layer.Size {
+				sawModel = true
+			}
+		}
+		if !sawModel {
+			t.Errorf("Expected completed progress for the model blob with its size from the tar header")
+		}
+		if last := messages[len(messages)-1]; last.Type != "success" {
+			t.Errorf("Expected last message to be success, got %+v", last)
+		}
+	})
+
+	t.Run("cached blobs", func(t *testing.T) {
+		messages, err := load(t, bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to load model: %v", err)
+		}
+		for _, msg := range messages[:len(messages)-1] {
+			if msg.Layer.Current != msg.Layer.Size {
+				t.Errorf("Expected cached blob to be reported as complete, got %+v", msg)
+			}
+		}
+		if last := messages[len(messages)-1]; last.Type != "success" {
+			t.Errorf("Expected last message to be success, got %+v", last)
+		}
+	})
+
+	t.Run("truncated archive", func(t *testing.T) {
+		messages, err := load(t, bytes.NewReader(archive.Bytes()[:archive.Len()/2]))
+		if err == nil {
+			t.Fatalf("Expected error loading truncated archive")
+		}
+		if len(messages) == 0 {
+			t.Fatalf("Expected error to be reported to the progress writer")
+		}
+		if last := messages[len(messages)-1]; last.Type != "error" || !strings.Contains(last.Message, err.Error()) {
+			t.Errorf("Expected last message to report %q, got %+v", err, last)
+		}
+	})
+}
+
+func TestLoadFromOCILayout(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	dir := t.TempDir()
+	b, err := builder.FromGGUF(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create builder: %v", err)
+	}
+	if err := b.Build(t.Context(), oci.NewTarget(dir, "v1"), nil); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	id, err := client.LoadFromOCILayout(dir, "v1")
+	if err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	if err := client.Tag(id, "some/repo:v1"); err != nil {
+		t.Fatalf("Failed to tag model: %v", err)
+	}
+	model, err := client.GetModel("some/repo:v1")
+	if err != nil {
+		t.Fatalf("Failed to get model: %v", err)
+	}
+	if mid, err := model.ID(); err != nil || mid != id {
+		t.Fatalf("Expected model ID %s, got %s (%v)", id, mid, err)
+	}
+	bundle, err := client.GetBundle("some/repo:v1")
+	if err != nil {
+		t.Fatalf("Failed to get bundle: %v", err)
+	}
+	if _, err := os.Stat(bundle.GGUFPath()); err != nil {
+		t.Fatalf("Expected bundled GGUF file: %v", err)
+	}
+
+	if _, err := client.LoadFromOCILayout(dir, "missing"); !errors.Is(err, oci.ErrNotFound) {
+		t.Fatalf("Expected %v, got %v", oci.ErrNotFound, err)
+	}
+}
+
+func TestSaveModelsCancel(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(mdl, []string{"some/model:v1"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := client.store.Write(mutate.ContextSize(mdl, 2048), []string{"some/other"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	// Cancel the save as soon as the archive is being written
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	w := writerFunc(func(p []byte) (int, error) {
+		cancel()
+		return len(p), nil
+	})
+	err = client.SaveModels(ctx, []string{"some/model:v1", "some/other"}, w, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+// writerFunc is an io.Writer implemented by a function
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestSaveAndLoadModels(t *testing.T) {
+	source, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := source.store.Write(mdl, []string{"some/model:v1"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	other := mutate.ContextSize(mdl, 2048)
+	if err := source.store.Write(other, []string{"some/other"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	otherID, err := other.ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := source.SaveModels(t.Context(), []string{"some/model:v1", "some/other", otherID}, &buf, nil); err != nil {
+		t.Fatalf("Failed to save models: %v", err)
+	}
+
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ids, err := client.LoadModels(t.Context(), &buf, nil)
+	if err != nil {
+		t.Fatalf("Failed to load models: %v", err)
+	}
+	if len(ids) != 2 || ids[1] != otherID {
+		t.Fatalf("Expected 2 models ending with %s, got %v", otherID, ids)
+	}
+	for tag, id := range map[string]string{"some/model:v1": ids[0], "some/other": otherID} {
+		model, err := client.GetModel(tag)
+		if err != nil {
+			t.Fatalf("Failed to get model %q: %v", tag, err)
+		}
+		if mid, err := model.ID(); err != nil || mid != id {
+			t.Fatalf("Expected %q to be %s, got %s (%v)", tag, id, mid, err)
+		}
+	}
+}
+
+func TestLoadModelInvalidArchive(t *testing.T) {
+	var buf bytes.Buffer
+	target, err := tarball.NewTarget(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+	bldr, err := builder.FromGGUF(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create builder: %v", err)
+	}
+	if err := bldr.Build(t.Context(), target, nil); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	t.Run("poisoned blob", func(t *testing.T) {
+		client, err := NewClient(WithStoreRootPath(t.TempDir()))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		// Flip a byte of the GGUF contents so that they no longer match the blob's digest
+		contents, err := os.ReadFile(testGGUFFile)
+		if err != nil {
+			t.Fatalf("Failed to read GGUF file: %v", err)
+		}
+		archive := bytes.Clone(buf.Bytes())
+		i := bytes.Index(archive, contents)
+		if i < 0 {
+			t.Fatalf("GGUF contents not found in archive")
+		}
+		archive[i+len(contents)-1] ^= 0xff
+		if _, err := client.LoadModel(bytes.NewReader(archive), nil); !errors.Is(err, tarball.ErrInvalidArchive) {
+			t.Fatalf("Expected %v, got %v", tarball.ErrInvalidArchive, err)
+		}
+		blobs, err := os.ReadDir(filepath.Join(client.GetStorePath(), "blobs", "sha256"))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Failed to list blobs: %v", err)
+		}
+		if len(blobs) != 0 {
+			t.Fatalf("Expected no blobs to be left in the store, got %d", len(blobs))
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		client, err := NewClient(WithStoreRootPath(t.TempDir()), WithLoadLimits(tarball.WithMaxBlobSize(1024)))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := client.LoadModel(bytes.NewReader(buf.Bytes()), nil); !errors.Is(err, tarball.ErrLimitExceeded) {
+			t.Fatalf("Expected %v, got %v", tarball.ErrLimitExceeded, err)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package distribution
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	mdregistry "github.com/docker/model-distribution/registry"
+)
+
+// countingRegistry is a test registry that counts the manifest and blob requests it serves
+type countingRegistry struct {
+	*httptest.Server
+	manifests requestRecorder
+	blobs     requestRecorder
+}
+
+func newCountingRegistry(h http.Handler) *countingRegistry {
+	r := &countingRegistry{
+		manifests: requestRecorder{path: "/manifests/"},
+		blobs:     requestRecorder{path: "/blobs/"},
+	}
+	r.Server = httptest.NewServer(r.manifests.wrap(r.blobs.wrap(h)))
+	return r
+}
+
+func (r *countingRegistry) host(t *testing.T) string {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	return u.Host
+}
+
+func TestRegistryMirrors(t *testing.T) {
+	upstream := newCountingRegistry(registry.New())
+	defer upstream.Close()
+	mirror := newCountingRegistry(registry.New())
+	defer mirror.Close()
+	deadMirror := httptest.NewServer(http.NotFoundHandler())
+	deadMirror.Close()
+	deadHost := strings.TrimPrefix(deadMirror.URL, "http://")
+
+	// Serve a different manifest than requested for every digest-pinned reference
+	tampering := newCountingRegistry(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			return
+		}
+		if strings.Contains(r.URL.Path, "/manifests/sha256:") {
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Header().Set("Docker-Content-Digest", r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[]}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer tampering.Close()
+
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	for _, host := range []string{upstream.host(t), mirror.host(t)} {
+		ref, err := name.ParseReference(host + "/mirror-test/model:v1")
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, mdl); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	}
+	tag := upstream.host(t) + "/mirror-test/model:v1"
+
+	newClient := func(t *testing.T, mirrors ...string) *Client {
+		tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+		if err != nil {
+			t.Fatalf("Failed to create temp directory: %v", err)
+		}
+		t.Cleanup(func() { os.RemoveAll(tempDir) })
+		client, err := NewClient(
+			WithStoreRootPath(tempDir),
+			WithRegistryMirrors(map[string][]string{upstream.host(t): mirrors}),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client
+	}
+
+	assertPulled := func(t *testing.T, client *Client, reference string) {
+		t.Helper()
+		pulled, err := client.GetModel(reference)
+		if err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+		if id, _ := pulled.ID(); id != digest.String() {
+			t.Fatalf("Expected model %s, got %s", digest, id)
+		}
+	}
+
+	t.Run("reads from mirror", func(t *testing.T) {
+		client := newClient(t, mirror.host(t))
+		upstream.manifests.reset()
+		upstream.blobs.reset()
+		mirror.manifests.reset()
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		assertPulled(t, client, tag)
+		if n := upstream.manifests.count(); n != 0 {
+			t.Errorf("Expected no manifest requests to upstream, got %d", n)
+		}
+		if mirror.manifests.count() == 0 {
+			t.Errorf("Expected manifest to be read from mirror")
+		}
+		if n := upstream.blobs.count(); n != 0 {
+			t.Errorf("Expected no blob requests to upstream, got %d", n)
+		}
+	})
+
+	t.Run("reads blobs from mirror when upstream is unreachable", func(t *testing.T) {
+		offline := deadHost + "/mirror-test/model:v1"
+		tempDir := t.TempDir()
+		client, err := NewClient(
+			WithStoreRootPath(tempDir),
+			WithRegistryMirrors(map[string][]string{deadHost: {mirror.host(t)}}),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		mirror.blobs.reset()
+		if err := client.PullModel(t.Context(), offline, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		assertPulled(t, client, offline)
+		if mirror.blobs.count() == 0 {
+			t.Errorf("Expected blobs to be read from mirror")
+		}
+	})
+
+	t.Run("falls back to next mirror and upstream", func(t *testing.T) {
+		client := newClient(t, deadHost, tampering.host(t))
+		upstream.manifests.reset()
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		assertPulled(t, client, tag)
+		if upstream.manifests.count() == 0 {
+			t.Errorf("Expected manifest to be read from upstream")
+		}
+	})
+
+	t.Run("verifies digest-pinned references", func(t *testing.T) {
+		client := newClient(t, tampering.host(t))
+		upstream.manifests.reset()
+		tampering.manifests.reset()
+		pinned := upstream.host(t) + "/mirror-test/model@" + digest.String()
+		if err := client.PullModel(t.Context(), pinned, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if tampering.manifests.count() == 0 {
+			t.Errorf("Expected mirror to be tried first")
+		}
+		if upstream.manifests.count() == 0 {
+			t.Errorf("Expected manifest to be read from upstream after mirror failed verification")
+		}
+		assertPulled(t, client, digest.String())
+	})
+
+	t.Run("missing everywhere", func(t *testing.T) {
+		client := newClient(t, mirror.host(t))
+		err := client.PullModel(t.Context(), upstream.host(t)+"/mirror-test/model:missing", nil)
+		if !errors.Is(err, mdregistry.ErrModelNotFound) {
+			t.Fatalf("Expected ErrModelNotFound, got: %v", err)
+		}
+	})
+}
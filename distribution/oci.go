@@ -0,0 +1,33 @@
+package distribution
+
+import (
+	"fmt"
+
+	"github.com/docker/model-distribution/oci"
+)
+
+// LoadFromOCILayout loads the model listed under ref in the OCI image layout at dir into the store and returns its ID.
+// ref is a ref name (org.opencontainers.image.ref.name) or a manifest digest; it may be empty if the layout lists a
+// single manifest. The model is not tagged.
+func (c *Client) LoadFromOCILayout(dir, ref string) (string, error) {
+	c.log.Infoln("Loading model from OCI image layout:", dir, "ref:", ref)
+	mdl, err := oci.Image(dir, ref)
+	if err != nil {
+		return "", fmt.Errorf("reading model from image layout: %w", err)
+	}
+	if err := checkCompat(mdl); err != nil {
+		return "", err
+	}
+	if err := c.checkDecryptable(dir, mdl); err != nil {
+		return "", err
+	}
+	id, err := mdl.ID()
+	if err != nil {
+		return "", fmt.Errorf("getting model ID: %w", err)
+	}
+	if err := c.store.Write(mdl, nil, nil); err != nil {
+		return "", fmt.Errorf("writing model to store: %w", err)
+	}
+	c.log.Infoln("Loaded model with ID:", id)
+	return id, nil
+}
@@ -0,0 +1,31 @@
+package distribution
+
+import (
+	"io"
+
+	"github.com/docker/model-distribution/progress"
+)
+
+// startPhase reports the start of an operation and returns the progress writer to report the rest of the operation
+// to. Failures to report progress are logged but never fail the operation.
+func (c *Client) startPhase(w io.Writer, phase progress.Phase, reference string) progress.Writer {
+	pw := progress.NewWriter(w)
+	if err := pw.Handle(progress.PhaseStart{Phase: phase, Reference: reference}); err != nil {
+		c.log.Warnf("Failed to write progress: %v", err)
+		// If we fail to write progress, don't try again
+		return progress.NewWriter(nil)
+	}
+	return pw
+}
+
+// endPhase reports the end of an operation, with message on success or err on failure
+func (c *Client) endPhase(pw progress.Writer, phase progress.Phase, reference, message string, err error) {
+	if writeErr := pw.Handle(progress.PhaseEnd{
+		Phase:     phase,
+		Reference: reference,
+		Message:   message,
+		Err:       err,
+	}); writeErr != nil {
+		c.log.Warnf("Failed to write progress: %v", writeErr)
+	}
+}
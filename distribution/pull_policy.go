@@ -0,0 +1,65 @@
+package distribution
+
+import (
+	"fmt"
+)
+
+// PullPolicy determines when PullModel contacts the registry
+type PullPolicy string
+
+const (
+	// PullAlways resolves the reference against the registry on every pull. This is the default policy.
+	PullAlways PullPolicy = "always"
+	// PullIfMissing uses the model from the local store if the reference is present and only contacts the registry
+	// otherwise. Models from the local store are neither verified nor admitted again.
+	PullIfMissing PullPolicy = "missing"
+	// PullNever never contacts the registry. Pulls of references that are not in the local store fail with
+	// ErrOffline. Models from the local store are neither verified nor admitted again.
+	PullNever PullPolicy = "never"
+)
+
+// ParsePullPolicy parses a pull policy from its string representation
+func ParsePullPolicy(s string) (PullPolicy, error) {
+	switch p := PullPolicy(s); p {
+	case PullAlways, PullIfMissing, PullNever:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid pull policy %q: must be one of %q, %q or %q", s, PullAlways, PullIfMissing, PullNever)
+	}
+}
+
+// PullOption represents an option for a single call to PullModel
+type PullOption func(*pullOptions)
+
+// pullOptions holds the configuration for a single pull
+type pullOptions struct {
+	policy       PullPolicy
+	verification VerificationPolicy
+	referrers    []string
+}
+
+// WithPullPolicy sets the pull policy. PullModel fails if the policy is not one of the known policies.
+func WithPullPolicy(policy PullPolicy) PullOption {
+	return func(o *pullOptions) {
+		if policy != "" {
+			o.policy = policy
+		}
+	}
+}
+
+func defaultPullOptions() *pullOptions {
+	return &pullOptions{
+		policy: PullAlways,
+	}
+}
+
+// checkOnline returns an *OfflineError if the client is in offline mode
+func (c *Client) checkOnline(operation, reference string) error {
+	if c.offline {
+		return &OfflineError{
+			Operation: operation,
+			Reference: reference,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package distribution
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/docker/model-distribution/internal/gguf"
+)
+
+func TestPullPolicy(t *testing.T) {
+	// Set up test registry that counts requests
+	var requests atomic.Int32
+	reg := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		reg.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	tag := registryURL.Host + "/policy-test/model:v1"
+	if err := writeToRegistry(testGGUFFile, tag); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+
+	newClient := func(t *testing.T, opts ...Option) *Client {
+		tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+		if err != nil {
+			t.Fatalf("Failed to create temp directory: %v", err)
+		}
+		t.Cleanup(func() { os.RemoveAll(tempDir) })
+		client, err := NewClient(append([]Option{WithStoreRootPath(tempDir)}, opts...)...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client
+	}
+
+	t.Run("if missing pulls absent model", func(t *testing.T) {
+		client := newClient(t)
+		requests.Store(0)
+		if err := client.PullModel(t.Context(), tag, nil, WithPullPolicy(PullIfMissing)); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if requests.Load() == 0 {
+			t.Fatalf("Expected registry to be contacted")
+		}
+		if _, err := client.GetModel(tag); err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+	})
+
+	t.Run("if missing uses local model without network", func(t *testing.T) {
+		client := newClient(t)
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		requests.Store(0)
+		var buf bytes.Buffer
+		if err := client.PullModel(t.Context(), tag, &buf, WithPullPolicy(PullIfMissing)); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if n := requests.Load(); n != 0 {
+			t.Fatalf("Expected no registry requests, got %d", n)
+		}
+		if !strings.Contains(buf.String(), "Using cached model") {
+			t.Errorf("Expected cached model message, got %q", buf.String())
+		}
+	})
+
+	t.Run("always contacts registry", func(t *testing.T) {
+		client := newClient(t)
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		requests.Store(0)
+		if err := client.PullModel(t.Context(), tag, nil, WithPullPolicy(PullAlways)); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if requests.Load() == 0 {
+			t.Fatalf("Expected registry to be contacted")
+		}
+	})
+
+	t.Run("never fails for absent model", func(t *testing.T) {
+		client := newClient(t)
+		requests.Store(0)
+		err := client.PullModel(t.Context(), tag, nil, WithPullPolicy(PullNever))
+		if !errors.Is(err, ErrOffline) {
+			t.Fatalf("Expected ErrOffline, got: %v", err)
+		}
+		var offlineErr *OfflineError
+		if !errors.As(err, &offlineErr) || offlineErr.Reference != tag {
+			t.Fatalf("Expected *OfflineError for %q, got: %v", tag, err)
+		}
+		if n := requests.Load(); n != 0 {
+			t.Fatalf("Expected no registry requests, got %d", n)
+		}
+	})
+
+	t.Run("never uses local model", func(t *testing.T) {
+		client := newClient(t)
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		requests.Store(0)
+		if err := client.PullModel(t.Context(), tag, nil, WithPullPolicy(PullNever)); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if n := requests.Load(); n != 0 {
+			t.Fatalf("Expected no registry requests, got %d", n)
+		}
+	})
+
+	t.Run("unknown policy is rejected", func(t *testing.T) {
+		client := newClient(t)
+		requests.Store(0)
+		err := client.PullModel(t.Context(), tag, nil, WithPullPolicy("always "))
+		if err == nil || !strings.Contains(err.Error(), "invalid pull policy") {
+			t.Fatalf("Expected invalid pull policy error, got: %v", err)
+		}
+		if n := requests.Load(); n != 0 {
+			t.Fatalf("Expected no registry requests, got %d", n)
+		}
+	})
+}
+
+func TestOfflineClient(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	client, err := NewClient(WithStoreRootPath(tempDir), WithOffline(true))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Write a model to the store
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	localTag := "registry.example.com/offline/model:local"
+	if err := client.store.Write(mdl, []string{localTag}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	t.Run("pull local model", func(t *testing.T) {
+		if err := client.PullModel(t.Context(), localTag, nil, WithPullPolicy(PullAlways)); err != nil {
+			t.Fatalf("Expected pull of local model to succeed offline: %v", err)
+		}
+	})
+
+	t.Run("pull missing model", func(t *testing.T) {
+		if err := client.PullModel(t.Context(), "registry.example.com/offline/model:missing", nil); !errors.Is(err, ErrOffline) {
+			t.Fatalf("Expected ErrOffline, got: %v", err)
+		}
+	})
+
+	t.Run("push", func(t *testing.T) {
+		if err := client.PushModel(t.Context(), localTag, nil); !errors.Is(err, ErrOffline) {
+			t.Fatalf("Expected ErrOffline, got: %v", err)
+		}
+	})
+
+	t.Run("list remote tags", func(t *testing.T) {
+		if _, err := client.ListRemoteTags(t.Context(), "registry.example.com/offline/model", false); !errors.Is(err, ErrOffline) {
+			t.Fatalf("Expected ErrOffline, got: %v", err)
+		}
+	})
+
+	t.Run("check updates", func(t *testing.T) {
+		if _, err := client.CheckUpdates(t.Context()); !errors.Is(err, ErrOffline) {
+			t.Fatalf("Expected ErrOffline, got: %v", err)
+		}
+		if _, err := client.UpdateAll(t.Context(), nil); !errors.Is(err, ErrOffline) {
+			t.Fatalf("Expected ErrOffline, got: %v", err)
+		}
+	})
+}
+
+func TestParsePullPolicy(t *testing.T) {
+	for _, s := range []string{"always", "missing", "never"} {
+		if p, err := ParsePullPolicy(s); err != nil || string(p) != s {
+			t.Errorf("ParsePullPolicy(%q) = %q, %v", s, p, err)
+		}
+	}
+	if _, err := ParsePullPolicy("sometimes"); err == nil {
+		t.Errorf("Expected error for invalid pull policy")
+	}
+}
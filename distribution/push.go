@@ -0,0 +1,113 @@
+package distribution
+
+import (
+	"context"
+	"crypto/ecdh"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/registry"
+	"github.com/docker/model-distribution/signing"
+	"github.com/docker/model-distribution/types"
+)
+
+// PushOption represents an option for a single call to PushModel or PushDryRun
+type PushOption func(*pushOptions)
+
+// pushOptions holds the configuration for a single push
+type pushOptions struct {
+	source     string
+	tags       []string
+	signer     signing.Signer
+	recipients []*ecdh.PublicKey
+}
+
+// WithPushSource pushes the local model with the given reference, a tag or a model ID, instead of the model tagged
+// with the destination. The destination does not need to exist in the local store then.
+func WithPushSource(reference string) PushOption {
+	return func(o *pushOptions) {
+		if reference != "" {
+			o.source = reference
+		}
+	}
+}
+
+// WithPushTags pushes the model to the given extra tags in the destination repository, e.g. "latest", in the same
+// operation
+func WithPushTags(tags ...string) PushOption {
+	return func(o *pushOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithPushSigner signs the pushed model with signer and attaches the signature to it in the registry
+func WithPushSigner(signer signing.Signer) PushOption {
+	return func(o *pushOptions) {
+		if signer != nil {
+			o.signer = signer
+		}
+	}
+}
+
+// WithPushEncryption encrypts the GGUF and multimodal projector layers of the pushed model for the given recipients.
+// The local model is left in plaintext.
+func WithPushEncryption(recipients ...*ecdh.PublicKey) PushOption {
+	return func(o *pushOptions) {
+		o.recipients = append(o.recipients, recipients...)
+	}
+}
+
+func newPushOptions(opts []PushOption) *pushOptions {
+	options := &pushOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// preparePush resolves the local model and the registry target for a push to tag, encrypting the model if requested
+func (c *Client) preparePush(tag string, options *pushOptions) (types.ModelArtifact, *registry.Target, error) {
+	source := tag
+	if options.source != "" {
+		source = options.source
+	}
+
+	// Parse the tag
+	target, err := c.registry.NewTarget(tag, options.tags...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("new tag: %w", err)
+	}
+
+	// Get the model from the store
+	mdl, err := c.store.Read(source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading model: %w", err)
+	}
+	if len(options.recipients) > 0 {
+		encrypted, err := mutate.EncryptLayers(mdl, options.recipients)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encrypting model: %w", err)
+		}
+		return encrypted, target, nil
+	}
+	return mdl, target, nil
+}
+
+// PushDryRun returns the config and layer blobs that PushModel would upload for the same arguments, leaving out the
+// blobs the registry already has. Nothing is written to the registry.
+func (c *Client) PushDryRun(ctx context.Context, tag string, opts ...PushOption) ([]v1.Descriptor, error) {
+	if err := c.checkOnline("push", tag); err != nil {
+		return nil, err
+	}
+	mdl, target, err := c.preparePush(tag, newPushOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	missing, err := target.MissingBlobs(ctx, mdl)
+	if err != nil {
+		return nil, fmt.Errorf("checking blobs in registry: %w", err)
+	}
+	return missing, nil
+}
@@ -0,0 +1,435 @@
+package distribution
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/internal/partial"
+	"github.com/docker/model-distribution/progress"
+	"github.com/docker/model-distribution/types"
+)
+
+// eventRecorder is a progress writer recording the typed events it receives
+type eventRecorder struct {
+	io.Writer
+	events []progress.Event
+}
+
+func (r *eventRecorder) Handle(e progress.Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+func TestPushOptions(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	repo := registryURL.Host + "/push/model"
+
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	client, err := NewClient(WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	if err := client.store.Write(mdl, []string{"local/model:v1"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	assertDigest := func(t *testing.T, reference string) {
+		t.Helper()
+		ref, err := name.ParseReference(reference)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		desc, err := remote.Head(ref)
+		if err != nil {
+			t.Fatalf("Failed to resolve %q: %v", reference, err)
+		}
+		if desc.Digest != digest {
+			t.Fatalf("Expected digest %s for %q, got %s", digest, reference, desc.Digest)
+		}
+	}
+
+	t.Run("dry run before push", func(t *testing.T) {
+		blobs, err := client.PushDryRun(t.Context(), repo+":v1", WithPushSource("local/model:v1"))
+		if err != nil {
+			t.Fatalf("Failed to run push dry run: %v", err)
+		}
+		manifest, err := mdl.Manifest()
+		if err != nil {
+			t.Fatalf("Failed to get manifest: %v", err)
+		}
+		if len(blobs) != len(manifest.Layers)+1 {
+			t.Fatalf("Expected config and %d layers to be uploaded, got %v", len(manifest.Layers), blobs)
+		}
+		if blobs[0].Digest != manifest.Config.Digest {
+			t.Errorf("Expected config blob %s, got %s", manifest.Config.Digest, blobs[0].Digest)
+		}
+		ref, err := name.ParseReference(repo + ":v1")
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if _, err := remote.Head(ref); err == nil {
+			t.Errorf("Expected dry run not to push the model")
+		}
+	})
+
+	t.Run("push by ID with extra tags", func(t *testing.T) {
+		rec := &eventRecorder{Writer: io.Discard}
+		err := client.PushModel(t.Context(), repo+":v1", rec, WithPushSource(digest.String()), WithPushTags("latest", "stable"))
+		if err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		for _, tag := range []string{"v1", "latest", "stable"} {
+			assertDigest(t, repo+":"+tag)
+		}
+		var started int
+		for _, e := range rec.events {
+			if _, ok := e.(progress.LayerStart); ok {
+				started++
+			}
+		}
+		if started == 0 {
+			t.Errorf("Expected layers to be uploaded")
+		}
+	})
+
+	t.Run("existing blobs are skipped", func(t *testing.T) {
+		rec := &eventRecorder{Writer: io.Discard}
+		if err := client.PushModel(t.Context(), repo+":v2", rec, WithPushSource("local/model:v1")); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		assertDigest(t, repo+":v2")
+		var skipped []progress.LayerSkip
+		for _, e := range rec.events {
+			switch e := e.(type) {
+			case progress.LayerStart:
+				t.Errorf("Expected no layer uploads, got %+v", e)
+			case progress.LayerSkip:
+				skipped = append(skipped, e)
+			}
+		}
+		if len(skipped) == 0 {
+			t.Fatalf("Expected skipped layers to be reported")
+		}
+		last := skipped[len(skipped)-1]
+		if last.Totals.Complete != last.Totals.Total {
+			t.Errorf("Expected skipped layers to complete the push, got %+v", last.Totals)
+		}
+		end, ok := rec.events[len(rec.events)-1].(progress.PhaseEnd)
+		if !ok || end.Err != nil || end.Phase != progress.PhasePush {
+			t.Errorf("Expected successful end of push, got %+v", rec.events[len(rec.events)-1])
+		}
+	})
+
+	t.Run("dry run after push", func(t *testing.T) {
+		blobs, err := client.PushDryRun(t.Context(), repo+":v3", WithPushSource("local/model:v1"))
+		if err != nil {
+			t.Fatalf("Failed to run push dry run: %v", err)
+		}
+		if len(blobs) != 0 {
+			t.Fatalf("Expected no blobs to upload, got %v", blobs)
+		}
+	})
+
+	t.Run("invalid extra tag", func(t *testing.T) {
+		if err := client.PushModel(t.Context(), repo+":v1", nil, WithPushSource("local/model:v1"), WithPushTags("not a tag")); err == nil {
+			t.Fatalf("Expected error for invalid tag")
+		}
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		err := client.PushModel(t.Context(), repo+":v1", nil, WithPushSource("local/model:missing"))
+		if err == nil {
+			t.Fatalf("Expected error for missing source")
+		}
+	})
+}
+
+// flakyUploads wraps a registry, serving upload status requests from the ranges the registry reported and failing
+// the first chunk upload that starts at a non-zero offset after passing part of it on to the registry. If failEmpty
+// is set, the first chunk upload fails without passing any of it on and the upload reports the range "0-0". If
+// parallel is set, chunks are held back until two of them are in flight.
+type flakyUploads struct {
+	http.Handler
+	mu          sync.Mutex
+	ranges      map[string]string
+	chunks      []string
+	fail        bool
+	failEmpty   bool
+	parallel    chan struct{}
+	inflight    int
+	maxInflight int
+}
+
+func (f *flakyUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.URL.Path, "/blobs/uploads/") || strings.HasSuffix(r.URL.Path, "/blobs/uploads/") {
+		f.Handler.ServeHTTP(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		f.mu.Lock()
+		rng, ok := f.ranges[r.URL.Path]
+		f.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Location", r.URL.Path)
+		w.Header().Set("Range", rng)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPatch:
+		f.mu.Lock()
+		f.inflight++
+		f.maxInflight = max(f.maxInflight, f.inflight)
+		if f.inflight == 2 && f.parallel != nil {
+			close(f.parallel)
+		}
+		contentRange := r.Header.Get("Content-Range")
+		f.chunks = append(f.chunks, contentRange)
+		fail := f.fail && !strings.HasPrefix(contentRange, "0-")
+		if fail {
+			f.fail = false
+		}
+		if f.failEmpty {
+			f.failEmpty = false
+			f.ranges[r.URL.Path] = "0-0"
+			f.inflight--
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.mu.Unlock()
+		defer func() {
+			f.mu.Lock()
+			f.inflight--
+			f.mu.Unlock()
+		}()
+
+		// Hold chunks back so that uploads overlap when they run in parallel
+		if f.parallel != nil {
+			select {
+			case <-f.parallel:
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if fail {
+			body = body[:len(body)/2]
+		}
+		forward := r.Clone(r.Context())
+		forward.Body = io.NopCloser(bytes.NewReader(body))
+		forward.ContentLength = int64(len(body))
+		rec := httptest.NewRecorder()
+		f.Handler.ServeHTTP(rec, forward)
+
+		f.mu.Lock()
+		f.ranges[r.URL.Path] = rec.Header().Get("Range")
+		f.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(rec.Body.Bytes())
+	default:
+		f.Handler.ServeHTTP(w, r)
+	}
+}
+
+func TestPushChunkedUploads(t *testing.T) {
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	mmprojLayer, err := partial.NewLayer(filepath.Join("..", "assets", "dummy.mmproj"), types.MediaTypeMultimodalProjector)
+	if err != nil {
+		t.Fatalf("Failed to create mmproj layer: %v", err)
+	}
+	templateLayer, err := partial.NewLayer(filepath.Join("..", "assets", "template.jinja"), types.MediaTypeChatTemplate)
+	if err != nil {
+		t.Fatalf("Failed to create template layer: %v", err)
+	}
+	bundle := mutate.AppendLayers(mdl, mmprojLayer, templateLayer)
+	digest, err := bundle.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+
+	newClient := func(t *testing.T, opts ...Option) *Client {
+		t.Helper()
+		client, err := NewClient(append([]Option{WithStoreRootPath(t.TempDir()), WithUploadChunkSize(256)}, opts...)...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.store.Write(bundle, []string{"local/model:v1"}, nil); err != nil {
+			t.Fatalf("Failed to write model to store: %v", err)
+		}
+		return client
+	}
+	newRegistry := func(t *testing.T, flaky *flakyUploads) string {
+		t.Helper()
+		flaky.Handler = registry.New()
+		flaky.ranges = make(map[string]string)
+		server := httptest.NewServer(flaky)
+		t.Cleanup(server.Close)
+		registryURL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to parse registry URL: %v", err)
+		}
+		return registryURL.Host + "/chunked/model:v1"
+	}
+
+	t.Run("resumes interrupted uploads", func(t *testing.T) {
+		flaky := &flakyUploads{fail: true}
+		tag := newRegistry(t, flaky)
+		client := newClient(t)
+		if err := client.PushModel(t.Context(), tag, nil, WithPushSource("local/model:v1")); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		ref, err := name.ParseReference(tag)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		img, err := remote.Image(ref)
+		if err != nil {
+			t.Fatalf("Failed to read pushed model: %v", err)
+		}
+		if got, err := img.Digest(); err != nil || got != digest {
+			t.Fatalf("Expected digest %s, got %s (%v)", digest, got, err)
+		}
+		// Remote layers verify their digest when read to the end
+		layers, err := img.Layers()
+		if err != nil {
+			t.Fatalf("Failed to get layers: %v", err)
+		}
+		for _, layer := range layers {
+			rc, err := layer.Compressed()
+			if err != nil {
+				t.Fatalf("Failed to open layer: %v", err)
+			}
+			if _, err := io.Copy(io.Discard, rc); err != nil {
+				t.Fatalf("Failed to read layer: %v", err)
+			}
+			rc.Close()
+		}
+
+		// The failed chunk was half received, so the retry starts in the middle of it rather than at zero
+		var resumed bool
+		for _, chunk := range flaky.chunks {
+			if chunk == "" {
+				// The config blob is uploaded in one piece along with the manifest
+				continue
+			}
+			var start, end int
+			if _, err := fmt.Sscanf(chunk, "%d-%d", &start, &end); err != nil {
+				t.Fatalf("Invalid Content-Range %q: %v", chunk, err)
+			}
+			if start%256 != 0 {
+				resumed = true
+			}
+		}
+		if !resumed {
+			t.Errorf("Expected an upload to resume from the offset the registry received, got chunks %v", flaky.chunks)
+		}
+		if flaky.maxInflight != 1 {
+			t.Errorf("Expected sequential uploads by default, got %d in parallel", flaky.maxInflight)
+		}
+	})
+
+	t.Run("restarts uploads the registry received nothing of", func(t *testing.T) {
+		flaky := &flakyUploads{failEmpty: true}
+		tag := newRegistry(t, flaky)
+		client := newClient(t)
+		if err := client.PushModel(t.Context(), tag, nil, WithPushSource("local/model:v1")); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		ref, err := name.ParseReference(tag)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		img, err := remote.Image(ref)
+		if err != nil {
+			t.Fatalf("Failed to read pushed model: %v", err)
+		}
+		layers, err := img.Layers()
+		if err != nil {
+			t.Fatalf("Failed to get layers: %v", err)
+		}
+		for _, layer := range layers {
+			rc, err := layer.Compressed()
+			if err != nil {
+				t.Fatalf("Failed to open layer: %v", err)
+			}
+			if _, err := io.Copy(io.Discard, rc); err != nil {
+				t.Fatalf("Failed to read layer: %v", err)
+			}
+			rc.Close()
+		}
+		// The first byte must be sent again rather than skipped
+		if len(flaky.chunks) < 2 || flaky.chunks[1] != flaky.chunks[0] || !strings.HasPrefix(flaky.chunks[0], "0-") {
+			t.Errorf("Expected the failed chunk to be sent again from offset 0, got chunks %v", flaky.chunks)
+		}
+	})
+
+	t.Run("parallel uploads", func(t *testing.T) {
+		flaky := &flakyUploads{parallel: make(chan struct{})}
+		tag := newRegistry(t, flaky)
+		client := newClient(t, WithUploadConcurrency(3))
+		if err := client.PushModel(t.Context(), tag, nil, WithPushSource("local/model:v1")); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		if flaky.maxInflight < 2 {
+			t.Errorf("Expected parallel uploads, got at most %d at a time", flaky.maxInflight)
+		}
+	})
+
+	t.Run("no retries", func(t *testing.T) {
+		tag := newRegistry(t, &flakyUploads{fail: true})
+		client := newClient(t, WithUploadRetries(0))
+		if err := client.PushModel(t.Context(), tag, nil, WithPushSource("local/model:v1")); err == nil {
+			t.Fatalf("Expected push to fail without retries")
+		}
+	})
+}
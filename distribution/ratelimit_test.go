@@ -0,0 +1,183 @@
+package distribution
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	mdregistry "github.com/docker/model-distribution/registry"
+)
+
+// rateLimiter wraps a registry, rejecting the first manifest requests of each repository with 429 Too Many Requests
+type rateLimiter struct {
+	http.Handler
+	mu         sync.Mutex
+	limited    map[string]int
+	retryAfter string
+	requests   int
+}
+
+func (l *rateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("RateLimit-Limit", "100;w=21600")
+	w.Header().Set("RateLimit-Remaining", "76;w=21600")
+	w.Header().Set("Docker-RateLimit-Source", "192.0.2.1")
+	if !strings.Contains(r.URL.Path, "/manifests/") {
+		l.Handler.ServeHTTP(w, r)
+		return
+	}
+	repo, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/")
+
+	l.mu.Lock()
+	l.requests++
+	limited := l.limited[repo] > 0
+	if limited {
+		l.limited[repo]--
+	}
+	l.mu.Unlock()
+	if !limited {
+		l.Handler.ServeHTTP(w, r)
+		return
+	}
+	if l.retryAfter != "" {
+		w.Header().Set("Retry-After", l.retryAfter)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write([]byte(`{"errors":[{"code":"TOOMANYREQUESTS","message":"rate limit exceeded"}]}`))
+}
+
+func TestRateLimit(t *testing.T) {
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	newRegistry := func(t *testing.T, limiter *rateLimiter) string {
+		t.Helper()
+		limiter.Handler = registry.New()
+		server := httptest.NewServer(limiter)
+		t.Cleanup(server.Close)
+		registryURL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to parse registry URL: %v", err)
+		}
+		tag := registryURL.Host + "/limited/model:v1"
+		ref, err := name.ParseReference(tag)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, mdl); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		limiter.mu.Lock()
+		limiter.requests = 0
+		limiter.mu.Unlock()
+		return tag
+	}
+	newClient := func(t *testing.T, opts ...Option) *Client {
+		t.Helper()
+		client, err := NewClient(append([]Option{WithStoreRootPath(t.TempDir())}, opts...)...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client
+	}
+
+	t.Run("retries after delay", func(t *testing.T) {
+		limiter := &rateLimiter{retryAfter: "1"}
+		tag := newRegistry(t, limiter)
+		limiter.limited = map[string]int{"limited/model": 2}
+		client := newClient(t)
+
+		start := time.Now()
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 2*time.Second {
+			t.Errorf("Expected pull to wait for the rate limit to reset, took %s", elapsed)
+		}
+	})
+
+	t.Run("retry budget exhausted", func(t *testing.T) {
+		limiter := &rateLimiter{retryAfter: "0"}
+		tag := newRegistry(t, limiter)
+		limiter.limited = map[string]int{"limited/model": 10}
+		client := newClient(t, WithRateLimitRetries(2))
+
+		err := client.PullModel(t.Context(), tag, nil)
+		if !errors.Is(err, mdregistry.ErrRateLimited) {
+			t.Fatalf("Expected ErrRateLimited, got %v", err)
+		}
+		if limiter.requests != 3 {
+			t.Errorf("Expected 3 manifest requests, got %d", limiter.requests)
+		}
+	})
+
+	t.Run("delay exceeds max wait", func(t *testing.T) {
+		limiter := &rateLimiter{retryAfter: "3600"}
+		tag := newRegistry(t, limiter)
+		limiter.limited = map[string]int{"limited/model": 1}
+		client := newClient(t, WithRateLimitMaxWait(time.Minute))
+
+		err := client.PullModel(t.Context(), tag, nil)
+		var regErr *mdregistry.Error
+		if !errors.As(err, &regErr) || !errors.Is(err, mdregistry.ErrRateLimited) {
+			t.Fatalf("Expected rate limit error, got %v", err)
+		}
+		if regErr.RetryAfter != time.Hour {
+			t.Errorf("Expected retry after 1h, got %s", regErr.RetryAfter)
+		}
+		if limiter.requests != 1 {
+			t.Errorf("Expected no retries, got %d manifest requests", limiter.requests)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		limiter := &rateLimiter{}
+		tag := newRegistry(t, limiter)
+		client := newClient(t)
+
+		status, err := client.RateLimitStatus(t.Context(), tag)
+		if err != nil {
+			t.Fatalf("Failed to get rate limit status: %v", err)
+		}
+		expected := mdregistry.RateLimit{
+			Enforced:  true,
+			Limit:     100,
+			Remaining: 76,
+			Window:    6 * time.Hour,
+			Source:    "192.0.2.1",
+		}
+		if status != expected {
+			t.Errorf("Expected status %+v, got %+v", expected, status)
+		}
+	})
+
+	t.Run("status while rate limited", func(t *testing.T) {
+		limiter := &rateLimiter{retryAfter: "60"}
+		tag := newRegistry(t, limiter)
+		limiter.limited = map[string]int{"limited/model": 1}
+		client := newClient(t)
+
+		status, err := client.RateLimitStatus(t.Context(), tag)
+		if err != nil {
+			t.Fatalf("Failed to get rate limit status: %v", err)
+		}
+		if !status.Enforced || status.Remaining != 76 {
+			t.Errorf("Expected rate limit status, got %+v", status)
+		}
+		if limiter.requests != 1 {
+			t.Errorf("Expected no retries, got %d manifest requests", limiter.requests)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package distribution
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// requestRecorder records the URLs of the requests to a registry that match its method and path filter
+type requestRecorder struct {
+	method string // matches any method if empty
+	path   string // matches paths containing it, or any path if empty
+
+	mu   sync.Mutex
+	urls []*url.URL
+}
+
+func (rr *requestRecorder) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (rr.method == "" || r.Method == rr.method) && strings.Contains(r.URL.Path, rr.path) {
+			rr.mu.Lock()
+			rr.urls = append(rr.urls, r.URL)
+			rr.mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// reset returns the recorded URLs and clears them
+func (rr *requestRecorder) reset() []*url.URL {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	urls := rr.urls
+	rr.urls = nil
+	return urls
+}
+
+// count returns the number of recorded URLs
+func (rr *requestRecorder) count() int {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return len(rr.urls)
+}
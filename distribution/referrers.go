@@ -0,0 +1,147 @@
+package distribution
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/internal/store"
+	"github.com/docker/model-distribution/registry"
+	"github.com/docker/model-distribution/types"
+)
+
+// WithPullReferrers also fetches the artifacts of the given types that refer to the pulled model, e.g. model cards
+// or evaluation results, into the store. GetReferrers returns them once the pull completes.
+func WithPullReferrers(artifactTypes ...string) PullOption {
+	return func(o *pullOptions) {
+		o.referrers = append(o.referrers, artifactTypes...)
+	}
+}
+
+// Referrers lists the artifacts of the given type that refer to the model in the registry, or all artifacts if
+// artifactType is empty
+func (c *Client) Referrers(ctx context.Context, reference string, artifactType string) ([]v1.Descriptor, error) {
+	c.log.Infoln("Listing referrers:", reference)
+	if err := c.checkOnline("list referrers of", reference); err != nil {
+		return nil, err
+	}
+	descs, err := c.registry.Referrers(ctx, reference, artifactType)
+	if err != nil {
+		return nil, fmt.Errorf("listing referrers: %w", err)
+	}
+	return descs, nil
+}
+
+// Attach pushes the files as an artifact of the given type that refers to the model in the registry, e.g. an SBOM
+// or a model card, and returns the digest of the artifact
+func (c *Client) Attach(ctx context.Context, reference string, artifactType string, files []string) (string, error) {
+	c.log.Infoln("Attaching artifact:", artifactType, "reference:", reference)
+	if err := c.checkOnline("attach to", reference); err != nil {
+		return "", err
+	}
+	digest, err := c.registry.Attach(ctx, reference, artifactType, files)
+	if err != nil {
+		c.log.Errorln("Failed to attach artifact:", err, "reference:", reference)
+		return "", fmt.Errorf("attaching artifact: %w", err)
+	}
+	c.log.Infoln("Successfully attached artifact:", digest.String())
+	return digest.String(), nil
+}
+
+// FetchReferrer downloads the files of the artifact with the given digest from the repository of the reference to
+// dir and returns their paths. The local store is not modified.
+func (c *Client) FetchReferrer(ctx context.Context, reference string, digest string, dir string) ([]string, error) {
+	c.log.Infoln("Fetching referrer:", digest, "reference:", reference)
+	if err := c.checkOnline("fetch referrer of", reference); err != nil {
+		return nil, err
+	}
+	hash, err := v1.NewHash(digest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest %q: %w", digest, err)
+	}
+	img, err := c.registry.Referrer(ctx, reference, hash)
+	if err != nil {
+		return nil, fmt.Errorf("reading referrer: %w", err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("reading referrer manifest: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	var paths []string
+	for _, desc := range manifest.Layers {
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("getting layer %s: %w", desc.Digest, err)
+		}
+		path := filepath.Join(dir, store.ReferrerFileName(desc))
+		if err := writeLayerFile(path, layer); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeLayerFile(path string, layer v1.Layer) error {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return fmt.Errorf("reading layer: %w", err)
+	}
+	defer rc.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, rc); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// GetReferrers returns the artifacts stored alongside the model by pulls with WithPullReferrers
+func (c *Client) GetReferrers(reference string) ([]types.Referrer, error) {
+	referrers, err := c.store.Referrers(reference)
+	if err != nil {
+		return nil, fmt.Errorf("reading referrers: %w", err)
+	}
+	return referrers, nil
+}
+
+// pullReferrers fetches the artifacts of the given types that refer to the model with the given digest into the store
+func (c *Client) pullReferrers(ctx context.Context, reference string, digest v1.Hash, artifactTypes []string) error {
+	if len(artifactTypes) == 0 {
+		return nil
+	}
+	// Look up referrers by digest, in case the tag was moved since it was resolved
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return registry.NewReferenceError(reference, err)
+	}
+	subject := ref.Context().Name() + "@" + digest.String()
+	for _, artifactType := range artifactTypes {
+		descs, err := c.registry.Referrers(ctx, subject, artifactType)
+		if err != nil {
+			return fmt.Errorf("listing referrers: %w", err)
+		}
+		for _, desc := range descs {
+			img, err := c.registry.Referrer(ctx, subject, desc.Digest)
+			if err != nil {
+				return fmt.Errorf("reading referrer: %w", err)
+			}
+			if err := c.store.WriteReferrer(ctx, digest, img); err != nil {
+				return fmt.Errorf("writing referrer to store: %w", err)
+			}
+			c.log.Infoln("Stored referrer:", desc.Digest.String(), "type:", desc.ArtifactType)
+		}
+	}
+	return nil
+}
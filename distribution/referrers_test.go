@@ -0,0 +1,171 @@
+package distribution
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	mdregistry "github.com/docker/model-distribution/registry"
+)
+
+const (
+	testModelCardType = "application/vnd.docker.ai.model.card.v1+markdown"
+	testSBOMType      = "application/spdx+json"
+)
+
+func TestReferrers(t *testing.T) {
+	files := t.TempDir()
+	cardPath := filepath.Join(files, "README.md")
+	evalPath := filepath.Join(files, "eval.json")
+	sbomPath := filepath.Join(files, "sbom.spdx.json")
+	contents := map[string]string{
+		"README.md":      "# Dummy model\n",
+		"eval.json":      `{"accuracy": 0.5}`,
+		"sbom.spdx.json": `{"spdxVersion": "SPDX-2.3"}`,
+	}
+	for _, path := range []string{cardPath, evalPath, sbomPath} {
+		if err := os.WriteFile(path, []byte(contents[filepath.Base(path)]), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	for _, referrers := range []bool{true, false} {
+		name := "referrers tag schema"
+		if referrers {
+			name = "referrers API"
+		}
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(registry.New(registry.WithReferrersSupport(referrers)))
+			defer server.Close()
+			registryURL, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to parse registry URL: %v", err)
+			}
+			tag := registryURL.Host + "/attached/model:v1"
+			pushModel(t, tag)
+
+			client, err := NewClient(WithStoreRootPath(t.TempDir()))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			cardDigest, err := client.Attach(t.Context(), tag, testModelCardType, []string{cardPath, evalPath})
+			if err != nil {
+				t.Fatalf("Failed to attach model card: %v", err)
+			}
+			if _, err := client.Attach(t.Context(), tag, testSBOMType, []string{sbomPath}); err != nil {
+				t.Fatalf("Failed to attach SBOM: %v", err)
+			}
+
+			t.Run("list", func(t *testing.T) {
+				all, err := client.Referrers(t.Context(), tag, "")
+				if err != nil {
+					t.Fatalf("Failed to list referrers: %v", err)
+				}
+				if len(all) != 2 {
+					t.Fatalf("Expected 2 referrers, got %d", len(all))
+				}
+				cards, err := client.Referrers(t.Context(), tag, testModelCardType)
+				if err != nil {
+					t.Fatalf("Failed to list referrers: %v", err)
+				}
+				if len(cards) != 1 || cards[0].Digest.String() != cardDigest || cards[0].ArtifactType != testModelCardType {
+					t.Fatalf("Expected model card %s, got %+v", cardDigest, cards)
+				}
+			})
+
+			t.Run("fetch", func(t *testing.T) {
+				dir := filepath.Join(t.TempDir(), "card")
+				paths, err := client.FetchReferrer(t.Context(), tag, cardDigest, dir)
+				if err != nil {
+					t.Fatalf("Failed to fetch referrer: %v", err)
+				}
+				if len(paths) != 2 {
+					t.Fatalf("Expected 2 files, got %v", paths)
+				}
+				assertFiles(t, paths, contents)
+			})
+
+			t.Run("pull with referrers", func(t *testing.T) {
+				if err := client.PullModel(t.Context(), tag, nil, WithPullReferrers(testModelCardType)); err != nil {
+					t.Fatalf("Failed to pull model: %v", err)
+				}
+				stored, err := client.GetReferrers(tag)
+				if err != nil {
+					t.Fatalf("Failed to get referrers: %v", err)
+				}
+				if len(stored) != 1 || stored[0].Digest != cardDigest || stored[0].ArtifactType != testModelCardType {
+					t.Fatalf("Expected model card %s in store, got %+v", cardDigest, stored)
+				}
+				assertFiles(t, stored[0].Files, contents)
+
+				// Deleting the model removes its referrers
+				if _, err := client.DeleteModel(tag, false); err != nil {
+					t.Fatalf("Failed to delete model: %v", err)
+				}
+				entries, err := os.ReadDir(filepath.Join(client.GetStorePath(), "referrers", "sha256"))
+				if err != nil {
+					t.Fatalf("Failed to read referrers directory: %v", err)
+				}
+				if len(entries) != 0 {
+					t.Fatalf("Expected referrers to be removed with the model, got %d", len(entries))
+				}
+			})
+
+			t.Run("attach to missing model", func(t *testing.T) {
+				_, err := client.Attach(t.Context(), registryURL.Host+"/attached/model:missing", testModelCardType, []string{cardPath})
+				if !errors.Is(err, mdregistry.ErrModelNotFound) {
+					t.Fatalf("Expected %v, got %v", mdregistry.ErrModelNotFound, err)
+				}
+			})
+
+			t.Run("attach duplicate file names", func(t *testing.T) {
+				other := filepath.Join(t.TempDir(), "README.md")
+				if err := os.WriteFile(other, []byte("other"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+				if _, err := client.Attach(t.Context(), tag, testModelCardType, []string{cardPath, other}); err == nil {
+					t.Fatalf("Expected error for duplicate file names")
+				}
+			})
+		})
+	}
+}
+
+// pushModel pushes the test model to the given tag
+func pushModel(t *testing.T, tag string) {
+	t.Helper()
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	ref, err := name.ParseReference(tag)
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+	if err := remote.Write(ref, mdl); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+}
+
+// assertFiles checks that the files at paths have the expected contents by base name
+func assertFiles(t *testing.T, paths []string, contents map[string]string) {
+	t.Helper()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		if expected := contents[filepath.Base(path)]; string(data) != expected {
+			t.Fatalf("Expected %q in %s, got %q", expected, path, data)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package distribution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	mdregistry "github.com/docker/model-distribution/registry"
+)
+
+func TestListRemoteTags(t *testing.T) {
+	// Set up test registry
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	repository := registryURL.Host + "/testmodel"
+
+	// Create temp directory for store
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create client
+	client, err := NewClient(WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	expectedTags := []string{"Q4_K_M", "Q8_0", "latest"}
+	for _, tag := range expectedTags {
+		ref, err := name.ParseReference(repository + ":" + tag)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, mdl); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	}
+	expectedDigest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get model digest: %v", err)
+	}
+	manifest, err := mdl.Manifest()
+	if err != nil {
+		t.Fatalf("Failed to get model manifest: %v", err)
+	}
+	expectedSize := manifest.Config.Size
+	for _, l := range manifest.Layers {
+		expectedSize += l.Size
+	}
+
+	t.Run("without details", func(t *testing.T) {
+		tags, err := client.ListRemoteTags(t.Context(), repository, false)
+		if err != nil {
+			t.Fatalf("Failed to list tags: %v", err)
+		}
+		var names []string
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+			if tag.Size != 0 {
+				t.Errorf("Expected no size for tag %q without details, got %d", tag.Name, tag.Size)
+			}
+		}
+		slices.Sort(names)
+		if !slices.Equal(names, expectedTags) {
+			t.Fatalf("Expected tags %v, got %v", expectedTags, names)
+		}
+	})
+
+	t.Run("with details", func(t *testing.T) {
+		tags, err := client.ListRemoteTags(t.Context(), repository, true)
+		if err != nil {
+			t.Fatalf("Failed to list tags: %v", err)
+		}
+		if len(tags) != len(expectedTags) {
+			t.Fatalf("Expected %d tags, got %d", len(expectedTags), len(tags))
+		}
+		for _, tag := range tags {
+			if tag.Digest != expectedDigest {
+				t.Errorf("Expected digest %s for tag %q, got %s", expectedDigest, tag.Name, tag.Digest)
+			}
+			if tag.Size != expectedSize {
+				t.Errorf("Expected size %d for tag %q, got %d", expectedSize, tag.Name, tag.Size)
+			}
+		}
+	})
+
+	t.Run("unknown repository", func(t *testing.T) {
+		_, err := client.ListRemoteTags(t.Context(), registryURL.Host+"/missing", false)
+		if !errors.Is(err, mdregistry.ErrModelNotFound) {
+			t.Fatalf("Expected ErrModelNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("invalid repository", func(t *testing.T) {
+		_, err := client.ListRemoteTags(t.Context(), "Invalid Repository", false)
+		if !errors.Is(err, ErrInvalidReference) {
+			t.Fatalf("Expected ErrInvalidReference, got: %v", err)
+		}
+	})
+}
+
+func TestListTagsPagination(t *testing.T) {
+	// Wrap the test registry with a handler that advertises the next page using a Link header
+	reg := registry.New()
+	var pages int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/tags/list") {
+			pages++
+			n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+			rec := httptest.NewRecorder()
+			reg.ServeHTTP(rec, r)
+			var page struct {
+				Tags []string `json:"tags"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err == nil && n > 0 && len(page.Tags) == n {
+				w.Header().Set("Link", fmt.Sprintf(`<%s?n=%d&last=%s>; rel="next"`, r.URL.Path, n, page.Tags[n-1]))
+			}
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	repository := registryURL.Host + "/testmodel"
+
+	expectedTags := []string{"a", "b", "c", "d", "e"}
+	for _, tag := range expectedTags {
+		if err := writeToRegistry(testGGUFFile, repository+":"+tag); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	}
+
+	client := mdregistry.NewClient(mdregistry.WithPageSize(2))
+	tags, err := client.ListTags(t.Context(), repository)
+	if err != nil {
+		t.Fatalf("Failed to list tags: %v", err)
+	}
+	if !slices.Equal(tags, expectedTags) {
+		t.Fatalf("Expected tags %v, got %v", expectedTags, tags)
+	}
+	if pages != 3 {
+		t.Fatalf("Expected 3 pages to be requested, got %d", pages)
+	}
+}
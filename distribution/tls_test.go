@@ -0,0 +1,164 @@
+package distribution
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker/model-distribution/internal/gguf"
+)
+
+// newClientCertificate returns a self-signed certificate for TLS client authentication
+func newClientCertificate(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "model-distribution-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, cert
+}
+
+func TestRegistryTLS(t *testing.T) {
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	push := func(t *testing.T, reference string, transport http.RoundTripper, opts ...name.Option) {
+		t.Helper()
+		ref, err := name.ParseReference(reference, opts...)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, mdl, remote.WithTransport(transport)); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+	}
+	host := func(t *testing.T, server *httptest.Server) string {
+		t.Helper()
+		serverURL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to parse server URL: %v", err)
+		}
+		return serverURL.Host
+	}
+	pull := func(t *testing.T, reference string, opts ...Option) error {
+		t.Helper()
+		client, err := NewClient(append([]Option{WithStoreRootPath(t.TempDir())}, opts...)...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client.PullModel(t.Context(), reference, nil)
+	}
+
+	t.Run("private CA", func(t *testing.T) {
+		server := httptest.NewTLSServer(registry.New())
+		defer server.Close()
+		reference := host(t, server) + "/tls/model:v1"
+		push(t, reference, server.Client().Transport)
+
+		if err := pull(t, reference); err == nil {
+			t.Fatalf("Expected pull from registry with untrusted certificate to fail")
+		}
+		pool := x509.NewCertPool()
+		pool.AddCert(server.Certificate())
+		if err := pull(t, reference, WithCACertPool(pool)); err != nil {
+			t.Fatalf("Failed to pull model with CA pool: %v", err)
+		}
+	})
+
+	t.Run("insecure registry over HTTPS", func(t *testing.T) {
+		server := httptest.NewTLSServer(registry.New())
+		defer server.Close()
+		reference := host(t, server) + "/tls/model:v1"
+		push(t, reference, server.Client().Transport)
+
+		if err := pull(t, reference, WithInsecureRegistries(host(t, server))); err != nil {
+			t.Fatalf("Failed to pull model from insecure registry: %v", err)
+		}
+	})
+
+	t.Run("insecure registry over plain HTTP", func(t *testing.T) {
+		server := httptest.NewServer(registry.New())
+		defer server.Close()
+		_, port, err := net.SplitHostPort(host(t, server))
+		if err != nil {
+			t.Fatalf("Failed to parse server address: %v", err)
+		}
+
+		// Use a host name that is not assumed to serve plain HTTP the way localhost addresses are
+		dialer := &net.Dialer{}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, server.Listener.Addr().String())
+		}
+		registryHost := "registry.test:" + port
+		reference := registryHost + "/plain/model:v1"
+		push(t, reference, transport, name.Insecure)
+
+		if err := pull(t, reference, WithTransport(transport)); err == nil {
+			t.Fatalf("Expected pull over HTTPS from plain HTTP registry to fail")
+		}
+		if err := pull(t, reference, WithTransport(transport), WithInsecureRegistries(registryHost)); err != nil {
+			t.Fatalf("Failed to pull model from plain HTTP registry: %v", err)
+		}
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		clientCert, clientCA := newClientCertificate(t)
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(clientCA)
+
+		server := httptest.NewUnstartedServer(registry.New())
+		server.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		}
+		server.StartTLS()
+		defer server.Close()
+		reference := host(t, server) + "/mtls/model:v1"
+
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+		push(t, reference, transport)
+
+		pool := x509.NewCertPool()
+		pool.AddCert(server.Certificate())
+		if err := pull(t, reference, WithCACertPool(pool)); err == nil {
+			t.Fatalf("Expected pull without client certificate to fail")
+		}
+		if err := pull(t, reference, WithCACertPool(pool), WithClientCertificate(clientCert)); err != nil {
+			t.Fatalf("Failed to pull model with client certificate: %v", err)
+		}
+	})
+}
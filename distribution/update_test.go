@@ -0,0 +1,134 @@
+package distribution
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+func TestCheckUpdates(t *testing.T) {
+	// Set up test registry
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	registryURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	// Create temp directory for store
+	tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create client
+	client, err := NewClient(WithStoreRootPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Pull two models
+	changedTag := registryURL.Host + "/update-test/changed:latest"
+	unchangedTag := registryURL.Host + "/update-test/unchanged:latest"
+	for _, tag := range []string{changedTag, unchangedTag} {
+		if err := writeToRegistry(testGGUFFile, tag); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+	}
+	localModel, err := client.GetModel(changedTag)
+	if err != nil {
+		t.Fatalf("Failed to get model: %v", err)
+	}
+	localID, err := localModel.ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+
+	// Push a different model to one of the tags
+	modelContent, err := os.ReadFile(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to read test model file: %v", err)
+	}
+	updatedModelFile := filepath.Join(tempDir, "updated-dummy.gguf")
+	if err := os.WriteFile(updatedModelFile, append(modelContent, []byte("UPDATED CONTENT")...), 0644); err != nil {
+		t.Fatalf("Failed to create updated model file: %v", err)
+	}
+	if err := writeToRegistry(updatedModelFile, changedTag); err != nil {
+		t.Fatalf("Failed to push updated model: %v", err)
+	}
+
+	t.Run("check reports changed tags only", func(t *testing.T) {
+		updates, err := client.CheckUpdates(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to check updates: %v", err)
+		}
+		if len(updates) != 1 {
+			t.Fatalf("Expected 1 update, got %d: %+v", len(updates), updates)
+		}
+		if updates[0].Tag != changedTag {
+			t.Errorf("Expected update for %q, got %q", changedTag, updates[0].Tag)
+		}
+		if updates[0].LocalDigest != localID {
+			t.Errorf("Expected local digest %q, got %q", localID, updates[0].LocalDigest)
+		}
+		if updates[0].RemoteDigest == localID {
+			t.Errorf("Expected remote digest to differ from local digest")
+		}
+
+		// Nothing should have been downloaded
+		mdl, err := client.GetModel(changedTag)
+		if err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+		if id, _ := mdl.ID(); id != localID {
+			t.Errorf("Expected local model to be unchanged, got %q", id)
+		}
+	})
+
+	t.Run("update all pulls changed tags", func(t *testing.T) {
+		pulled, err := client.UpdateAll(t.Context(), nil)
+		if err != nil {
+			t.Fatalf("Failed to update models: %v", err)
+		}
+		if len(pulled) != 1 || pulled[0].Tag != changedTag {
+			t.Fatalf("Expected %q to be updated, got %+v", changedTag, pulled)
+		}
+
+		mdl, err := client.GetModel(changedTag)
+		if err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+		if id, _ := mdl.ID(); id != pulled[0].RemoteDigest {
+			t.Errorf("Expected local model to have digest %q, got %q", pulled[0].RemoteDigest, id)
+		}
+
+		updates, err := client.CheckUpdates(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to check updates: %v", err)
+		}
+		if len(updates) != 0 {
+			t.Fatalf("Expected no updates after update all, got %+v", updates)
+		}
+	})
+
+	t.Run("unresolvable tags are reported", func(t *testing.T) {
+		if err := client.Tag(unchangedTag, registryURL.Host+"/update-test/missing:latest"); err != nil {
+			t.Fatalf("Failed to tag model: %v", err)
+		}
+		updates, err := client.CheckUpdates(t.Context())
+		if err == nil {
+			t.Fatalf("Expected error for unresolvable tag")
+		}
+		if len(updates) != 0 {
+			t.Fatalf("Expected no updates, got %+v", updates)
+		}
+	})
+}
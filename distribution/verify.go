@@ -0,0 +1,108 @@
+package distribution
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/signing"
+)
+
+// VerificationPolicy determines whether PullModel requires models to be signed by a trusted key
+type VerificationPolicy string
+
+const (
+	// VerifyOff does not look for signatures. This is the default policy.
+	VerifyOff VerificationPolicy = "off"
+	// VerifyOptional accepts all models, but logs a warning for models without a valid signature by a trusted key.
+	VerifyOptional VerificationPolicy = "optional"
+	// VerifyRequired only accepts models with a valid signature by a trusted key. Other signatures are ignored.
+	VerifyRequired VerificationPolicy = "required"
+)
+
+// ParseVerificationPolicy parses a verification policy from its string representation
+func ParseVerificationPolicy(s string) (VerificationPolicy, error) {
+	switch p := VerificationPolicy(s); p {
+	case VerifyOff, VerifyOptional, VerifyRequired:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid verification policy %q: must be one of %q, %q or %q", s, VerifyOff, VerifyOptional, VerifyRequired)
+	}
+}
+
+// WithVerificationPolicy sets the signature verification policy of pulls and the public keys of trusted signers.
+// Signatures are only verified on pulls that contact the registry: models that are already in the local store, e.g.
+// from a load or an earlier pull, are used as they are by PullIfMissing and PullNever pulls and in offline mode.
+func WithVerificationPolicy(policy VerificationPolicy, keys ...crypto.PublicKey) Option {
+	return func(o *options) {
+		if policy != "" {
+			o.verification = policy
+		}
+		o.verifyKeys = append(o.verifyKeys, keys...)
+	}
+}
+
+// WithPullVerification overrides the client's signature verification policy for a single pull. It has no effect if the
+// pull does not contact the registry.
+func WithPullVerification(policy VerificationPolicy) PullOption {
+	return func(o *pullOptions) {
+		if policy != "" {
+			o.verification = policy
+		}
+	}
+}
+
+// verifyModel checks the signatures attached to the manifest with the given digest against the trusted keys. It
+// returns true as soon as a signature by a trusted key verifies.
+func (c *Client) verifyModel(ctx context.Context, reference string, digest v1.Hash, policy VerificationPolicy) (bool, error) {
+	if policy == VerifyOff {
+		return false, nil
+	}
+	verificationErr := func(err error) error {
+		return &VerificationError{Reference: reference, Digest: digest.String(), Err: err}
+	}
+
+	sigs, err := c.registry.Signatures(ctx, reference, digest)
+	if err != nil {
+		if policy == VerifyOptional {
+			c.log.Warnln("Failed to get signatures, skipping verification:", err, "reference:", reference)
+			return false, nil
+		}
+		return false, verificationErr(err)
+	}
+	if len(sigs) == 0 {
+		if policy == VerifyOptional {
+			c.log.Warnln("Model is not signed:", reference)
+			return false, nil
+		}
+		return false, verificationErr(signing.ErrNoSignature)
+	}
+
+	// Anyone who can push to the repository can attach signatures, so signatures that do not verify are skipped
+	// rather than failing the pull
+	var invalid, untrusted error
+	for _, sig := range sigs {
+		err := sig.Verify(digest, c.verifyKeys)
+		if err == nil {
+			c.log.Infoln("Verified model signature by key:", sig.KeyID)
+			return true, nil
+		}
+		c.log.Warnln("Skipping signature:", err, "reference:", reference)
+		if errors.Is(err, signing.ErrUntrustedKey) {
+			untrusted = err
+		} else {
+			invalid = err
+		}
+	}
+	if policy == VerifyOptional {
+		c.log.Warnln("Model is not signed by a trusted key:", reference)
+		return false, nil
+	}
+	if invalid != nil {
+		return false, verificationErr(invalid)
+	}
+	return false, verificationErr(untrusted)
+}
@@ -0,0 +1,228 @@
+package distribution
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/signing"
+)
+
+func TestSignatureVerification(t *testing.T) {
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	var signers []signing.Signer
+	for _, key := range []crypto.Signer{edKey, ecKey} {
+		signer, err := signing.NewSigner(key)
+		if err != nil {
+			t.Fatalf("Failed to create signer: %v", err)
+		}
+		signers = append(signers, signer)
+	}
+
+	for _, referrers := range []bool{true, false} {
+		name := "referrers tag schema"
+		if referrers {
+			name = "referrers API"
+		}
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(registry.New(registry.WithReferrersSupport(referrers)))
+			defer server.Close()
+			registryURL, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to parse registry URL: %v", err)
+			}
+			signedRef := registryURL.Host + "/signed/model:v1"
+			unsignedRef := registryURL.Host + "/unsigned/model:v1"
+			bogusRef := registryURL.Host + "/bogus/model:v1"
+
+			// Push a model signed with both keys and an unsigned model
+			pusher, err := NewClient(WithStoreRootPath(t.TempDir()))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			mdl, err := gguf.NewModel(testGGUFFile)
+			if err != nil {
+				t.Fatalf("Failed to create model: %v", err)
+			}
+			if err := pusher.store.Write(mdl, []string{signedRef}, nil); err != nil {
+				t.Fatalf("Failed to write model to store: %v", err)
+			}
+			if err := pusher.store.Write(mutate.ContextSize(mdl, 4096), []string{unsignedRef}, nil); err != nil {
+				t.Fatalf("Failed to write model to store: %v", err)
+			}
+			if err := pusher.PushModel(t.Context(), signedRef, nil, WithPushSigner(signers[0])); err != nil {
+				t.Fatalf("Failed to push signed model: %v", err)
+			}
+			if err := pusher.PushModel(t.Context(), signedRef, nil, WithPushSigner(signers[1])); err != nil {
+				t.Fatalf("Failed to push signed model: %v", err)
+			}
+			if err := pusher.PushModel(t.Context(), unsignedRef, nil); err != nil {
+				t.Fatalf("Failed to push unsigned model: %v", err)
+			}
+
+			// Push a model with a bogus signature claiming the ed25519 key before a valid one
+			if err := pusher.store.Write(mdl, []string{bogusRef}, nil); err != nil {
+				t.Fatalf("Failed to write model to store: %v", err)
+			}
+			if err := pusher.PushModel(t.Context(), bogusRef, nil, WithPushSigner(&bogusSigner{signers[0]})); err != nil {
+				t.Fatalf("Failed to push model with bogus signature: %v", err)
+			}
+			if err := pusher.PushModel(t.Context(), bogusRef, nil, WithPushSigner(signers[1])); err != nil {
+				t.Fatalf("Failed to push signed model: %v", err)
+			}
+
+			for _, tc := range []struct {
+				name        string
+				reference   string
+				policy      VerificationPolicy
+				keys        []crypto.PublicKey
+				pullOpts    []PullOption
+				expectedErr error
+			}{
+				{
+					name:      "required with ed25519 key",
+					reference: signedRef,
+					policy:    VerifyRequired,
+					keys:      []crypto.PublicKey{edPub},
+				},
+				{
+					name:      "required with ECDSA key",
+					reference: signedRef,
+					policy:    VerifyRequired,
+					keys:      []crypto.PublicKey{otherKey.Public(), &ecKey.PublicKey},
+				},
+				{
+					name:        "required with untrusted signer",
+					reference:   signedRef,
+					policy:      VerifyRequired,
+					keys:        []crypto.PublicKey{otherKey.Public()},
+					expectedErr: signing.ErrUntrustedKey,
+				},
+				{
+					name:        "required for unsigned model",
+					reference:   unsignedRef,
+					policy:      VerifyRequired,
+					keys:        []crypto.PublicKey{edPub},
+					expectedErr: signing.ErrNoSignature,
+				},
+				{
+					name:      "optional for unsigned model",
+					reference: unsignedRef,
+					policy:    VerifyOptional,
+					keys:      []crypto.PublicKey{edPub},
+				},
+				{
+					name:      "optional with untrusted signer",
+					reference: signedRef,
+					policy:    VerifyOptional,
+					keys:      []crypto.PublicKey{otherKey.Public()},
+				},
+				{
+					name:      "required skips bogus signature",
+					reference: bogusRef,
+					policy:    VerifyRequired,
+					keys:      []crypto.PublicKey{edPub, &ecKey.PublicKey},
+				},
+				{
+					name:        "required with only bogus signature by trusted key",
+					reference:   bogusRef,
+					policy:      VerifyRequired,
+					keys:        []crypto.PublicKey{edPub},
+					expectedErr: signing.ErrInvalidSignature,
+				},
+				{
+					name:      "optional with bogus signature by trusted key",
+					reference: bogusRef,
+					policy:    VerifyOptional,
+					keys:      []crypto.PublicKey{edPub},
+				},
+				{
+					name:      "off for unsigned model",
+					reference: unsignedRef,
+					policy:    VerifyOff,
+				},
+				{
+					name:      "pull option overrides client policy",
+					reference: unsignedRef,
+					policy:    VerifyRequired,
+					keys:      []crypto.PublicKey{edPub},
+					pullOpts:  []PullOption{WithPullVerification(VerifyOff)},
+				},
+			} {
+				t.Run(tc.name, func(t *testing.T) {
+					client, err := NewClient(WithStoreRootPath(t.TempDir()), WithVerificationPolicy(tc.policy, tc.keys...))
+					if err != nil {
+						t.Fatalf("Failed to create client: %v", err)
+					}
+					err = client.PullModel(t.Context(), tc.reference, nil, tc.pullOpts...)
+					models, listErr := client.ListModels()
+					if listErr != nil {
+						t.Fatalf("Failed to list models: %v", listErr)
+					}
+					if tc.expectedErr == nil {
+						if err != nil {
+							t.Fatalf("Failed to pull model: %v", err)
+						}
+						if len(models) != 1 {
+							t.Fatalf("Expected 1 model in store, got %d", len(models))
+						}
+						return
+					}
+					if !errors.Is(err, ErrVerificationFailed) || !errors.Is(err, tc.expectedErr) {
+						t.Fatalf("Expected %v and %v, got %v", ErrVerificationFailed, tc.expectedErr, err)
+					}
+					var verr *VerificationError
+					if !errors.As(err, &verr) || verr.Reference != tc.reference {
+						t.Fatalf("Expected *VerificationError for %q, got %T", tc.reference, err)
+					}
+					if len(models) != 0 {
+						t.Fatalf("Expected nothing to be written to the store, got %d models", len(models))
+					}
+				})
+			}
+		})
+	}
+
+	t.Run("required policy without keys", func(t *testing.T) {
+		tempDir, err := os.MkdirTemp("", "model-distribution-test-*")
+		if err != nil {
+			t.Fatalf("Failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+		if _, err := NewClient(WithStoreRootPath(tempDir), WithVerificationPolicy(VerifyRequired)); err == nil {
+			t.Fatalf("Expected error for required policy without keys")
+		}
+	})
+}
+
+// bogusSigner claims the public key of a signer but produces signatures that do not verify
+type bogusSigner struct {
+	signing.Signer
+}
+
+func (s *bogusSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	return []byte("bogus"), nil
+}
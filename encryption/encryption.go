@@ -0,0 +1,260 @@
+// Package encryption encrypts model layers with a random content key that is wrapped for one or more recipient
+// public keys.
+//
+// Layer contents are encrypted with AES-256-GCM in segments of SegmentSize bytes, so that arbitrarily large files can
+// be streamed. The encrypted stream starts with a version byte and a random nonce prefix; the nonce of each segment is
+// the prefix followed by the segment counter, with the top bit set for the final segment so that truncation is
+// detected. The content key is wrapped for each recipient with ECDH (X25519 or NIST P-curves) between an ephemeral key
+// and the recipient's key, HKDF-SHA256 and AES-256-GCM. The wrapped keys are stored in the AnnotationRecipients
+// annotation of the layer.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/docker/model-distribution/internal/keys"
+)
+
+const (
+	// MediaTypeSuffix is appended to the media type of encrypted layers
+	MediaTypeSuffix = "+encrypted"
+	// AnnotationRecipients is the layer annotation holding the JSON encoded []Recipient of an encrypted layer
+	AnnotationRecipients = "com.docker.ai.encryption.recipients"
+	// SegmentSize is the size of the plaintext segments that are encrypted separately
+	SegmentSize = 64 * 1024
+
+	keySize    = 32
+	prefixSize = 8
+	// version is the first byte of encrypted streams. Its fixed value also ensures that encrypted layers are never
+	// mistaken for gzip or zstd compressed data.
+	version  = 0x01
+	lastFlag = uint32(1) << 31
+	wrapInfo = "model-distribution layer key"
+)
+
+var (
+	ErrNoRecipient      = errors.New("none of the decryption keys is a recipient of the layer")
+	ErrDecryptionFailed = errors.New("decryption failed")
+	ErrUnsupportedKey   = errors.New("unsupported key type: must be X25519 or ECDSA")
+)
+
+// Recipient holds the content key of a layer wrapped for one public key
+type Recipient struct {
+	// KeyID identifies the recipient's public key, see KeyID
+	KeyID string `json:"keyId"`
+	// EphemeralKey is the public key of the ephemeral key pair used to wrap the content key
+	EphemeralKey []byte `json:"ephemeralKey"`
+	// WrappedKey is the encrypted content key
+	WrappedKey []byte `json:"wrappedKey"`
+}
+
+// EncryptedMediaType returns the media type of the encrypted form of layers of the given media type
+func EncryptedMediaType(mt types.MediaType) types.MediaType {
+	return mt + MediaTypeSuffix
+}
+
+// IsEncrypted returns true if mt is the media type of an encrypted layer
+func IsEncrypted(mt types.MediaType) bool {
+	return strings.HasSuffix(string(mt), MediaTypeSuffix)
+}
+
+// DecryptedMediaType returns the media type of the plaintext of encrypted layers of the given media type
+func DecryptedMediaType(mt types.MediaType) types.MediaType {
+	return types.MediaType(strings.TrimSuffix(string(mt), MediaTypeSuffix))
+}
+
+// KeyID returns the identifier of a public key, the SHA-256 digest of its PKIX encoding
+func KeyID(pub *ecdh.PublicKey) (string, error) {
+	return keys.ID(pub)
+}
+
+// wrapKey wraps the content key for the recipient's public key
+func wrapKey(key []byte, pub *ecdh.PublicKey) (Recipient, error) {
+	keyID, err := KeyID(pub)
+	if err != nil {
+		return Recipient{}, err
+	}
+	ephemeral, err := pub.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return Recipient{}, fmt.Errorf("generating ephemeral key: %w", err)
+	}
+	aead, err := wrappingCipher(ephemeral, pub, ephemeral.PublicKey())
+	if err != nil {
+		return Recipient{}, err
+	}
+	return Recipient{
+		KeyID:        keyID,
+		EphemeralKey: ephemeral.PublicKey().Bytes(),
+		WrappedKey:   aead.Seal(nil, make([]byte, aead.NonceSize()), key, nil),
+	}, nil
+}
+
+// unwrapKey returns the content key wrapped in r for priv
+func unwrapKey(r Recipient, priv *ecdh.PrivateKey) ([]byte, error) {
+	ephemeral, err := priv.Curve().NewPublicKey(r.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ephemeral key: %v", ErrDecryptionFailed, err)
+	}
+	aead, err := wrappingCipher(priv, ephemeral, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	key, err := aead.Open(nil, make([]byte, aead.NonceSize()), r.WrappedKey, nil)
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("%w: cannot unwrap content key", ErrDecryptionFailed)
+	}
+	return key, nil
+}
+
+// wrappingCipher derives the key wrapping cipher from the ECDH secret of priv and peer. The ephemeral public key and
+// the recipient's public key are mixed into the derived key.
+func wrappingCipher(priv *ecdh.PrivateKey, peer, ephemeral *ecdh.PublicKey) (cipher.AEAD, error) {
+	secret, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key agreement: %v", ErrDecryptionFailed, err)
+	}
+	recipient := peer
+	if peer == ephemeral {
+		recipient = priv.PublicKey()
+	}
+	salt := append(append([]byte{}, ephemeral.Bytes()...), recipient.Bytes()...)
+	wrappingKey, err := hkdf.Key(sha256.New, secret, salt, wrapInfo, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving wrapping key: %w", err)
+	}
+	return newAEAD(wrappingKey)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ContentKey returns the content key of a layer with the given annotations, unwrapped with the first of keys that is
+// a recipient of the layer. It returns ErrNoRecipient if none of them is.
+func ContentKey(annotations map[string]string, keys []*ecdh.PrivateKey) ([]byte, error) {
+	recipients, err := parseRecipients(annotations)
+	if err != nil {
+		return nil, err
+	}
+	for _, priv := range keys {
+		keyID, err := KeyID(priv.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range recipients {
+			if r.KeyID == keyID {
+				return unwrapKey(r, priv)
+			}
+		}
+	}
+	return nil, ErrNoRecipient
+}
+
+// HasRecipient returns true if one of keys is a recipient of a layer with the given annotations
+func HasRecipient(annotations map[string]string, keys []*ecdh.PrivateKey) bool {
+	recipients, err := parseRecipients(annotations)
+	if err != nil {
+		return false
+	}
+	for _, priv := range keys {
+		keyID, err := KeyID(priv.PublicKey())
+		if err != nil {
+			continue
+		}
+		for _, r := range recipients {
+			if r.KeyID == keyID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseRecipients(annotations map[string]string) ([]Recipient, error) {
+	raw, ok := annotations[AnnotationRecipients]
+	if !ok {
+		return nil, fmt.Errorf("%w: layer has no %s annotation", ErrDecryptionFailed, AnnotationRecipients)
+	}
+	var recipients []Recipient
+	if err := json.Unmarshal([]byte(raw), &recipients); err != nil {
+		return nil, fmt.Errorf("%w: invalid %s annotation: %v", ErrDecryptionFailed, AnnotationRecipients, err)
+	}
+	return recipients, nil
+}
+
+// Decrypt writes the plaintext of the encrypted stream src to dst, using the first of keys that is a recipient of
+// the layer with the given annotations
+func Decrypt(dst io.Writer, src io.Reader, annotations map[string]string, keys []*ecdh.PrivateKey) error {
+	key, err := ContentKey(annotations, keys)
+	if err != nil {
+		return err
+	}
+	r, err := NewDecryptReader(src, key)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// LoadPublicKey returns the PEM encoded X25519 or ECDSA public key ("PUBLIC KEY") in the file at path as an ECDH key
+func LoadPublicKey(path string) (*ecdh.PublicKey, error) {
+	key, err := keys.LoadPublicKey(path)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *ecdh.PublicKey:
+		return k, nil
+	case *ecdsa.PublicKey:
+		return k.ECDH()
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+	}
+}
+
+// LoadPrivateKey returns the PEM encoded X25519 or ECDSA private key in the file at path as an ECDH key. Both PKCS #8
+// ("PRIVATE KEY") and SEC 1 ("EC PRIVATE KEY") encodings are supported.
+func LoadPrivateKey(path string) (*ecdh.PrivateKey, error) {
+	key, err := keys.LoadPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *ecdh.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k.ECDH()
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+	}
+}
+
+// segmentNonce returns the nonce of the segment with the given index
+func segmentNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, prefixSize+4)
+	copy(nonce, prefix)
+	if last {
+		index |= lastFlag
+	}
+	binary.BigEndian.PutUint32(nonce[prefixSize:], index)
+	return nonce
+}
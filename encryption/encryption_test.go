@@ -0,0 +1,169 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+
+	"github.com/docker/model-distribution/encryption"
+	"github.com/docker/model-distribution/types"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	return path
+}
+
+func readAll(t *testing.T, layer v1.Layer) []byte {
+	t.Helper()
+	rc, err := layer.Compressed()
+	if err != nil {
+		t.Fatalf("Failed to open layer: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("Failed to read layer: %v", err)
+	}
+	return data
+}
+
+func TestEncryptLayer(t *testing.T) {
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate X25519 key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate X25519 key: %v", err)
+	}
+
+	// Keys are loaded from PEM files like the CLI does
+	xDER, err := x509.MarshalPKCS8PrivateKey(xKey)
+	if err != nil {
+		t.Fatalf("Failed to encode X25519 key: %v", err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to encode ECDSA key: %v", err)
+	}
+	ecPubDER, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to encode ECDSA public key: %v", err)
+	}
+	xPriv, err := encryption.LoadPrivateKey(writePEM(t, "PRIVATE KEY", xDER))
+	if err != nil {
+		t.Fatalf("Failed to load X25519 key: %v", err)
+	}
+	ecPriv, err := encryption.LoadPrivateKey(writePEM(t, "EC PRIVATE KEY", ecDER))
+	if err != nil {
+		t.Fatalf("Failed to load ECDSA key: %v", err)
+	}
+	ecPub, err := encryption.LoadPublicKey(writePEM(t, "PUBLIC KEY", ecPubDER))
+	if err != nil {
+		t.Fatalf("Failed to load ECDSA public key: %v", err)
+	}
+
+	for _, size := range []int{0, 1, encryption.SegmentSize, 3*encryption.SegmentSize + 17} {
+		plaintext := make([]byte, size)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatalf("Failed to generate plaintext: %v", err)
+		}
+		layer := static.NewLayer(plaintext, types.MediaTypeGGUF)
+		encrypted, err := encryption.EncryptLayer(layer, []*ecdh.PublicKey{xPriv.PublicKey(), ecPub})
+		if err != nil {
+			t.Fatalf("Failed to encrypt layer: %v", err)
+		}
+		mt, err := encrypted.MediaType()
+		if err != nil {
+			t.Fatalf("Failed to get media type: %v", err)
+		}
+		if mt != types.MediaTypeGGUFEncrypted || !encryption.IsEncrypted(mt) || encryption.DecryptedMediaType(mt) != types.MediaTypeGGUF {
+			t.Fatalf("Unexpected media type %q", mt)
+		}
+		desc, err := encrypted.(interface {
+			Descriptor() (*v1.Descriptor, error)
+		}).Descriptor()
+		if err != nil {
+			t.Fatalf("Failed to get descriptor: %v", err)
+		}
+
+		// The ciphertext is stable and matches the descriptor
+		ciphertext := readAll(t, encrypted)
+		if !bytes.Equal(ciphertext, readAll(t, encrypted)) {
+			t.Fatalf("Expected the same ciphertext on every read")
+		}
+		digest, n, err := v1.SHA256(bytes.NewReader(ciphertext))
+		if err != nil {
+			t.Fatalf("Failed to hash ciphertext: %v", err)
+		}
+		if digest != desc.Digest || n != desc.Size {
+			t.Fatalf("Expected %s (%d bytes), got %s (%d bytes)", desc.Digest, desc.Size, digest, n)
+		}
+		if size > 0 && bytes.Contains(ciphertext, plaintext) {
+			t.Fatalf("Ciphertext contains the plaintext")
+		}
+
+		for _, key := range []*ecdh.PrivateKey{xPriv, ecPriv} {
+			var out bytes.Buffer
+			if err := encryption.Decrypt(&out, bytes.NewReader(ciphertext), desc.Annotations, []*ecdh.PrivateKey{other, key}); err != nil {
+				t.Fatalf("Failed to decrypt %d bytes: %v", size, err)
+			}
+			if !bytes.Equal(out.Bytes(), plaintext) {
+				t.Fatalf("Decrypted contents differ from the plaintext")
+			}
+		}
+
+		if err := encryption.Decrypt(io.Discard, bytes.NewReader(ciphertext), desc.Annotations, []*ecdh.PrivateKey{other}); !errors.Is(err, encryption.ErrNoRecipient) {
+			t.Fatalf("Expected %v, got %v", encryption.ErrNoRecipient, err)
+		}
+		if encryption.HasRecipient(desc.Annotations, []*ecdh.PrivateKey{other}) || !encryption.HasRecipient(desc.Annotations, []*ecdh.PrivateKey{ecPriv}) {
+			t.Fatalf("Unexpected recipients %s", desc.Annotations[encryption.AnnotationRecipients])
+		}
+
+		// Modified and truncated ciphertexts are rejected
+		tampered := bytes.Clone(ciphertext)
+		tampered[len(tampered)-1] ^= 1
+		invalid := map[string][]byte{
+			"modified":  tampered,
+			"truncated": ciphertext[:len(ciphertext)-1],
+			"version":   append([]byte{2}, ciphertext[1:]...),
+		}
+		if size > encryption.SegmentSize {
+			// Dropping whole segments must be detected as well
+			invalid["segment"] = ciphertext[:1+8+encryption.SegmentSize+16]
+		}
+		for name, data := range invalid {
+			err := encryption.Decrypt(io.Discard, bytes.NewReader(data), desc.Annotations, []*ecdh.PrivateKey{xPriv})
+			if !errors.Is(err, encryption.ErrDecryptionFailed) {
+				t.Fatalf("Expected %v for %s ciphertext of %d bytes, got %v", encryption.ErrDecryptionFailed, name, size, err)
+			}
+		}
+	}
+
+	t.Run("no recipients", func(t *testing.T) {
+		if _, err := encryption.EncryptLayer(static.NewLayer([]byte("x"), types.MediaTypeGGUF), nil); err == nil {
+			t.Fatalf("Expected error without recipients")
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package encryption
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+var _ v1.Layer = &encryptedLayer{}
+
+// encryptedLayer is the encrypted form of a layer. The content key and nonce prefix are fixed when the layer is
+// created, so every read of the layer yields the same ciphertext.
+type encryptedLayer struct {
+	layer  v1.Layer
+	key    []byte
+	prefix []byte
+	desc   v1.Descriptor
+}
+
+// EncryptLayer returns the encrypted form of layer, with a content key wrapped for each of the recipients. The
+// returned layer's media type has the MediaTypeSuffix and its descriptor carries the AnnotationRecipients annotation.
+func EncryptLayer(layer v1.Layer, recipients []*ecdh.PublicKey) (v1.Layer, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	mt, err := layer.MediaType()
+	if err != nil {
+		return nil, fmt.Errorf("get layer media type: %w", err)
+	}
+	if IsEncrypted(mt) {
+		return nil, fmt.Errorf("layer of type %q is already encrypted", mt)
+	}
+
+	key := make([]byte, keySize)
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating content key: %w", err)
+	}
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	wrapped := make([]Recipient, 0, len(recipients))
+	for _, pub := range recipients {
+		r, err := wrapKey(key, pub)
+		if err != nil {
+			return nil, fmt.Errorf("wrapping content key: %w", err)
+		}
+		wrapped = append(wrapped, r)
+	}
+	annotation, err := json.Marshal(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("encoding recipients: %w", err)
+	}
+
+	l := &encryptedLayer{
+		layer:  layer,
+		key:    key,
+		prefix: prefix,
+	}
+	rc, err := l.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	digest, size, err := v1.SHA256(rc)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting layer: %w", err)
+	}
+	l.desc = v1.Descriptor{
+		MediaType:   EncryptedMediaType(mt),
+		Size:        size,
+		Digest:      digest,
+		Annotations: map[string]string{AnnotationRecipients: string(annotation)},
+	}
+	return l, nil
+}
+
+// Descriptor returns the descriptor of the encrypted layer, including the recipients annotation
+func (l *encryptedLayer) Descriptor() (*v1.Descriptor, error) {
+	return &l.desc, nil
+}
+
+func (l *encryptedLayer) Digest() (v1.Hash, error) {
+	return l.desc.Digest, nil
+}
+
+// DiffID is the digest of the encrypted contents, the plaintext digest must not be disclosed
+func (l *encryptedLayer) DiffID() (v1.Hash, error) {
+	return l.desc.Digest, nil
+}
+
+func (l *encryptedLayer) Compressed() (io.ReadCloser, error) {
+	rc, err := l.layer.Uncompressed()
+	if err != nil {
+		return nil, fmt.Errorf("open layer: %w", err)
+	}
+	r, err := newEncryptReader(rc, l.key, l.prefix)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, rc}, nil
+}
+
+func (l *encryptedLayer) Uncompressed() (io.ReadCloser, error) {
+	return l.Compressed()
+}
+
+func (l *encryptedLayer) Size() (int64, error) {
+	return l.desc.Size, nil
+}
+
+func (l *encryptedLayer) MediaType() (types.MediaType, error) {
+	return l.desc.MediaType, nil
+}
@@ -0,0 +1,153 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptReader encrypts the plaintext read from src
+type encryptReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	index  uint32
+	plain  []byte
+	out    []byte
+	buf    []byte
+	done   bool
+}
+
+// newEncryptReader returns a reader of the encrypted stream of the plaintext read from src
+func newEncryptReader(src io.Reader, key, prefix []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptReader{
+		src:    bufio.NewReaderSize(src, SegmentSize),
+		aead:   aead,
+		prefix: prefix,
+		plain:  make([]byte, SegmentSize),
+		out:    make([]byte, 0, SegmentSize+aead.Overhead()),
+		buf:    append([]byte{version}, prefix...),
+	}, nil
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.nextSegment(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *encryptReader) nextSegment() error {
+	n, err := io.ReadFull(r.src, r.plain)
+	last := false
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, err := r.src.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if r.index == ^uint32(0)>>1 && !last {
+		return errors.New("layer is too large to encrypt")
+	}
+	r.buf = r.aead.Seal(r.out[:0], segmentNonce(r.prefix, r.index, last), r.plain[:n], nil)
+	r.index++
+	r.done = last
+	return nil
+}
+
+// decryptReader decrypts the encrypted stream read from src
+type decryptReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	index  uint32
+	sealed []byte
+	out    []byte
+	buf    []byte
+	done   bool
+}
+
+// NewDecryptReader returns a reader of the plaintext of the encrypted stream src. Reads fail with ErrDecryptionFailed
+// if the stream was modified or truncated.
+func NewDecryptReader(src io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	r := &decryptReader{
+		src:    bufio.NewReaderSize(src, SegmentSize+aead.Overhead()),
+		aead:   aead,
+		prefix: make([]byte, prefixSize),
+		sealed: make([]byte, SegmentSize+aead.Overhead()),
+		out:    make([]byte, 0, SegmentSize),
+	}
+	v, err := r.src.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrDecryptionFailed, err)
+	}
+	if v != version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrDecryptionFailed, v)
+	}
+	if _, err := io.ReadFull(r.src, r.prefix); err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrDecryptionFailed, err)
+	}
+	return r, nil
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.nextSegment(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *decryptReader) nextSegment() error {
+	n, err := io.ReadFull(r.src, r.sealed)
+	last := false
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, err := r.src.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	plain, err := r.aead.Open(r.out[:0], segmentNonce(r.prefix, r.index, last), r.sealed[:n], nil)
+	if err != nil {
+		return fmt.Errorf("%w: segment %d was modified or truncated", ErrDecryptionFailed, r.index)
+	}
+	r.buf = plain
+	r.index++
+	r.done = last
+	return nil
+}
@@ -1,23 +1,30 @@
 package bundle
 
 import (
+	"crypto/ecdh"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcr "github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/docker/model-distribution/encryption"
+	"github.com/docker/model-distribution/internal/partial"
 	"github.com/docker/model-distribution/types"
 )
 
-// Unpack creates and return a Bundle by unpacking files and config from model into dir.
-func Unpack(dir string, model types.Model) (*Bundle, error) {
+// Unpack creates and return a Bundle by unpacking files and config from model into dir. Encrypted GGUF and multimodal
+// projector layers are decrypted into the bundle with the first of keys that is a recipient of the layer.
+func Unpack(dir string, model types.Model, keys ...*ecdh.PrivateKey) (*Bundle, error) {
 	bundle := &Bundle{
 		dir: dir,
 	}
-	if err := unpackGGUFs(bundle, model); err != nil {
+	if err := unpackGGUFs(bundle, model, keys); err != nil {
 		return nil, fmt.Errorf("add GGUF file(s) to runtime bundle: %w", err)
 	}
-	if err := unpackMultiModalProjector(bundle, model); err != nil {
+	if err := unpackMultiModalProjector(bundle, model, keys); err != nil {
 		return nil, fmt.Errorf("add multi-model projector file to runtime bundle: %w", err)
 	}
 	if err := unpackTemplate(bundle, model); err != nil {
@@ -46,23 +53,36 @@ func unpackRuntimeConfig(bundle *Bundle, mdl types.Model) error {
 	return nil
 }
 
-func unpackGGUFs(bundle *Bundle, mdl types.Model) error {
+func unpackGGUFs(bundle *Bundle, mdl types.Model, keys []*ecdh.PrivateKey) error {
 	ggufPaths, err := mdl.GGUFPaths()
 	if err != nil {
 		return fmt.Errorf("get GGUF files for model: %w", err)
 	}
+	sources := make([]func(string) error, len(ggufPaths))
+	for i, path := range ggufPaths {
+		sources[i] = func(bundlePath string) error { return unpackFile(bundlePath, path) }
+	}
+	if len(sources) == 0 {
+		encrypted, err := encryptedLayers(mdl, types.MediaTypeGGUF)
+		if err != nil {
+			return fmt.Errorf("get encrypted GGUF files for model: %w", err)
+		}
+		for _, layer := range encrypted {
+			sources = append(sources, func(bundlePath string) error { return decryptFile(bundlePath, layer, keys) })
+		}
+	}
 
-	if len(ggufPaths) == 1 {
-		if err := unpackFile(filepath.Join(bundle.dir, "model.gguf"), ggufPaths[0]); err != nil {
+	if len(sources) == 1 {
+		if err := sources[0](filepath.Join(bundle.dir, "model.gguf")); err != nil {
 			return err
 		}
 		bundle.ggufFile = "model.gguf"
-		return err
+		return nil
 	}
 
-	for i := range ggufPaths {
-		name := fmt.Sprintf("model-%05d-of-%05d.gguf", i+1, len(ggufPaths))
-		if err := unpackFile(filepath.Join(bundle.dir, name), ggufPaths[i]); err != nil {
+	for i := range sources {
+		name := fmt.Sprintf("model-%05d-of-%05d.gguf", i+1, len(sources))
+		if err := sources[i](filepath.Join(bundle.dir, name)); err != nil {
 			return err
 		}
 		bundle.ggufFile = name
@@ -71,13 +91,23 @@ func unpackGGUFs(bundle *Bundle, mdl types.Model) error {
 	return nil
 }
 
-func unpackMultiModalProjector(bundle *Bundle, mdl types.Model) error {
-	path, err := mdl.MMPROJPath()
-	if err != nil {
-		return nil // no such file
-	}
-	if err = unpackFile(filepath.Join(bundle.dir, "model.mmproj"), path); err != nil {
-		return err
+func unpackMultiModalProjector(bundle *Bundle, mdl types.Model, keys []*ecdh.PrivateKey) error {
+	bundlePath := filepath.Join(bundle.dir, "model.mmproj")
+	if path, err := mdl.MMPROJPath(); err == nil {
+		if err = unpackFile(bundlePath, path); err != nil {
+			return err
+		}
+	} else {
+		encrypted, err := encryptedLayers(mdl, types.MediaTypeMultimodalProjector)
+		if err != nil {
+			return fmt.Errorf("get encrypted multimodal projector file for model: %w", err)
+		}
+		if len(encrypted) != 1 {
+			return nil // no such file
+		}
+		if err := decryptFile(bundlePath, encrypted[0], keys); err != nil {
+			return err
+		}
 	}
 	bundle.mmprojPath = "model.mmproj"
 	return nil
@@ -98,3 +128,47 @@ func unpackTemplate(bundle *Bundle, mdl types.Model) error {
 func unpackFile(bundlePath string, srcPath string) error {
 	return os.Link(srcPath, bundlePath)
 }
+
+// encryptedLayers returns the locally available layers of mdl holding encrypted files of the given media type
+func encryptedLayers(mdl types.Model, mt ggcr.MediaType) ([]*partial.Layer, error) {
+	withLayers, ok := mdl.(interface{ Layers() ([]v1.Layer, error) })
+	if !ok {
+		return nil, nil
+	}
+	layers, err := withLayers.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("get layers: %w", err)
+	}
+	var encrypted []*partial.Layer
+	for _, l := range layers {
+		lmt, err := l.MediaType()
+		if err != nil || lmt != encryption.EncryptedMediaType(mt) {
+			continue
+		}
+		layer, ok := l.(*partial.Layer)
+		if !ok {
+			return nil, fmt.Errorf("%s Layer is not available locally", lmt)
+		}
+		encrypted = append(encrypted, layer)
+	}
+	return encrypted, nil
+}
+
+// decryptFile writes the plaintext of the encrypted layer to bundlePath
+func decryptFile(bundlePath string, layer *partial.Layer, keys []*ecdh.PrivateKey) error {
+	src, err := os.Open(layer.Path)
+	if err != nil {
+		return fmt.Errorf("open encrypted layer: %w", err)
+	}
+	defer src.Close()
+	dst, err := os.Create(bundlePath)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", bundlePath, err)
+	}
+	if err := encryption.Decrypt(dst, src, layer.Annotations, keys); err != nil {
+		dst.Close()
+		os.Remove(bundlePath)
+		return fmt.Errorf("decrypt layer %s: %w", layer.Descriptor.Digest, err)
+	}
+	return dst.Close()
+}
@@ -0,0 +1,72 @@
+// Package keys reads PEM encoded keys and identifies public keys. Callers convert the parsed keys to the types they
+// support.
+package keys
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// ID returns the identifier of a public key, the SHA-256 digest of its PKIX encoding
+func ID(pub any) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("encoding public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// LoadPrivateKey returns the PEM encoded private key in the file at path. Both PKCS #8 ("PRIVATE KEY") and SEC 1
+// ("EC PRIVATE KEY") encodings are supported.
+func LoadPrivateKey(path string) (any, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q in %q", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key %q: %w", path, err)
+	}
+	return key, nil
+}
+
+// LoadPublicKey returns the PEM encoded public key ("PUBLIC KEY") in the file at path
+func LoadPublicKey(path string) (any, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unexpected PEM block %q in %q", block.Type, path)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key %q: %w", path, err)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %q", path)
+	}
+	return block, nil
+}
@@ -15,12 +15,14 @@ import (
 type model struct {
 	base            types.ModelArtifact
 	appended        []v1.Layer
+	replaced        map[v1.Hash]v1.Layer
 	configMediaType ggcr.MediaType
 	contextSize     *uint64
+	provenance      *types.Provenance
 }
 
 func (m *model) Descriptor() (types.Descriptor, error) {
-	return partial.Descriptor(m.base)
+	return partial.Descriptor(m)
 }
 
 func (m *model) ID() (string, error) {
@@ -98,6 +100,18 @@ func (m *model) Layers() ([]v1.Layer, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(m.replaced) > 0 {
+		ls = append([]v1.Layer{}, ls...)
+		for i, l := range ls {
+			d, err := l.Digest()
+			if err != nil {
+				return nil, fmt.Errorf("get layer digest: %w", err)
+			}
+			if r, ok := m.replaced[d]; ok {
+				ls[i] = r
+			}
+		}
+	}
 	return append(ls, m.appended...), nil
 }
 
@@ -117,6 +131,9 @@ func (m *model) RawConfigFile() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := m.replaceDiffIDs(cf); err != nil {
+		return nil, err
+	}
 	for _, l := range m.appended {
 		diffID, err := l.DiffID()
 		if err != nil {
@@ -127,9 +144,47 @@ func (m *model) RawConfigFile() ([]byte, error) {
 	if m.contextSize != nil {
 		cf.Config.ContextSize = m.contextSize
 	}
+	if m.provenance != nil {
+		cf.Descriptor.Provenance = m.provenance
+	}
 	raw, err := json.Marshal(cf)
 	if err != nil {
 		return nil, err
 	}
 	return raw, err
 }
+
+// replaceDiffIDs replaces the diff IDs of replaced layers in cf with those of their replacements
+func (m *model) replaceDiffIDs(cf *types.ConfigFile) error {
+	if len(m.replaced) == 0 {
+		return nil
+	}
+	ls, err := m.base.Layers()
+	if err != nil {
+		return err
+	}
+	for _, l := range ls {
+		d, err := l.Digest()
+		if err != nil {
+			return fmt.Errorf("get layer digest: %w", err)
+		}
+		r, ok := m.replaced[d]
+		if !ok {
+			continue
+		}
+		oldID, err := l.DiffID()
+		if err != nil {
+			return err
+		}
+		newID, err := r.DiffID()
+		if err != nil {
+			return err
+		}
+		for i, id := range cf.RootFS.DiffIDs {
+			if id == oldID {
+				cf.RootFS.DiffIDs[i] = newID
+			}
+		}
+	}
+	return nil
+}
@@ -1,9 +1,13 @@
 package mutate
 
 import (
+	"crypto/ecdh"
+	"fmt"
+
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	ggcr "github.com/google/go-containerregistry/pkg/v1/types"
 
+	"github.com/docker/model-distribution/encryption"
 	"github.com/docker/model-distribution/types"
 )
 
@@ -27,3 +31,51 @@ func ContextSize(mdl types.ModelArtifact, cs uint64) types.ModelArtifact {
 		contextSize: &cs,
 	}
 }
+
+// Provenance records the build provenance in the descriptor of mdl
+func Provenance(mdl types.ModelArtifact, p types.Provenance) types.ModelArtifact {
+	return &model{
+		base:       mdl,
+		provenance: &p,
+	}
+}
+
+// ReplaceLayers replaces the layers of mdl with the given digests, keeping their position in the manifest
+func ReplaceLayers(mdl types.ModelArtifact, replacements map[v1.Hash]v1.Layer) types.ModelArtifact {
+	return &model{
+		base:     mdl,
+		replaced: replacements,
+	}
+}
+
+// EncryptLayers encrypts the GGUF and multimodal projector layers of mdl for the given recipients. Other layers, such
+// as licenses and chat templates, are left in plaintext.
+func EncryptLayers(mdl types.ModelArtifact, recipients []*ecdh.PublicKey) (types.ModelArtifact, error) {
+	layers, err := mdl.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("get layers: %w", err)
+	}
+	replacements := make(map[v1.Hash]v1.Layer)
+	for _, l := range layers {
+		mt, err := l.MediaType()
+		if err != nil {
+			return nil, fmt.Errorf("get layer media type: %w", err)
+		}
+		if mt != types.MediaTypeGGUF && mt != types.MediaTypeMultimodalProjector {
+			continue
+		}
+		d, err := l.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("get layer digest: %w", err)
+		}
+		encrypted, err := encryption.EncryptLayer(l, recipients)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt layer %s: %w", d, err)
+		}
+		replacements[d] = encrypted
+	}
+	if len(replacements) == 0 {
+		return nil, fmt.Errorf("model has no layers to encrypt")
+	}
+	return ReplaceLayers(mdl, replacements), nil
+}
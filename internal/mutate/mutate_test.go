@@ -1,6 +1,8 @@
 package mutate_test
 
 import (
+	"crypto/ecdh"
+	"crypto/rand"
 	"encoding/json"
 	"path/filepath"
 	"testing"
@@ -8,6 +10,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/static"
 	ggcr "github.com/google/go-containerregistry/pkg/v1/types"
 
+	"github.com/docker/model-distribution/encryption"
 	"github.com/docker/model-distribution/internal/gguf"
 	"github.com/docker/model-distribution/internal/mutate"
 	"github.com/docker/model-distribution/types"
@@ -112,3 +115,56 @@ func TestContextSize(t *testing.T) {
 		t.Fatalf("Expected context size of 2096 got %d", *cfg2.ContextSize)
 	}
 }
+
+func TestEncryptLayers(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	base, err := gguf.NewModel(filepath.Join("..", "..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	mdl1 := mutate.AppendLayers(base, static.NewLayer([]byte("license"), types.MediaTypeLicense))
+
+	mdl2, err := mutate.EncryptLayers(mdl1, []*ecdh.PublicKey{key.PublicKey()})
+	if err != nil {
+		t.Fatalf("Failed to encrypt model: %v", err)
+	}
+	manifest1, err := mdl1.Manifest()
+	if err != nil {
+		t.Fatalf("Failed to get manifest: %v", err)
+	}
+	manifest2, err := mdl2.Manifest()
+	if err != nil {
+		t.Fatalf("Failed to get manifest: %v", err)
+	}
+	if len(manifest2.Layers) != 2 {
+		t.Fatalf("Expected 2 layers, got %d", len(manifest2.Layers))
+	}
+	if gguf := manifest2.Layers[0]; gguf.MediaType != types.MediaTypeGGUFEncrypted ||
+		gguf.Digest == manifest1.Layers[0].Digest || gguf.Annotations[encryption.AnnotationRecipients] == "" {
+		t.Fatalf("Expected an encrypted GGUF layer, got %+v", gguf)
+	}
+	if manifest2.Layers[1].Digest != manifest1.Layers[1].Digest {
+		t.Fatalf("Expected the license layer to be left in plaintext")
+	}
+
+	// The config refers to the encrypted layers
+	cf, err := mdl2.RawConfigFile()
+	if err != nil {
+		t.Fatalf("Failed to get raw config file: %v", err)
+	}
+	var cfg types.ConfigFile
+	if err := json.Unmarshal(cf, &cfg); err != nil {
+		t.Fatalf("Failed to unmarshal config file: %v", err)
+	}
+	if len(cfg.RootFS.DiffIDs) != 2 || cfg.RootFS.DiffIDs[0] != manifest2.Layers[0].Digest ||
+		cfg.RootFS.DiffIDs[1] != manifest1.Layers[1].Digest {
+		t.Fatalf("Unexpected diff IDs %v", cfg.RootFS.DiffIDs)
+	}
+
+	if _, err := mutate.EncryptLayers(mdl2, []*ecdh.PublicKey{key.PublicKey()}); err == nil {
+		t.Fatalf("Expected error encrypting a model without plaintext GGUF layers")
+	}
+}
@@ -0,0 +1,74 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// UpdateInterval defines how often progress updates should be sent
+const UpdateInterval = 100 * time.Millisecond
+
+// MinBytesForUpdate defines the minimum number of bytes that need to be transferred
+// before sending a progress update
+const MinBytesForUpdate = 1024 * 1024 // 1MB
+
+type Layer struct {
+	ID      string // Layer ID
+	Size    uint64 // Layer size
+	Current uint64 // Current bytes transferred
+}
+
+// Message represents a structured message for progress reporting
+type Message struct {
+	Type    string `json:"type"`    // "progress", "success", or "error"
+	Message string `json:"message"` // Deprecated: the message should be defined by clients based on Message.Total and Message.Layer
+	Total   uint64 `json:"total"`
+	Pulled  uint64 `json:"pulled"` // Deprecated: use Layer.Current
+	Layer   Layer  `json:"layer"`  // Current layer information
+}
+
+// WriteProgress writes a progress update message
+func WriteProgress(w io.Writer, msg string, imageSize, layerSize, current uint64, layerID string) error {
+	return write(w, Message{
+		Type:    "progress",
+		Message: msg,
+		Total:   imageSize,
+		Pulled:  current,
+		Layer: Layer{
+			ID:      layerID,
+			Size:    layerSize,
+			Current: current,
+		},
+	})
+}
+
+// WriteSuccess writes a success message
+func WriteSuccess(w io.Writer, message string) error {
+	return write(w, Message{
+		Type:    "success",
+		Message: message,
+	})
+}
+
+// WriteError writes an error message
+func WriteError(w io.Writer, message string) error {
+	return write(w, Message{
+		Type:    "error",
+		Message: message,
+	})
+}
+
+// write writes a JSON-formatted progress message to the writer
+func write(w io.Writer, msg Message) error {
+	if w == nil {
+		return nil
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
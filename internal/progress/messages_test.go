@@ -0,0 +1,138 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	v1types "github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/docker/model-distribution/types"
+)
+
+// mockLayer implements v1.Layer for testing
+type mockLayer struct {
+	size      int64
+	diffID    string
+	mediaType v1types.MediaType
+}
+
+func (m *mockLayer) Digest() (v1.Hash, error) {
+	return v1.Hash{}, nil
+}
+
+func (m *mockLayer) DiffID() (v1.Hash, error) {
+	return v1.NewHash(m.diffID)
+}
+
+func (m *mockLayer) Compressed() (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func (m *mockLayer) Uncompressed() (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func (m *mockLayer) Size() (int64, error) {
+	return m.size, nil
+}
+
+func (m *mockLayer) MediaType() (v1types.MediaType, error) {
+	return m.mediaType, nil
+}
+
+func newMockLayer(size int64) *mockLayer {
+	return &mockLayer{
+		size:      size,
+		diffID:    "sha256:c7790a0a70161f1bfd441cf157313e9efb8fcd1f0831193101def035ead23b32",
+		mediaType: types.MediaTypeGGUF,
+	}
+}
+
+func TestMessages(t *testing.T) {
+	t.Run("writeProgress", func(t *testing.T) {
+		var buf bytes.Buffer
+		update := v1.Update{
+			Complete: 1024 * 1024,
+		}
+		layer1 := newMockLayer(2016)
+		layer2 := newMockLayer(1)
+
+		err := WriteProgress(&buf, "Downloaded: 1.00 MB", uint64(layer1.size+layer2.size), uint64(layer1.size), uint64(update.Complete), layer1.diffID)
+		if err != nil {
+			t.Fatalf("Failed to write progress message: %v", err)
+		}
+
+		var msg Message
+		if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+
+		if msg.Type != "progress" {
+			t.Errorf("Expected type 'progress', got '%s'", msg.Type)
+		}
+		if msg.Message != "Downloaded: 1.00 MB" {
+			t.Errorf("Expected message 'Downloaded: 1.00 MB', got '%s'", msg.Message)
+		}
+		if msg.Total != uint64(2017) {
+			t.Errorf("Expected total 2017, got %d", msg.Total)
+		}
+		if msg.Pulled != uint64(1024*1024) {
+			t.Errorf("Expected pulled 1MB, got %d", msg.Pulled)
+		}
+		if msg.Layer == (Layer{}) {
+			t.Errorf("Expected layer to be set")
+		}
+		if msg.Layer.ID != "sha256:c7790a0a70161f1bfd441cf157313e9efb8fcd1f0831193101def035ead23b32" {
+			t.Errorf("Expected layer ID to be %s, got %s", "sha256:c7790a0a70161f1bfd441cf157313e9efb8fcd1f0831193101def035ead23b32", msg.Layer.ID)
+		}
+		if msg.Layer.Size != uint64(2016) {
+			t.Errorf("Expected layer size to be %d, got %d", 2016, msg.Layer.Size)
+		}
+		if msg.Layer.Current != uint64(1048576) {
+			t.Errorf("Expected layer current to be %d, got %d", 1048576, msg.Layer.Current)
+		}
+	})
+
+	t.Run("writeSuccess", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := WriteSuccess(&buf, "Model pulled successfully")
+		if err != nil {
+			t.Fatalf("Failed to write success message: %v", err)
+		}
+
+		var msg Message
+		if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+
+		if msg.Type != "success" {
+			t.Errorf("Expected type 'success', got '%s'", msg.Type)
+		}
+		if msg.Message != "Model pulled successfully" {
+			t.Errorf("Expected message 'Model pulled successfully', got '%s'", msg.Message)
+		}
+	})
+
+	t.Run("writeError", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := WriteError(&buf, "Error: something went wrong")
+		if err != nil {
+			t.Fatalf("Failed to write error message: %v", err)
+		}
+
+		var msg Message
+		if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+
+		if msg.Type != "error" {
+			t.Errorf("Expected type 'error', got '%s'", msg.Type)
+		}
+		if msg.Message != "Error: something went wrong" {
+			t.Errorf("Expected message 'Error: something went wrong', got '%s'", msg.Message)
+		}
+	})
+}
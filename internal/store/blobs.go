@@ -1,14 +1,17 @@
 package store
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
-	"github.com/docker/model-distribution/internal/progress"
-
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/internal/utils"
+	"github.com/docker/model-distribution/progress"
 )
 
 const (
@@ -27,34 +30,54 @@ func (s *LocalStore) blobPath(hash v1.Hash) string {
 
 type blob interface {
 	DiffID() (v1.Hash, error)
+	Size() (int64, error)
 	Uncompressed() (io.ReadCloser, error)
 }
 
-// writeLayer write the layer blob to the store
-func (s *LocalStore) writeLayer(layer blob, updates chan<- v1.Update) error {
+// writeLayer write the layer blob to the store, reporting its progress to the tracker. It returns true if the blob
+// was written and false if it was already present, in which case it is reported as skipped.
+func (s *LocalStore) writeLayer(ctx context.Context, layer blob, tracker *progress.Tracker) (bool, error) {
 	hash, err := layer.DiffID()
 	if err != nil {
-		return fmt.Errorf("get file hash: %w", err)
+		return false, fmt.Errorf("get file hash: %w", err)
 	}
-	if s.hasBlob(hash) {
-		// todo: write something to the progress channel (we probably need to redo progress reporting a little bit)
-		return nil
+	size, err := layer.Size()
+	if err != nil {
+		return false, fmt.Errorf("get blob size: %w", err)
+	}
+	info := progress.Layer{ID: hash.String(), Size: size}
+	if s.HasBlob(hash) {
+		tracker.Skip(info)
+		return false, nil
 	}
 
 	lr, err := layer.Uncompressed()
 	if err != nil {
-		return fmt.Errorf("get blob contents: %w", err)
+		return false, fmt.Errorf("get blob contents: %w", err)
 	}
 	defer lr.Close()
-	r := progress.NewReader(lr, updates)
+	lt := tracker.Start(info)
 
-	return s.WriteBlob(hash, r)
+	if err := s.WriteBlobContext(ctx, hash, lt.Reader(lr)); err != nil {
+		return false, err
+	}
+	lt.Done()
+	return true, nil
 }
 
 // WriteBlob writes the blob to the store, reporting progress to the given channel.
 // If the blob is already in the store, it is a no-op and the blob is not consumed from the reader.
 func (s *LocalStore) WriteBlob(diffID v1.Hash, r io.Reader) error {
-	if s.hasBlob(diffID) {
+	return s.WriteBlobContext(context.Background(), diffID, r)
+}
+
+// WriteBlobContext is like WriteBlob but stops reading from r once ctx is done.
+// The incomplete blob file is removed whenever the blob could not be written, including on cancellation.
+func (s *LocalStore) WriteBlobContext(ctx context.Context, diffID v1.Hash, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.HasBlob(diffID) {
 		return nil
 	}
 
@@ -66,7 +89,7 @@ func (s *LocalStore) WriteBlob(diffID v1.Hash, r io.Reader) error {
 	defer os.Remove(incompletePath(path))
 	defer f.Close()
 
-	if _, err := io.Copy(f, r); err != nil {
+	if _, err := io.Copy(f, &utils.ContextReader{Ctx: ctx, Reader: r}); err != nil {
 		return fmt.Errorf("copy blob %q to store: %w", diffID.String(), err)
 	}
 
@@ -77,12 +100,41 @@ func (s *LocalStore) WriteBlob(diffID v1.Hash, r io.Reader) error {
 	return nil
 }
 
+// CleanupBlobs removes the blobs with the given hashes unless they are referenced by a model in the index.
+// It is used to discard the blobs written by an operation that failed or was cancelled before writing its manifest.
+func (s *LocalStore) CleanupBlobs(hashes []v1.Hash) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	idx, err := s.readIndex()
+	if err != nil {
+		return fmt.Errorf("reading models index: %w", err)
+	}
+	referenced := make(map[string]struct{})
+	for _, m := range idx.Models {
+		for _, file := range m.Files {
+			referenced[file] = struct{}{}
+		}
+	}
+	var errs []error
+	for _, hash := range hashes {
+		if _, ok := referenced[hash.String()]; ok {
+			continue
+		}
+		if err := s.removeBlob(hash); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove blob %q: %w", hash.String(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // removeBlob removes the blob with the given hash from the store.
 func (s *LocalStore) removeBlob(hash v1.Hash) error {
 	return os.Remove(s.blobPath(hash))
 }
 
-func (s *LocalStore) hasBlob(hash v1.Hash) bool {
+// HasBlob returns true if the blob with the given hash is in the store
+func (s *LocalStore) HasBlob(hash v1.Hash) bool {
 	if _, err := os.Stat(s.blobPath(hash)); err == nil {
 		return true
 	}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -9,6 +10,11 @@ import (
 	"testing"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/internal/partial"
+	"github.com/docker/model-distribution/types"
 )
 
 func TestBlobs(t *testing.T) {
@@ -111,6 +117,139 @@ func TestBlobs(t *testing.T) {
 	})
 }
 
+func TestWriteCancelled(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "blob-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	store, err := New(Options{RootPath: filepath.Join(tmpDir, "store")})
+	if err != nil {
+		t.Fatalf("error creating store: %v", err)
+	}
+
+	t.Run("WriteBlobContext cancelled mid-stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		hash := v1.Hash{
+			Algorithm: "sha256",
+			Hex:       "0000000000000000000000000000000000000000000000000000000000000000",
+		}
+		r := &cancelingReader{r: bytes.NewReader(make([]byte, 4096)), cancel: cancel}
+		if err := store.WriteBlobContext(ctx, hash, r); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+		assertNoBlobs(t, store)
+	})
+
+	t.Run("WriteContext cancelled mid-layer", func(t *testing.T) {
+		mdl, err := gguf.NewModel(filepath.Join("..", "..", "assets", "dummy.gguf"))
+		if err != nil {
+			t.Fatalf("error creating model: %v", err)
+		}
+		license, err := partial.NewLayer(filepath.Join("..", "..", "assets", "license.txt"), types.MediaTypeLicense)
+		if err != nil {
+			t.Fatalf("error creating license layer: %v", err)
+		}
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		mdlWithLicense := mutate.AppendLayers(mdl, &cancelingLayer{Layer: license, cancel: cancel})
+
+		if err := store.WriteContext(ctx, mdlWithLicense, []string{"cancelled:latest"}, nil); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+		assertNoBlobs(t, store)
+		if models, err := store.List(); err != nil || len(models) != 0 {
+			t.Fatalf("expected no models in store, got %v (err: %v)", models, err)
+		}
+	})
+
+	t.Run("WriteContext keeps blobs of other models", func(t *testing.T) {
+		mdl, err := gguf.NewModel(filepath.Join("..", "..", "assets", "dummy.gguf"))
+		if err != nil {
+			t.Fatalf("error creating model: %v", err)
+		}
+		if err := store.Write(mdl, []string{"existing:latest"}, nil); err != nil {
+			t.Fatalf("error writing model: %v", err)
+		}
+		license, err := partial.NewLayer(filepath.Join("..", "..", "assets", "license.txt"), types.MediaTypeLicense)
+		if err != nil {
+			t.Fatalf("error creating license layer: %v", err)
+		}
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		mdlWithLicense := mutate.AppendLayers(mdl, &cancelingLayer{Layer: license, cancel: cancel})
+		if err := store.WriteContext(ctx, mdlWithLicense, []string{"cancelled:latest"}, nil); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+
+		layers, err := mdl.Layers()
+		if err != nil {
+			t.Fatalf("error getting layers: %v", err)
+		}
+		diffID, err := layers[0].DiffID()
+		if err != nil {
+			t.Fatalf("error getting diffID: %v", err)
+		}
+		if !store.HasBlob(diffID) {
+			t.Fatalf("expected blob of existing model to be kept")
+		}
+		if _, err := store.Read("existing:latest"); err != nil {
+			t.Fatalf("expected existing model to be readable: %v", err)
+		}
+	})
+}
+
+// assertNoBlobs fails the test if any blob or incomplete blob file is present in the store
+func assertNoBlobs(t *testing.T, store *LocalStore) {
+	t.Helper()
+	err := filepath.WalkDir(store.blobsDir(), func(path string, d os.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			t.Errorf("unexpected file left in store: %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error walking blobs directory: %v", err)
+	}
+}
+
+// cancelingReader cancels the context after the first read
+type cancelingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (c *cancelingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p[:min(len(p), 16)])
+	c.cancel()
+	return n, err
+}
+
+func (c *cancelingReader) Close() error {
+	return nil
+}
+
+// cancelingLayer is a layer whose contents cancel the context while they are being read
+type cancelingLayer struct {
+	v1.Layer
+	cancel context.CancelFunc
+}
+
+func (l *cancelingLayer) Uncompressed() (io.ReadCloser, error) {
+	rc, err := l.Layer.Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+	return &cancelingReader{r: rc, cancel: l.cancel}, nil
+}
+
 var _ io.Reader = &errorReader{}
 
 type errorReader struct {
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"crypto/ecdh"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,8 +21,9 @@ func (s *LocalStore) bundlePath(hash v1.Hash) string {
 	return filepath.Join(s.rootPath, bundlesDir, hash.Algorithm, hash.Hex)
 }
 
-// BundleForModel returns a runtime bundle for the given model
-func (s *LocalStore) BundleForModel(ref string) (types.ModelBundle, error) {
+// BundleForModel returns a runtime bundle for the given model. Encrypted layers are decrypted into the bundle with keys
+// when it is created.
+func (s *LocalStore) BundleForModel(ref string, keys ...*ecdh.PrivateKey) (types.ModelBundle, error) {
 	mdl, err := s.Read(ref)
 	if err != nil {
 		return nil, fmt.Errorf("find model content: %w", err)
@@ -33,22 +35,24 @@ func (s *LocalStore) BundleForModel(ref string) (types.ModelBundle, error) {
 	path := s.bundlePath(dgst)
 	if bdl, err := bundle.Parse(path); err != nil {
 		// create for first time or replace bad/corrupted bundle
-		return s.createBundle(path, mdl)
+		return s.createBundle(path, mdl, keys)
 	} else {
 		return bdl, nil
 	}
 }
 
 // createBundle unpacks the bundle to path, replacing existing bundle if one is found
-func (s *LocalStore) createBundle(path string, mdl *Model) (types.ModelBundle, error) {
+func (s *LocalStore) createBundle(path string, mdl *Model, keys []*ecdh.PrivateKey) (types.ModelBundle, error) {
 	if err := os.RemoveAll(path); err != nil {
 		return nil, fmt.Errorf("remove %s: %w", path, err)
 	}
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, fmt.Errorf("create bundle directory: %w", err)
 	}
-	bdl, err := bundle.Unpack(path, mdl)
+	bdl, err := bundle.Unpack(path, mdl, keys...)
 	if err != nil {
+		// do not leave decrypted files behind
+		os.RemoveAll(path)
 		return nil, fmt.Errorf("unpack bundle: %w", err)
 	}
 	return bdl, nil
@@ -26,7 +26,7 @@ func (s *LocalStore) WriteManifest(hash v1.Hash, raw []byte) error {
 		return fmt.Errorf("parse manifest: %w", err)
 	}
 	for _, layer := range manifest.Layers {
-		if !s.hasBlob(layer.Digest) {
+		if !s.HasBlob(layer.Digest) {
 			return errors.New("missing blob %q for manifest - refusing to write unless all blobs exist")
 		}
 	}
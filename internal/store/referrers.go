@@ -0,0 +1,168 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+
+	"github.com/docker/model-distribution/internal/utils"
+	"github.com/docker/model-distribution/types"
+)
+
+const (
+	referrersDir = "referrers"
+	// annotationTitle is the annotation holding the file name of a layer
+	annotationTitle = "org.opencontainers.image.title"
+	// referrerManifestFile is the name of the manifest file in the directory of a stored referrer
+	referrerManifestFile = "manifest.json"
+)
+
+// referrersPath returns the path to the directory holding the referrers of the model with the given digest
+func (s *LocalStore) referrersPath(subject v1.Hash) string {
+	return filepath.Join(s.rootPath, referrersDir, subject.Algorithm, subject.Hex)
+}
+
+// ReferrerFileName returns the file name of a referrer's layer: its title annotation if that is a plain file name,
+// its digest otherwise
+func ReferrerFileName(desc v1.Descriptor) string {
+	title := desc.Annotations[annotationTitle]
+	if title == "" || title != filepath.Base(title) || title == "." || title == ".." || title == referrerManifestFile {
+		return desc.Digest.Hex
+	}
+	return title
+}
+
+// WriteReferrer stores an artifact that refers to the model with the given digest, replacing any earlier copy. Its
+// files are stored next to its manifest rather than in the shared blobs directory, and are removed with the model.
+func (s *LocalStore) WriteReferrer(ctx context.Context, subject v1.Hash, referrer v1.Image) error {
+	digest, err := referrer.Digest()
+	if err != nil {
+		return fmt.Errorf("get referrer digest: %w", err)
+	}
+	rm, err := referrer.RawManifest()
+	if err != nil {
+		return fmt.Errorf("get referrer manifest: %w", err)
+	}
+	layers, err := referrer.Layers()
+	if err != nil {
+		return fmt.Errorf("get referrer layers: %w", err)
+	}
+
+	dir := filepath.Join(s.referrersPath(subject), digest.Hex)
+	tmp := incompletePath(dir)
+	if err := os.RemoveAll(tmp); err != nil {
+		return fmt.Errorf("remove %s: %w", tmp, err)
+	}
+	if err := os.MkdirAll(tmp, 0777); err != nil {
+		return fmt.Errorf("create referrer directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, layer := range layers {
+		desc, err := partial.Descriptor(layer)
+		if err != nil {
+			return fmt.Errorf("get layer descriptor: %w", err)
+		}
+		if err := writeReferrerFile(ctx, filepath.Join(tmp, ReferrerFileName(*desc)), layer); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmp, referrerManifestFile), rm, 0666); err != nil {
+		return fmt.Errorf("write referrer manifest: %w", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("remove %s: %w", dir, err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("rename referrer directory: %w", err)
+	}
+	return nil
+}
+
+func writeReferrerFile(ctx context.Context, path string, layer v1.Layer) error {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return fmt.Errorf("get referrer file contents: %w", err)
+	}
+	defer rc.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create referrer file: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, &utils.ContextReader{Ctx: ctx, Reader: rc}); err != nil {
+		return fmt.Errorf("write referrer file: %w", err)
+	}
+	return f.Close()
+}
+
+// Referrers returns the artifacts stored for the model with the given reference, sorted by digest
+func (s *LocalStore) Referrers(reference string) ([]types.Referrer, error) {
+	mdl, err := s.Read(reference)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := mdl.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("get model digest: %w", err)
+	}
+	root := s.referrersPath(subject)
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading referrers directory: %w", err)
+	}
+
+	var referrers []types.Referrer
+	for _, entry := range entries {
+		if !entry.IsDir() || filepath.Ext(entry.Name()) == ".incomplete" {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		rm, err := os.ReadFile(filepath.Join(dir, referrerManifestFile))
+		if err != nil {
+			return nil, fmt.Errorf("read referrer manifest: %w", err)
+		}
+		var manifest struct {
+			v1.Manifest
+			ArtifactType string `json:"artifactType,omitempty"`
+		}
+		if err := json.Unmarshal(rm, &manifest); err != nil {
+			return nil, fmt.Errorf("parse referrer manifest: %w", err)
+		}
+		artifactType := manifest.ArtifactType
+		if artifactType == "" {
+			artifactType = string(manifest.Config.MediaType)
+		}
+		digest, _, err := v1.SHA256(bytes.NewReader(rm))
+		if err != nil {
+			return nil, fmt.Errorf("hash referrer manifest: %w", err)
+		}
+		r := types.Referrer{
+			Digest:       digest.String(),
+			ArtifactType: artifactType,
+			Files:        []string{},
+		}
+		for _, layer := range manifest.Layers {
+			r.Files = append(r.Files, filepath.Join(dir, ReferrerFileName(layer)))
+		}
+		referrers = append(referrers, r)
+	}
+	sort.Slice(referrers, func(i, j int) bool { return referrers[i].Digest < referrers[j].Digest })
+	return referrers, nil
+}
+
+// removeReferrers removes the artifacts stored for the model with the given digest
+func (s *LocalStore) removeReferrers(subject v1.Hash) error {
+	return os.RemoveAll(s.referrersPath(subject))
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -8,7 +9,7 @@ import (
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 
-	"github.com/docker/model-distribution/internal/progress"
+	"github.com/docker/model-distribution/progress"
 )
 
 const (
@@ -94,6 +95,15 @@ func (s *LocalStore) List() ([]IndexEntry, error) {
 
 // Delete deletes a model by reference
 func (s *LocalStore) Delete(ref string) (string, []string, error) {
+	return s.DeleteContext(context.Background(), ref)
+}
+
+// DeleteContext is like Delete but returns without modifying the store if ctx is already done.
+// Once started, a delete runs to completion so that the index never references removed files.
+func (s *LocalStore) DeleteContext(ctx context.Context, ref string) (string, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
 	idx, err := s.readIndex()
 	if err != nil {
 		return "", nil, fmt.Errorf("reading models file: %w", err)
@@ -118,6 +128,11 @@ func (s *LocalStore) Delete(ref string) (string, []string, error) {
 		fmt.Printf("Warning: failed to remove bundle %q: %v\n", digest, err)
 	}
 
+	// Remove the artifacts stored alongside the model
+	if err := s.removeReferrers(digest); err != nil {
+		fmt.Printf("Warning: failed to remove referrers of %q: %v\n", digest, err)
+	}
+
 	// Before deleting blobs, check if they are referenced by other models
 	blobRefs := make(map[string]int)
 	for _, m := range idx.Models {
@@ -200,10 +215,38 @@ func (s *LocalStore) Version() string {
 
 // Write writes a model to the store
 func (s *LocalStore) Write(mdl v1.Image, tags []string, w io.Writer) error {
+	return s.WriteContext(context.Background(), mdl, tags, w)
+}
+
+// WriteContext is like Write but stops once ctx is done. If the model cannot be written, blobs written by this call
+// that are not referenced by another model are removed so that nothing is left behind in the store.
+func (s *LocalStore) WriteContext(ctx context.Context, mdl v1.Image, tags []string, w io.Writer) (err error) {
+	var written []v1.Hash
+	defer func() {
+		if err == nil {
+			return
+		}
+		if cleanupErr := s.CleanupBlobs(written); cleanupErr != nil {
+			fmt.Printf("Warning: failed to clean up blobs after failed write: %v\n", cleanupErr)
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// Write the config JSON file
+	configName, err := mdl.ConfigName()
+	if err != nil {
+		return fmt.Errorf("get config name: %w", err)
+	}
+	newConfig := !s.HasBlob(configName)
 	if err := s.writeConfigFile(mdl); err != nil {
 		return fmt.Errorf("writing config file: %w", err)
 	}
+	if newConfig {
+		written = append(written, configName)
+	}
 
 	layers, err := mdl.Layers()
 	if err != nil {
@@ -219,26 +262,24 @@ func (s *LocalStore) Write(mdl v1.Image, tags []string, w io.Writer) error {
 		imageSize += size
 	}
 
-	for _, layer := range layers {
-		var pr *progress.Reporter
-		var progressChan chan<- v1.Update
-		if w != nil {
-			pr = progress.NewProgressReporter(w, progress.PullMsg, imageSize, layer)
-			progressChan = pr.Updates()
+	tracker := progress.NewTracker(progress.NewWriter(w), imageSize)
+	defer func() {
+		if err := tracker.Err(); err != nil {
+			fmt.Printf("reporter finished with non-fatal error: %v\n", err)
 		}
-
-		err := s.writeLayer(layer, progressChan)
-
-		if progressChan != nil {
-			close(progressChan)
-			if err := pr.Wait(); err != nil {
-				fmt.Printf("reporter finished with non-fatal error: %v\n", err)
-			}
-		}
-
+	}()
+	for _, layer := range layers {
+		isNew, err := s.writeLayer(ctx, layer, tracker)
 		if err != nil {
 			return fmt.Errorf("writing blob: %w", err)
 		}
+		if isNew {
+			diffID, err := layer.DiffID()
+			if err != nil {
+				return fmt.Errorf("get layer diffID: %w", err)
+			}
+			written = append(written, diffID)
+		}
 	}
 
 	// Write the manifest
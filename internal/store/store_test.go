@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/docker/model-distribution/internal/mutate"
 	"github.com/docker/model-distribution/internal/partial"
 	"github.com/docker/model-distribution/internal/store"
+	"github.com/docker/model-distribution/progress"
 	"github.com/docker/model-distribution/types"
 )
 
@@ -600,3 +602,79 @@ func newTestModelWithMultimodalProjector(t *testing.T) types.ModelArtifact {
 	mdl = mutate.AppendLayers(mdl, licenseLayer, mmprojLayer)
 	return mdl
 }
+
+// TestWriteProgress tests the progress events reported when writing models
+func TestWriteProgress(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "store-progress-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, err := store.New(store.Options{
+		RootPath: tempDir,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	mdl, err := gguf.NewModel(filepath.Join("testdata", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	// write writes the model with the given tag and returns the progress events reported
+	write := func(tag string) []progress.Event {
+		var events []progress.Event
+		w := struct {
+			io.Writer
+			progress.Handler
+		}{io.Discard, progress.HandlerFunc(func(e progress.Event) error {
+			events = append(events, e)
+			return nil
+		})}
+		if err := s.Write(mdl, []string{tag}, w); err != nil {
+			t.Fatalf("Failed to write model: %v", err)
+		}
+		return events
+	}
+
+	t.Run("new blobs are transferred", func(t *testing.T) {
+		events := write("some-repo:first")
+		var start, done, skip int
+		for _, e := range events {
+			switch e.(type) {
+			case progress.LayerStart:
+				start++
+			case progress.LayerDone:
+				done++
+			case progress.LayerSkip:
+				skip++
+			}
+		}
+		if start != 1 || done != 1 || skip != 0 {
+			t.Fatalf("Expected one transferred layer, got %d started, %d done, %d skipped", start, done, skip)
+		}
+		last, ok := events[len(events)-1].(progress.LayerDone)
+		if !ok {
+			t.Fatalf("Expected last event to be LayerDone, got %T", events[len(events)-1])
+		}
+		if last.Totals.Complete != last.Totals.Total {
+			t.Fatalf("Expected write to be complete, got %+v", last.Totals)
+		}
+	})
+
+	t.Run("cached blobs are skipped", func(t *testing.T) {
+		events := write("some-repo:second")
+		if len(events) != 1 {
+			t.Fatalf("Expected a single event, got %d", len(events))
+		}
+		skip, ok := events[0].(progress.LayerSkip)
+		if !ok {
+			t.Fatalf("Expected LayerSkip event, got %T", events[0])
+		}
+		if skip.Layer.Size == 0 || skip.Totals.Complete != skip.Totals.Total {
+			t.Fatalf("Expected skipped layer to count as complete, got %+v", skip)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -103,3 +104,16 @@ func (pr *ProgressReader) Read(p []byte) (int, error) {
 	}
 	return n, err
 }
+
+// ContextReader wraps an io.Reader to fail with the context's error once the context is done
+type ContextReader struct {
+	Ctx    context.Context
+	Reader io.Reader
+}
+
+func (cr *ContextReader) Read(p []byte) (int, error) {
+	if err := cr.Ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.Reader.Read(p)
+}
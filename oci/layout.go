@@ -0,0 +1,107 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+
+	"github.com/docker/model-distribution/internal/partial"
+	"github.com/docker/model-distribution/types"
+)
+
+var (
+	ErrNotFound  = errors.New("manifest not found in image layout")
+	ErrAmbiguous = errors.New("image layout lists more than one manifest")
+)
+
+var _ types.ModelArtifact = &artifact{}
+
+// artifact is a model read from an image layout
+type artifact struct {
+	v1.Image
+}
+
+func (a *artifact) ID() (string, error) {
+	return partial.ID(a)
+}
+
+func (a *artifact) Config() (types.Config, error) {
+	return partial.Config(a)
+}
+
+func (a *artifact) Descriptor() (types.Descriptor, error) {
+	return partial.Descriptor(a)
+}
+
+// Image returns the model listed in the image layout at dir under refName, which may also be the digest of a
+// manifest. If refName is empty, the layout must list exactly one manifest.
+func Image(dir, refName string) (types.ModelArtifact, error) {
+	path, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open image layout %q: %w", dir, err)
+	}
+	desc, err := find(path, refName)
+	if err != nil {
+		return nil, err
+	}
+	img, err := path.Image(desc.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", desc.Digest, err)
+	}
+	return &artifact{img}, nil
+}
+
+// find returns the descriptor of the manifest listed under refName
+func find(path layout.Path, refName string) (v1.Descriptor, error) {
+	manifests, err := manifests(path)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	if refName == "" {
+		switch len(manifests) {
+		case 0:
+			return v1.Descriptor{}, ErrNotFound
+		case 1:
+			return manifests[0], nil
+		default:
+			return v1.Descriptor{}, fmt.Errorf("%w: a ref name or digest is required", ErrAmbiguous)
+		}
+	}
+	for _, desc := range manifests {
+		if desc.Annotations[AnnotationRefName] == refName {
+			return desc, nil
+		}
+	}
+	if strings.Contains(refName, ":") {
+		if digest, err := v1.NewHash(refName); err == nil {
+			for _, desc := range manifests {
+				if desc.Digest == digest {
+					return desc, nil
+				}
+			}
+		}
+	}
+	return v1.Descriptor{}, fmt.Errorf("%w: %q", ErrNotFound, refName)
+}
+
+// manifests returns the descriptors of the image manifests listed in the layout's index
+func manifests(path layout.Path) ([]v1.Descriptor, error) {
+	index, err := path.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	var images []v1.Descriptor
+	for _, desc := range manifest.Manifests {
+		if desc.MediaType.IsImage() {
+			images = append(images, desc)
+		}
+	}
+	return images, nil
+}
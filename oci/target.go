@@ -0,0 +1,185 @@
+// Package oci reads and writes models in OCI image layout directories (oci-layout, index.json and blobs/), as used by
+// tools like oras, skopeo and crane.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+
+	"github.com/docker/model-distribution/progress"
+	"github.com/docker/model-distribution/types"
+)
+
+// AnnotationRefName is the index annotation naming a manifest in an image layout, e.g. "latest"
+const AnnotationRefName = "org.opencontainers.image.ref.name"
+
+// Target writes an artifact to an OCI image layout directory. The directory is created if it does not exist, and
+// artifacts are added to an existing layout.
+type Target struct {
+	dir      string
+	refNames []string
+}
+
+// NewTarget returns a *Target for the image layout at dir. The artifact is listed in index.json under each of the
+// given ref names, replacing artifacts previously written with the same name. Without ref names the artifact is listed
+// by digest only.
+func NewTarget(dir string, refNames ...string) *Target {
+	return &Target{
+		dir:      dir,
+		refNames: refNames,
+	}
+}
+
+// Write writes the artifact's blobs to the layout and lists it in index.json, reporting progress to progressWriter as
+// a save operation. Blobs already in the layout are not written again.
+func (t *Target) Write(ctx context.Context, mdl types.ModelArtifact, progressWriter io.Writer) (err error) {
+	pw := progress.NewWriter(progressWriter)
+	if err := pw.Handle(progress.PhaseStart{Phase: progress.PhaseSave, Reference: t.dir}); err != nil {
+		fmt.Printf("reporter finished with non-fatal error: %v\n", err)
+		pw = progress.NewWriter(nil)
+	}
+	defer func() {
+		end := progress.PhaseEnd{Phase: progress.PhaseSave, Reference: t.dir, Err: err}
+		if err == nil {
+			end.Message = "Model saved successfully"
+		}
+		if err := pw.Handle(end); err != nil {
+			fmt.Printf("reporter finished with non-fatal error: %v\n", err)
+		}
+	}()
+	return t.write(ctx, mdl, pw)
+}
+
+func (t *Target) write(ctx context.Context, mdl types.ModelArtifact, pw progress.Writer) error {
+	path, err := openLayout(t.dir)
+	if err != nil {
+		return err
+	}
+
+	layers, err := mdl.Layers()
+	if err != nil {
+		return fmt.Errorf("get layers: %w", err)
+	}
+	var total int64
+	for _, layer := range layers {
+		size, err := layer.Size()
+		if err != nil {
+			return fmt.Errorf("get layer size: %w", err)
+		}
+		total += size
+	}
+	tracker := progress.NewTracker(pw, total)
+	for _, layer := range layers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeLayer(path, layer, tracker); err != nil {
+			return err
+		}
+	}
+
+	cfgName, err := mdl.ConfigName()
+	if err != nil {
+		return fmt.Errorf("get config name: %w", err)
+	}
+	rawConfig, err := mdl.RawConfigFile()
+	if err != nil {
+		return fmt.Errorf("get config: %w", err)
+	}
+	if err := path.WriteBlob(cfgName, io.NopCloser(bytes.NewReader(rawConfig))); err != nil {
+		return fmt.Errorf("write config blob: %w", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		return fmt.Errorf("get digest: %w", err)
+	}
+	rawManifest, err := mdl.RawManifest()
+	if err != nil {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+	if err := path.WriteBlob(digest, io.NopCloser(bytes.NewReader(rawManifest))); err != nil {
+		return fmt.Errorf("write manifest blob: %w", err)
+	}
+
+	// The blobs are in place, so replacing the image in the index does not write them again
+	if len(t.refNames) == 0 {
+		if err := path.ReplaceImage(mdl, match.Digests(digest)); err != nil {
+			return fmt.Errorf("update index: %w", err)
+		}
+		return nil
+	}
+	for _, refName := range t.refNames {
+		if err := path.ReplaceImage(mdl, match.Annotation(AnnotationRefName, refName),
+			layout.WithAnnotations(map[string]string{AnnotationRefName: refName}),
+		); err != nil {
+			return fmt.Errorf("update index: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeLayer writes the layer blob to the layout unless it is already present, reporting its progress to tracker
+func writeLayer(path layout.Path, layer v1.Layer, tracker *progress.Tracker) error {
+	digest, err := layer.Digest()
+	if err != nil {
+		return fmt.Errorf("get layer digest: %w", err)
+	}
+	size, err := layer.Size()
+	if err != nil {
+		return fmt.Errorf("get layer size: %w", err)
+	}
+	info := progress.Layer{ID: digest.String(), Size: size}
+	blobPath := filepath.Join(string(path), "blobs", digest.Algorithm, digest.Hex)
+	if fi, err := os.Stat(blobPath); err == nil {
+		if fi.Size() == size {
+			tracker.Skip(info)
+			return nil
+		}
+		// an incomplete blob from an interrupted write would otherwise be kept
+		if err := os.Remove(blobPath); err != nil {
+			return fmt.Errorf("remove incomplete blob: %w", err)
+		}
+	}
+
+	rc, err := layer.Compressed()
+	if err != nil {
+		return fmt.Errorf("open layer %q: %w", digest, err)
+	}
+	defer rc.Close()
+	lt := tracker.Start(info)
+	if err := path.WriteBlob(digest, io.NopCloser(lt.Reader(rc))); err != nil {
+		os.Remove(blobPath)
+		return fmt.Errorf("write layer %q: %w", digest, err)
+	}
+	lt.Done()
+	return nil
+}
+
+// openLayout returns the image layout at dir, creating an empty one if dir does not contain a layout
+func openLayout(dir string) (layout.Path, error) {
+	path, err := layout.FromPath(dir)
+	if err == nil {
+		return path, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("open image layout %q: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create image layout directory: %w", err)
+	}
+	path, err = layout.Write(dir, empty.Index)
+	if err != nil {
+		return "", fmt.Errorf("create image layout %q: %w", dir, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,118 @@
+package oci_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/oci"
+)
+
+func TestTarget(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "layout")
+	mdl, err := gguf.NewModel(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := oci.NewTarget(dir, "v1").Write(t.Context(), mdl, nil); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "oci-layout")); err != nil {
+		t.Fatalf("Expected oci-layout file: %v", err)
+	}
+	manifest, err := mdl.Manifest()
+	if err != nil {
+		t.Fatalf("Failed to get manifest: %v", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	for _, blob := range []v1.Hash{digest, manifest.Config.Digest, manifest.Layers[0].Digest} {
+		if _, err := os.Stat(filepath.Join(dir, "blobs", blob.Algorithm, blob.Hex)); err != nil {
+			t.Fatalf("Expected blob %s: %v", blob, err)
+		}
+	}
+	if index := readIndex(t, dir); len(index.Manifests) != 1 || index.Manifests[0].Digest != digest ||
+		index.Manifests[0].Annotations[oci.AnnotationRefName] != "v1" {
+		t.Fatalf("Expected index to list %s as v1, got %+v", digest, index.Manifests)
+	}
+
+	// Writing another model under the same ref name replaces the first one
+	other := mutate.ContextSize(mdl, 2048)
+	if err := oci.NewTarget(dir, "v1", "latest").Write(t.Context(), other, nil); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+	otherDigest, err := other.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	index := readIndex(t, dir)
+	if len(index.Manifests) != 2 {
+		t.Fatalf("Expected 2 manifests in index, got %+v", index.Manifests)
+	}
+	for _, desc := range index.Manifests {
+		if desc.Digest != otherDigest {
+			t.Fatalf("Expected every ref name to list %s, got %+v", otherDigest, index.Manifests)
+		}
+	}
+
+	t.Run("read by ref name", func(t *testing.T) {
+		img, err := oci.Image(dir, "latest")
+		if err != nil {
+			t.Fatalf("Failed to read model: %v", err)
+		}
+		cfg, err := img.Config()
+		if err != nil {
+			t.Fatalf("Failed to get config: %v", err)
+		}
+		if cfg.ContextSize == nil || *cfg.ContextSize != 2048 {
+			t.Fatalf("Expected context size 2048, got %v", cfg.ContextSize)
+		}
+	})
+
+	t.Run("read by digest", func(t *testing.T) {
+		img, err := oci.Image(dir, otherDigest.String())
+		if err != nil {
+			t.Fatalf("Failed to read model: %v", err)
+		}
+		if d, err := img.Digest(); err != nil || d != otherDigest {
+			t.Fatalf("Expected digest %s, got %s (%v)", otherDigest, d, err)
+		}
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		if err := oci.NewTarget(dir).Write(t.Context(), mdl, nil); err != nil {
+			t.Fatalf("Failed to write model: %v", err)
+		}
+		if _, err := oci.Image(dir, ""); !errors.Is(err, oci.ErrAmbiguous) {
+			t.Fatalf("Expected %v, got %v", oci.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := oci.Image(dir, "missing"); !errors.Is(err, oci.ErrNotFound) {
+			t.Fatalf("Expected %v, got %v", oci.ErrNotFound, err)
+		}
+	})
+}
+
+func readIndex(t *testing.T, dir string) v1.IndexManifest {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		t.Fatalf("Failed to read index.json: %v", err)
+	}
+	var index v1.IndexManifest
+	if err := json.Unmarshal(raw, &index); err != nil {
+		t.Fatalf("Failed to parse index.json: %v", err)
+	}
+	return index
+}
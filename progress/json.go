@@ -0,0 +1,76 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/docker/model-distribution/internal/progress"
+)
+
+// NewJSONWriter returns a Writer writing events to w as JSON lines in the format emitted by earlier versions of this
+// module: a "progress" message for every LayerProgress and LayerSkip event and a "success" or "error" message for
+// the PhaseEnd event of the outermost operation. Bytes written to it are passed through to w.
+func NewJSONWriter(w io.Writer) Writer {
+	return &jsonWriter{w: w}
+}
+
+type jsonWriter struct {
+	w      io.Writer
+	phases []Phase // phases of the operations in progress, innermost last
+}
+
+func (h *jsonWriter) Write(p []byte) (int, error) {
+	return h.w.Write(p)
+}
+
+func (h *jsonWriter) Handle(e Event) error {
+	switch e := e.(type) {
+	case PhaseStart:
+		h.phases = append(h.phases, e.Phase)
+	case PhaseEnd:
+		if len(h.phases) > 0 {
+			h.phases = h.phases[:len(h.phases)-1]
+		}
+		if len(h.phases) > 0 {
+			// The outermost operation reports the result
+			return nil
+		}
+		if e.Err != nil {
+			return progress.WriteError(h.w, fmt.Sprintf("Error: %s", e.Err.Error()))
+		}
+		if e.Message != "" {
+			return progress.WriteSuccess(h.w, e.Message)
+		}
+	case LayerSkip:
+		return h.writeProgress(e.Layer, e.Layer.Size, e.Totals)
+	case LayerProgress:
+		return h.writeProgress(e.Layer, e.Complete, e.Totals)
+	}
+	return nil
+}
+
+func (h *jsonWriter) writeProgress(layer Layer, complete int64, totals Totals) error {
+	var phase Phase
+	if len(h.phases) > 0 {
+		phase = h.phases[len(h.phases)-1]
+	}
+	verb := "Downloaded"
+	switch phase {
+	case PhasePush, PhaseCopy:
+		verb = "Uploaded"
+	case PhaseLoad:
+		verb = "Loaded"
+	case PhaseSave:
+		verb = "Transferred"
+	}
+	msg := fmt.Sprintf("%s: %.2f MB", verb, float64(complete)/1024/1024)
+	return progress.WriteProgress(h.w, msg, safeUint64(totals.Total), safeUint64(layer.Size), safeUint64(complete), layer.ID)
+}
+
+// safeUint64 converts an int64 to uint64, ensuring the value is non-negative
+func safeUint64(n int64) uint64 {
+	if n < 0 {
+		return 0
+	}
+	return uint64(n)
+}
@@ -0,0 +1,137 @@
+// Package progress reports the progress of model transfers as typed events.
+//
+// Operations emit a PhaseStart and a PhaseEnd event around their work and layer events for every blob they
+// transfer. Operations may be nested, e.g. a copy between registries contains a push, in which case the events of the
+// inner operation are delivered between the PhaseStart and PhaseEnd events of the outer one. Blobs that are already
+// present at the destination are reported with a LayerSkip event instead of being transferred. Layer events carry
+// aggregate Totals for the whole operation, including throughput and an estimated time to completion.
+//
+// APIs in this module accept an io.Writer for progress. Writers that implement Writer receive typed events; any other
+// writer receives the JSON lines format produced by NewJSONWriter.
+package progress
+
+import (
+	"io"
+	"time"
+)
+
+// Phase identifies the operation an event belongs to
+type Phase string
+
+const (
+	PhasePull Phase = "pull"
+	PhasePush Phase = "push"
+	PhaseLoad Phase = "load"
+	PhaseCopy Phase = "copy"
+	PhaseSave Phase = "save"
+)
+
+// Event is implemented by all progress events
+type Event interface {
+	isEvent()
+}
+
+// Layer identifies the blob a layer event refers to
+type Layer struct {
+	ID   string // Layer diffID, empty when the transfer is only tracked as a whole
+	Size int64  // Layer size in bytes
+}
+
+// Totals describes the aggregate progress of an operation
+type Totals struct {
+	Total          int64         // Total number of bytes of all layers
+	Complete       int64         // Number of bytes transferred or skipped so far
+	BytesPerSecond float64       // Average throughput of transferred (not skipped) bytes
+	ETA            time.Duration // Estimated time remaining, zero if unknown
+}
+
+// PhaseStart is emitted when an operation starts
+type PhaseStart struct {
+	Phase     Phase
+	Reference string
+}
+
+// PhaseEnd is emitted when an operation finishes. Err is set if the operation failed, otherwise Message describes
+// the result.
+type PhaseEnd struct {
+	Phase     Phase
+	Reference string
+	Message   string
+	Err       error
+}
+
+// LayerStart is emitted before a layer is transferred
+type LayerStart struct {
+	Layer  Layer
+	Totals Totals
+}
+
+// LayerSkip is emitted instead of LayerStart for a layer that does not need to be transferred because it is already
+// present at the destination
+type LayerSkip struct {
+	Layer  Layer
+	Totals Totals
+}
+
+// LayerProgress is emitted while a layer is transferred. Updates are throttled, see UpdateInterval and
+// MinBytesForUpdate.
+type LayerProgress struct {
+	Layer    Layer
+	Complete int64
+	Totals   Totals
+}
+
+// LayerDone is emitted after a layer has been transferred
+type LayerDone struct {
+	Layer  Layer
+	Totals Totals
+}
+
+func (PhaseStart) isEvent()    {}
+func (PhaseEnd) isEvent()      {}
+func (LayerStart) isEvent()    {}
+func (LayerSkip) isEvent()     {}
+func (LayerProgress) isEvent() {}
+func (LayerDone) isEvent()     {}
+
+// Handler receives progress events. If Handle returns an error, no further events are delivered for the operation.
+type Handler interface {
+	Handle(Event) error
+}
+
+// HandlerFunc adapts a function to a Handler
+type HandlerFunc func(Event) error
+
+// Handle calls f(e)
+func (f HandlerFunc) Handle(e Event) error {
+	return f(e)
+}
+
+// Writer is a progress writer receiving typed events
+type Writer interface {
+	io.Writer
+	Handler
+}
+
+// NewWriter returns the Writer to deliver events for the given progress writer to: w itself if it implements Writer,
+// a JSON writer if it is not nil, and a writer discarding all events otherwise. Operations pass the returned Writer
+// on to the operations they are composed of, so that all events of an operation reach the same Writer.
+func NewWriter(w io.Writer) Writer {
+	if w == nil {
+		return discard{}
+	}
+	if pw, ok := w.(Writer); ok {
+		return pw
+	}
+	return NewJSONWriter(w)
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (discard) Handle(Event) error {
+	return nil
+}
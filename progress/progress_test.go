@@ -0,0 +1,333 @@
+package progress_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	internalprogress "github.com/docker/model-distribution/internal/progress"
+	"github.com/docker/model-distribution/progress"
+)
+
+// recorder records the events it receives
+type recorder struct {
+	events []progress.Event
+}
+
+func (r *recorder) Handle(e progress.Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) layerProgress() []progress.LayerProgress {
+	var updates []progress.LayerProgress
+	for _, e := range r.events {
+		if u, ok := e.(progress.LayerProgress); ok {
+			updates = append(updates, u)
+		}
+	}
+	return updates
+}
+
+func TestTrackerEmissionScenarios(t *testing.T) {
+	tests := []struct {
+		name          string
+		updates       []int64
+		delays        []time.Duration
+		expectedCount int
+		description   string
+		layerSize     int64
+	}{
+		{
+			name:    "time-based updates",
+			updates: []int64{100, 100, 1000},
+			delays: []time.Duration{
+				progress.UpdateInterval + 100*time.Millisecond,
+				progress.UpdateInterval + 100*time.Millisecond,
+			},
+			expectedCount: 3, // First update + 2 time-based updates
+			description:   "should emit updates based on time interval",
+			layerSize:     100,
+		},
+		{
+			name:          "byte-based updates",
+			updates:       []int64{progress.MinBytesForUpdate, progress.MinBytesForUpdate * 2},
+			delays:        []time.Duration{10 * time.Millisecond},
+			expectedCount: 2, // First update + 1 byte-based update
+			description:   "should emit update based on byte threshold",
+			layerSize:     progress.MinBytesForUpdate + 1,
+		},
+		{
+			name:          "no updates - too frequent",
+			updates:       []int64{100, 100, 100},
+			delays:        []time.Duration{10 * time.Millisecond, 10 * time.Millisecond},
+			expectedCount: 1, // Only first update
+			description:   "should not emit updates if too frequent",
+			layerSize:     200,
+		},
+		{
+			name:          "finish update",
+			updates:       []int64{100, 100, 200},
+			delays:        []time.Duration{10 * time.Millisecond, 10 * time.Millisecond},
+			expectedCount: 2, // first and last update
+			description:   "should emit updates if finished",
+			layerSize:     200,
+		},
+		{
+			name:          "no updates - too few bytes",
+			updates:       []int64{50, progress.MinBytesForUpdate, progress.MinBytesForUpdate + 100},
+			delays:        []time.Duration{10 * time.Millisecond},
+			expectedCount: 2, // First update and last update
+			description:   "should emit updates based on bytes even if too frequent",
+			layerSize:     100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rec recorder
+			layer := progress.Layer{ID: "sha256:c7790a0a70161f1bfd441cf157313e9efb8fcd1f0831193101def035ead23b32", Size: tt.layerSize}
+			lt := progress.NewTracker(&rec, tt.layerSize).Start(layer)
+			for i, update := range tt.updates {
+				lt.Update(update)
+				if i < len(tt.delays) {
+					time.Sleep(tt.delays[i])
+				}
+			}
+			lt.Done()
+
+			updates := rec.layerProgress()
+			if len(updates) != tt.expectedCount {
+				t.Errorf("%s: expected %d updates, got %d", tt.description, tt.expectedCount, len(updates))
+			}
+			for i, u := range updates {
+				if u.Layer != layer {
+					t.Errorf("update %d: expected layer %v, got %v", i, layer, u.Layer)
+				}
+			}
+			if _, ok := rec.events[0].(progress.LayerStart); !ok {
+				t.Errorf("expected first event to be LayerStart, got %T", rec.events[0])
+			}
+			if _, ok := rec.events[len(rec.events)-1].(progress.LayerDone); !ok {
+				t.Errorf("expected last event to be LayerDone, got %T", rec.events[len(rec.events)-1])
+			}
+		})
+	}
+}
+
+func TestTrackerJSONOutput(t *testing.T) {
+	var buf bytes.Buffer
+	w := progress.NewJSONWriter(&buf)
+	layer := progress.Layer{ID: "sha256:c7790a0a70161f1bfd441cf157313e9efb8fcd1f0831193101def035ead23b32", Size: 2 * progress.MinBytesForUpdate}
+	if err := w.Handle(progress.PhaseStart{Phase: progress.PhasePull}); err != nil {
+		t.Fatalf("Failed to handle event: %v", err)
+	}
+	lt := progress.NewTracker(w, layer.Size+1).Start(layer)
+	for _, complete := range []int64{progress.MinBytesForUpdate, progress.MinBytesForUpdate + 1, layer.Size} {
+		lt.Update(complete)
+	}
+	lt.Done()
+
+	var messages []internalprogress.Message
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var msg internalprogress.Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+	expected := []internalprogress.Message{
+		{Type: "progress", Message: "Downloaded: 1.00 MB", Total: uint64(layer.Size + 1), Pulled: progress.MinBytesForUpdate, Layer: internalprogress.Layer{ID: layer.ID, Size: uint64(layer.Size), Current: progress.MinBytesForUpdate}},
+		{Type: "progress", Message: "Downloaded: 2.00 MB", Total: uint64(layer.Size + 1), Pulled: uint64(layer.Size), Layer: internalprogress.Layer{ID: layer.ID, Size: uint64(layer.Size), Current: uint64(layer.Size)}},
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d: %s", len(expected), len(messages), buf.String())
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("Message %d: expected %+v, got %+v", i, expected[i], messages[i])
+		}
+	}
+}
+
+func TestTrackerTotals(t *testing.T) {
+	var rec recorder
+	tracker := progress.NewTracker(&rec, 300)
+
+	tracker.Skip(progress.Layer{ID: "sha256:cached", Size: 100})
+	lt := tracker.Start(progress.Layer{ID: "sha256:new", Size: 200})
+	time.Sleep(10 * time.Millisecond)
+	if _, err := io.Copy(io.Discard, lt.Reader(strings.NewReader(strings.Repeat("x", 100)))); err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+
+	totals := tracker.Totals()
+	if totals.Total != 300 {
+		t.Errorf("Expected total 300, got %d", totals.Total)
+	}
+	if totals.Complete != 200 {
+		t.Errorf("Expected 200 complete bytes including skipped layer, got %d", totals.Complete)
+	}
+	if totals.BytesPerSecond <= 0 {
+		t.Errorf("Expected throughput to be set, got %f", totals.BytesPerSecond)
+	}
+	if totals.ETA <= 0 {
+		t.Errorf("Expected ETA to be set, got %s", totals.ETA)
+	}
+
+	skip, ok := rec.events[0].(progress.LayerSkip)
+	if !ok {
+		t.Fatalf("Expected first event to be LayerSkip, got %T", rec.events[0])
+	}
+	if skip.Totals.Complete != 100 || skip.Totals.BytesPerSecond != 0 {
+		t.Errorf("Expected skipped bytes to count as complete without throughput, got %+v", skip.Totals)
+	}
+}
+
+func TestTrackerHandlerError(t *testing.T) {
+	calls := 0
+	handler := progress.HandlerFunc(func(progress.Event) error {
+		calls++
+		return errors.New("broken pipe")
+	})
+	tracker := progress.NewTracker(handler, 100)
+	lt := tracker.Start(progress.Layer{Size: 100})
+	lt.Update(100)
+	lt.Done()
+	if calls != 1 {
+		t.Errorf("Expected no events after the handler failed, got %d calls", calls)
+	}
+	if tracker.Err() == nil {
+		t.Errorf("Expected tracker to return the handler error")
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := progress.NewWriter(&buf)
+	events := []progress.Event{
+		progress.PhaseStart{Phase: progress.PhasePush, Reference: "some/model:latest"},
+		progress.LayerSkip{Layer: progress.Layer{ID: "sha256:cached", Size: 100}, Totals: progress.Totals{Total: 300, Complete: 100}},
+		progress.LayerStart{Layer: progress.Layer{ID: "sha256:new", Size: 200}, Totals: progress.Totals{Total: 300, Complete: 100}},
+		progress.LayerProgress{Layer: progress.Layer{ID: "sha256:new", Size: 200}, Complete: 200, Totals: progress.Totals{Total: 300, Complete: 300}},
+		progress.LayerDone{Layer: progress.Layer{ID: "sha256:new", Size: 200}, Totals: progress.Totals{Total: 300, Complete: 300}},
+		progress.PhaseEnd{Phase: progress.PhasePush, Reference: "some/model:latest", Message: "Model pushed successfully"},
+		progress.PhaseEnd{Phase: progress.PhasePush, Err: errors.New("something went wrong")},
+	}
+	for _, e := range events {
+		if err := w.Handle(e); err != nil {
+			t.Fatalf("Failed to handle event: %v", err)
+		}
+	}
+
+	var messages []internalprogress.Message
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var msg internalprogress.Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+	expected := []internalprogress.Message{
+		{Type: "progress", Message: "Uploaded: 0.00 MB", Total: 300, Pulled: 100, Layer: internalprogress.Layer{ID: "sha256:cached", Size: 100, Current: 100}},
+		{Type: "progress", Message: "Uploaded: 0.00 MB", Total: 300, Pulled: 200, Layer: internalprogress.Layer{ID: "sha256:new", Size: 200, Current: 200}},
+		{Type: "success", Message: "Model pushed successfully"},
+		{Type: "error", Message: "Error: something went wrong"},
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d: %s", len(expected), len(messages), buf.String())
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("Message %d: expected %+v, got %+v", i, expected[i], messages[i])
+		}
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	var buf bytes.Buffer
+	terminal := progress.NewTerminalWriter(&buf)
+	if progress.NewWriter(terminal) != terminal {
+		t.Errorf("Expected writer implementing Writer to be used as is")
+	}
+	if err := progress.NewWriter(nil).Handle(progress.PhaseEnd{Message: "done"}); err != nil {
+		t.Errorf("Expected nil writer to discard events, got %v", err)
+	}
+}
+
+func TestTerminalWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := progress.NewTerminalWriter(&buf)
+	w.Handle(progress.PhaseStart{Phase: progress.PhasePull})
+	w.Handle(progress.LayerSkip{Totals: progress.Totals{Total: 2048, Complete: 1024}})
+	w.Handle(progress.LayerProgress{Totals: progress.Totals{Total: 2048, Complete: 2048}})
+	w.Handle(progress.PhaseEnd{Phase: progress.PhasePull, Message: "Model pulled successfully"})
+
+	out := buf.String()
+	if !strings.Contains(out, "\r"+progress.FormatBar(progress.Totals{Total: 2048, Complete: 1024})) {
+		t.Errorf("Expected progress bar for skipped layer, got %q", out)
+	}
+	if !strings.HasSuffix(out, "\nModel pulled successfully\n") {
+		t.Errorf("Expected bar line to be finished before the result, got %q", out)
+	}
+}
+
+func TestFormatBar(t *testing.T) {
+	tests := []struct {
+		totals   progress.Totals
+		expected string
+	}{
+		{
+			totals:   progress.Totals{Total: 0},
+			expected: "[>                             ]   0% 0 B / 0 B",
+		},
+		{
+			totals: progress.Totals{
+				Total:          2 * 1024 * 1024 * 1024,
+				Complete:       1024 * 1024 * 1024,
+				BytesPerSecond: 25 * 1024 * 1024,
+				ETA:            41*time.Second + 200*time.Millisecond,
+			},
+			expected: "[===============>              ]  50% 1.00 GB / 2.00 GB  25.00 MB/s  ETA 41s",
+		},
+		{
+			totals:   progress.Totals{Total: 1536, Complete: 1536},
+			expected: "[==============================] 100% 1.50 KB / 1.50 KB",
+		},
+	}
+	for _, tt := range tests {
+		if got := progress.FormatBar(tt.totals); got != tt.expected {
+			t.Errorf("FormatBar(%+v): expected %q, got %q", tt.totals, tt.expected, got)
+		}
+	}
+}
+
+func TestJSONWriterNestedPhases(t *testing.T) {
+	var buf bytes.Buffer
+	w := progress.NewJSONWriter(&buf)
+	for _, e := range []progress.Event{
+		progress.PhaseStart{Phase: progress.PhaseCopy},
+		progress.PhaseStart{Phase: progress.PhasePush},
+		progress.LayerProgress{Layer: progress.Layer{Size: 100}, Complete: 100, Totals: progress.Totals{Total: 100, Complete: 100}},
+		progress.PhaseEnd{Phase: progress.PhasePush, Message: "Model pushed successfully"},
+		progress.PhaseEnd{Phase: progress.PhaseCopy, Message: "Model copied successfully"},
+	} {
+		if err := w.Handle(e); err != nil {
+			t.Fatalf("Failed to handle event: %v", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a progress and a single success message, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "Uploaded:") {
+		t.Errorf("Expected progress of the inner push, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "Model copied successfully") {
+		t.Errorf("Expected result of the outer copy, got %q", lines[1])
+	}
+}
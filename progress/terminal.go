@@ -0,0 +1,114 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// barWidth is the number of characters in the progress bar rendered by the terminal writer
+const barWidth = 30
+
+// NewTerminalWriter returns a Writer rendering the aggregate progress of operations as a single progress bar line on
+// a terminal. The line is redrawn in place for every LayerProgress and LayerSkip event and finished with the result
+// of the outermost operation on its PhaseEnd. Bytes written to it are passed through to w on a line of their own.
+func NewTerminalWriter(w io.Writer) Writer {
+	return &terminalWriter{w: w}
+}
+
+type terminalWriter struct {
+	w     io.Writer
+	line  bool // whether a progress bar line is displayed and not finished yet
+	depth int  // number of operations in progress
+}
+
+func (t *terminalWriter) Write(p []byte) (int, error) {
+	if err := t.finishLine(); err != nil {
+		return 0, err
+	}
+	return t.w.Write(p)
+}
+
+func (t *terminalWriter) Handle(e Event) error {
+	switch e := e.(type) {
+	case PhaseStart:
+		t.depth++
+	case LayerSkip:
+		return t.render(e.Totals)
+	case LayerProgress:
+		return t.render(e.Totals)
+	case PhaseEnd:
+		if err := t.finishLine(); err != nil {
+			return err
+		}
+		if t.depth > 0 {
+			t.depth--
+		}
+		if t.depth > 0 {
+			return nil
+		}
+		if e.Err != nil {
+			_, err := fmt.Fprintf(t.w, "Error: %s\n", e.Err.Error())
+			return err
+		}
+		if e.Message != "" {
+			_, err := fmt.Fprintln(t.w, e.Message)
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *terminalWriter) render(totals Totals) error {
+	t.line = true
+	// Return to the start of the line and clear it before redrawing
+	_, err := fmt.Fprintf(t.w, "\r%s\033[K", FormatBar(totals))
+	return err
+}
+
+func (t *terminalWriter) finishLine() error {
+	if !t.line {
+		return nil
+	}
+	t.line = false
+	_, err := fmt.Fprintln(t.w)
+	return err
+}
+
+// FormatBar formats the totals as a progress bar followed by the completed and total size, the throughput and the
+// estimated time remaining, e.g. "[=======>      ]  50% 1.00 GB / 2.00 GB  25.00 MB/s  ETA 41s".
+func FormatBar(totals Totals) string {
+	var ratio float64
+	if totals.Total > 0 {
+		ratio = min(float64(totals.Complete)/float64(totals.Total), 1)
+	}
+	filled := int(ratio * barWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+
+	s := fmt.Sprintf("[%s] %3d%% %s / %s", bar, int(ratio*100), formatBytes(totals.Complete), formatBytes(totals.Total))
+	if totals.BytesPerSecond > 0 {
+		s += fmt.Sprintf("  %s/s", formatBytes(int64(totals.BytesPerSecond)))
+	}
+	if totals.ETA > 0 {
+		s += fmt.Sprintf("  ETA %s", totals.ETA.Round(time.Second))
+	}
+	return s
+}
+
+// formatBytes formats a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %cB", float64(n)/float64(div), "KMGTP"[exp])
+}
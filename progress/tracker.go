@@ -0,0 +1,168 @@
+package progress
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/model-distribution/internal/progress"
+)
+
+const (
+	// UpdateInterval defines how often LayerProgress events should be sent
+	UpdateInterval = progress.UpdateInterval
+
+	// MinBytesForUpdate defines the minimum number of bytes that need to be transferred before sending a
+	// LayerProgress event, unless UpdateInterval has passed
+	MinBytesForUpdate = progress.MinBytesForUpdate
+)
+
+// Tracker emits layer events for an operation transferring layers with a known combined size and keeps the
+// aggregate totals. It is safe for concurrent use, so layers may be transferred in parallel.
+type Tracker struct {
+	mu          sync.Mutex
+	handler     Handler
+	err         error
+	total       int64
+	complete    int64
+	transferred int64
+	started     time.Time
+}
+
+// NewTracker returns a tracker delivering events to h for an operation transferring total bytes.
+// A nil handler discards all events.
+func NewTracker(h Handler, total int64) *Tracker {
+	if h == nil {
+		h = discard{}
+	}
+	return &Tracker{
+		handler: h,
+		total:   total,
+	}
+}
+
+// AddTotal increases the total number of bytes by n. It is used by operations that only learn the sizes of their
+// layers as they go, such as loads of streamed archives.
+func (t *Tracker) AddTotal(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total += n
+}
+
+// Skip reports that the layer does not need to be transferred. Its size counts towards the completed bytes but not
+// towards the throughput.
+func (t *Tracker) Skip(layer Layer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.complete += layer.Size
+	t.emit(LayerSkip{Layer: layer, Totals: t.totals()})
+}
+
+// Start reports that the transfer of the layer starts and returns a LayerTracker to report its progress with.
+func (t *Tracker) Start(layer Layer) *LayerTracker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started.IsZero() {
+		t.started = time.Now()
+	}
+	t.emit(LayerStart{Layer: layer, Totals: t.totals()})
+	return &LayerTracker{tracker: t, layer: layer}
+}
+
+// Totals returns the aggregate progress so far
+func (t *Tracker) Totals() Totals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.totals()
+}
+
+// Err returns the first error returned by the handler, after which no further events were delivered
+func (t *Tracker) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *Tracker) totals() Totals {
+	totals := Totals{
+		Total:    t.total,
+		Complete: t.complete,
+	}
+	if t.started.IsZero() {
+		return totals
+	}
+	elapsed := time.Since(t.started).Seconds()
+	if elapsed <= 0 || t.transferred == 0 {
+		return totals
+	}
+	totals.BytesPerSecond = float64(t.transferred) / elapsed
+	if remaining := t.total - t.complete; remaining > 0 {
+		totals.ETA = time.Duration(float64(remaining) / totals.BytesPerSecond * float64(time.Second))
+	}
+	return totals
+}
+
+// emit delivers the event unless the handler failed before. The caller must hold t.mu.
+func (t *Tracker) emit(e Event) {
+	if t.err != nil {
+		return
+	}
+	t.err = t.handler.Handle(e)
+}
+
+// LayerTracker reports the progress of a single layer transfer
+type LayerTracker struct {
+	tracker      *Tracker
+	layer        Layer
+	complete     int64
+	lastComplete int64
+	lastUpdate   time.Time
+}
+
+// Update reports that complete bytes of the layer have been transferred. LayerProgress events are only emitted if
+// UpdateInterval has passed or MinBytesForUpdate bytes were transferred since the last one, or the layer is complete.
+func (l *LayerTracker) Update(complete int64) {
+	t := l.tracker
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.complete += complete - l.complete
+	t.transferred += complete - l.complete
+	l.complete = complete
+
+	now := time.Now()
+	if now.Sub(l.lastUpdate) >= UpdateInterval ||
+		complete-l.lastComplete >= MinBytesForUpdate ||
+		complete == l.layer.Size {
+		t.emit(LayerProgress{Layer: l.layer, Complete: complete, Totals: t.totals()})
+		l.lastUpdate = now
+		l.lastComplete = complete
+	}
+}
+
+// Done reports that the layer transfer finished
+func (l *LayerTracker) Done() {
+	t := l.tracker
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.emit(LayerDone{Layer: l.layer, Totals: t.totals()})
+}
+
+// Reader returns a reader that reports the bytes read from r as progress of the layer
+func (l *LayerTracker) Reader(r io.Reader) io.Reader {
+	return &reader{r: r, layer: l}
+}
+
+type reader struct {
+	r        io.Reader
+	layer    *LayerTracker
+	complete int64
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.complete += int64(n)
+	if n > 0 || err == io.EOF {
+		r.layer.Update(r.complete)
+	}
+	return n, err
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -13,7 +12,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 
-	"github.com/docker/model-distribution/internal/progress"
+	"github.com/docker/model-distribution/progress"
 	"github.com/docker/model-distribution/types"
 )
 
@@ -30,6 +29,13 @@ type Client struct {
 	userAgent string
 	keychain  authn.Keychain
 	auth      authn.Authenticator
+	// credentials are the authenticators for registries with their own credentials, by registry host
+	credentials map[string]authn.Authenticator
+	tls         tlsOptions
+	pageSize    int
+	mirrors     map[string][]string
+	upload      uploadOptions
+	rateLimit   rateLimitOptions
 }
 
 type ClientOption func(*Client)
@@ -50,6 +56,15 @@ func WithUserAgent(userAgent string) ClientOption {
 	}
 }
 
+// WithPageSize sets the number of results requested per page when listing tags.
+func WithPageSize(size int) ClientOption {
+	return func(c *Client) {
+		if size > 0 {
+			c.pageSize = size
+		}
+	}
+}
+
 func WithAuthConfig(username, password string) ClientOption {
 	return func(c *Client) {
 		if username != "" && password != "" {
@@ -66,56 +81,88 @@ func NewClient(opts ...ClientOption) *Client {
 		transport: remote.DefaultTransport,
 		userAgent: DefaultUserAgent,
 		keychain:  authn.DefaultKeychain,
+		upload:    defaultUploadOptions(),
+		rateLimit: defaultRateLimitOptions(),
 	}
 	for _, opt := range opts {
 		opt(client)
 	}
+	client.transport = &tokenCacheTransport{
+		inner:  &rateLimitTransport{inner: client.configureTLS(client.transport), opts: client.rateLimit},
+		realms: make(map[string]bool),
+		tokens: make(map[string]cachedToken),
+	}
 	return client
 }
 
 func (c *Client) Model(ctx context.Context, reference string) (types.ModelArtifact, error) {
 	// Parse the reference
-	ref, err := name.ParseReference(reference)
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
 	if err != nil {
 		return nil, NewReferenceError(reference, err)
 	}
 
-	// Set up authentication options
-	authOpts := []remote.Option{
-		remote.WithContext(ctx),
-		remote.WithTransport(c.transport),
-		remote.WithUserAgent(c.userAgent),
+	// Return the artifact at the given reference, reading from mirrors first if any are configured. The image is bound
+	// to the endpoint that served its manifest, so its config and layers are read from the same mirror.
+	remoteImg, err := readWithMirrors(ctx, c, ref, func(endpoint name.Reference) (v1.Image, error) {
+		img, err := remote.Image(endpoint, c.remoteOptions(ctx)...)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := img.Digest()
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyDigest(ref, digest); err != nil {
+			return nil, err
+		}
+		return img, nil
+	})
+	if err != nil {
+		return nil, wrapRegistryError("pull", reference, err)
 	}
 
-	// Use direct auth if provided, otherwise fall back to keychain
-	if c.auth != nil {
-		authOpts = append(authOpts, remote.WithAuth(c.auth))
-	} else {
-		authOpts = append(authOpts, remote.WithAuthFromKeychain(c.keychain))
-	}
+	return &artifact{remoteImg}, nil
+}
 
-	// Return the artifact at the given reference
-	remoteImg, err := remote.Image(ref, authOpts...)
+// Digest resolves the reference to the digest of its manifest using a HEAD request, without downloading the manifest.
+func (c *Client) Digest(ctx context.Context, reference string) (v1.Hash, error) {
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
 	if err != nil {
-		errStr := err.Error()
-		if strings.Contains(errStr, "UNAUTHORIZED") {
-			return nil, NewRegistryError(reference, "UNAUTHORIZED", "Authentication required for this model", err)
-		}
-		if strings.Contains(errStr, "MANIFEST_UNKNOWN") {
-			return nil, NewRegistryError(reference, "MANIFEST_UNKNOWN", "Model not found", err)
+		return v1.Hash{}, NewReferenceError(reference, err)
+	}
+
+	desc, err := readWithMirrors(ctx, c, ref, func(endpoint name.Reference) (*v1.Descriptor, error) {
+		desc, err := remote.Head(endpoint, c.remoteOptions(ctx)...)
+		if err != nil {
+			return nil, err
 		}
-		if strings.Contains(errStr, "NAME_UNKNOWN") {
-			return nil, NewRegistryError(reference, "NAME_UNKNOWN", "Repository not found", err)
+		if err := verifyDigest(ref, desc.Digest); err != nil {
+			return nil, err
 		}
-		return nil, NewRegistryError(reference, "UNKNOWN", err.Error(), err)
+		return desc, nil
+	})
+	if err != nil {
+		return v1.Hash{}, wrapRegistryError("inspect", reference, err)
 	}
+	return desc.Digest, nil
+}
 
-	return &artifact{remoteImg}, nil
+// remoteOptions returns the options shared by all requests the client makes to a registry.
+func (c *Client) remoteOptions(ctx context.Context) []remote.Option {
+	opts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithTransport(c.transport),
+		remote.WithUserAgent(c.userAgent),
+	}
+
+	// Use per-registry credentials or direct auth if provided, otherwise fall back to keychain
+	return append(opts, remote.WithAuthFromKeychain(clientKeychain{c}))
 }
 
 func (c *Client) BlobURL(reference string, digest v1.Hash) (string, error) {
 	// Parse the reference
-	ref, err := name.ParseReference(reference)
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
 	if err != nil {
 		return "", NewReferenceError(reference, err)
 	}
@@ -129,19 +176,14 @@ func (c *Client) BlobURL(reference string, digest v1.Hash) (string, error) {
 
 func (c *Client) BearerToken(ctx context.Context, reference string) (string, error) {
 	// Parse the reference
-	ref, err := name.ParseReference(reference)
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
 	if err != nil {
 		return "", NewReferenceError(reference, err)
 	}
 
-	var auth authn.Authenticator
-	if c.auth != nil {
-		auth = c.auth
-	} else {
-		auth, err = c.keychain.Resolve(ref.Context())
-		if err != nil {
-			return "", fmt.Errorf("resolving credentials: %w", err)
-		}
+	auth, err := c.resolveAuth(ctx, ref.Context())
+	if err != nil {
+		return "", err
 	}
 
 	pr, err := transport.Ping(ctx, ref.Context().Registry, c.transport)
@@ -156,29 +198,53 @@ func (c *Client) BearerToken(ctx context.Context, reference string) (string, err
 	return tok.Token, nil
 }
 
+// Target pushes models to a tag in a registry
 type Target struct {
-	reference name.Reference
-	transport http.RoundTripper
-	userAgent string
-	keychain  authn.Keychain
-	auth      authn.Authenticator
+	reference name.Tag
+	tags      []name.Tag
+	client    *Client
 }
 
-func (c *Client) NewTarget(tag string) (*Target, error) {
-	ref, err := name.NewTag(tag)
+// NewTarget returns a *Target pushing to the given tag. Extra tags are tag names that are pushed to the same
+// repository in the same operation, e.g. "latest".
+func (c *Client) NewTarget(tag string, extraTags ...string) (*Target, error) {
+	ref, err := name.NewTag(tag, c.nameOptions(tag)...)
 	if err != nil {
 		return nil, fmt.Errorf("invalid tag: %q: %w", tag, err)
 	}
-	return &Target{
+	t := &Target{
 		reference: ref,
-		transport: c.transport,
-		userAgent: c.userAgent,
-		keychain:  c.keychain,
-		auth:      c.auth,
-	}, nil
+		client:    c,
+	}
+	for _, extra := range extraTags {
+		extraRef, err := name.NewTag(ref.Context().Name()+":"+extra, c.nameOptions(tag)...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag: %q: %w", extra, err)
+		}
+		t.tags = append(t.tags, extraRef)
+	}
+	return t, nil
 }
 
-func (t *Target) Write(ctx context.Context, model types.ModelArtifact, progressWriter io.Writer) error {
+// Write pushes the model to the registry, reporting progress to progressWriter as a push operation. Blobs the
+// registry already has are reported as skipped and are not uploaded again. Layers are uploaded in chunks, in parallel
+// up to the client's upload concurrency, and interrupted uploads are resumed.
+func (t *Target) Write(ctx context.Context, model types.ModelArtifact, progressWriter io.Writer) (err error) {
+	pw := progress.NewWriter(progressWriter)
+	if err := pw.Handle(progress.PhaseStart{Phase: progress.PhasePush, Reference: t.reference.String()}); err != nil {
+		fmt.Printf("reporter finished with non-fatal error: %v\n", err)
+		pw = progress.NewWriter(nil)
+	}
+	defer func() {
+		end := progress.PhaseEnd{Phase: progress.PhasePush, Reference: t.reference.String(), Err: err}
+		if err == nil {
+			end.Message = "Model pushed successfully"
+		}
+		if err := pw.Handle(end); err != nil {
+			fmt.Printf("reporter finished with non-fatal error: %v\n", err)
+		}
+	}()
+
 	layers, err := model.Layers()
 	if err != nil {
 		return fmt.Errorf("getting layers: %w", err)
@@ -192,26 +258,33 @@ func (t *Target) Write(ctx context.Context, model types.ModelArtifact, progressW
 		}
 		imageSize += size
 	}
-	pr := progress.NewProgressReporter(progressWriter, progress.PushMsg, imageSize, nil)
-	defer pr.Wait()
 
-	// Set up authentication options
-	authOpts := []remote.Option{
-		remote.WithContext(ctx),
-		remote.WithTransport(t.transport),
-		remote.WithUserAgent(t.userAgent),
-		remote.WithProgress(pr.Updates()),
+	var mountFrom []name.Repository
+	for _, layer := range layers {
+		if ml, ok := layer.(*remote.MountableLayer); ok {
+			mountFrom = append(mountFrom, ml.Reference.Context())
+		}
 	}
-
-	// Use direct auth if provided, otherwise fall back to keychain
-	if t.auth != nil {
-		authOpts = append(authOpts, remote.WithAuth(t.auth))
-	} else {
-		authOpts = append(authOpts, remote.WithAuthFromKeychain(t.keychain))
+	up, err := t.client.newUploader(ctx, t.reference.Context(), mountFrom)
+	if err != nil {
+		return fmt.Errorf("write to registry %q: %w", t.reference.String(), err)
+	}
+	tracker := progress.NewTracker(pw, imageSize)
+	if err := up.uploadLayers(ctx, layers, tracker); err != nil {
+		return fmt.Errorf("write to registry %q: %w", t.reference.String(), wrapRegistryError("push", t.reference.String(), err))
 	}
 
-	if err := remote.Write(t.reference, model, authOpts...); err != nil {
-		return fmt.Errorf("write to registry %q: %w", t.reference.String(), err)
+	// Upload the config and the manifest. The layers are present now, so they are not uploaded again.
+	if err := remote.Write(t.reference, model, t.client.remoteOptions(ctx)...); err != nil {
+		return fmt.Errorf("write to registry %q: %w", t.reference.String(), wrapRegistryError("push", t.reference.String(), err))
+	}
+	for _, tag := range t.tags {
+		if err := remote.Tag(tag, model, t.client.remoteOptions(ctx)...); err != nil {
+			return fmt.Errorf("tag %q: %w", tag.String(), wrapRegistryError("push", tag.String(), err))
+		}
+	}
+	if err := tracker.Err(); err != nil {
+		fmt.Printf("reporter finished with non-fatal error: %v\n", err)
 	}
 	return nil
 }
@@ -0,0 +1,337 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	// helperCacheTTL is how long credentials returned by a credential helper are reused before running it again
+	helperCacheTTL = 5 * time.Minute
+	// defaultTokenExpiry is the lifetime assumed for registry tokens without an expires_in, as in the token spec
+	defaultTokenExpiry = 60 * time.Second
+	// tokenExpiryMargin is how long before their expiry cached registry tokens are refreshed
+	tokenExpiryMargin = 10 * time.Second
+)
+
+// Credential holds the credentials for one registry. Exactly one kind of credential should be set: a username and
+// password, an identity token exchanged for registry tokens, a registry token sent as a bearer token as is, or the
+// name of a credential helper. Helper "desktop" runs the "docker-credential-desktop" binary.
+type Credential struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identity_token,omitempty"`
+	RegistryToken string `json:"registry_token,omitempty"`
+	Helper        string `json:"helper,omitempty"`
+}
+
+// WithCredentials sets the credentials to use for each registry host (e.g. "docker.io"). Registries without
+// credentials use the credentials set with WithAuthConfig, or the default keychain.
+func WithCredentials(credentials map[string]Credential) ClientOption {
+	return func(c *Client) {
+		for host, cred := range credentials {
+			reg, err := name.NewRegistry(host)
+			if err != nil {
+				continue
+			}
+			if c.credentials == nil {
+				c.credentials = make(map[string]authn.Authenticator)
+			}
+			c.credentials[reg.RegistryStr()] = cred.authenticator(reg)
+		}
+	}
+}
+
+// authenticator returns the authenticator for the credential
+func (cred Credential) authenticator(reg name.Registry) authn.Authenticator {
+	if cred.Helper != "" {
+		return &helperAuthenticator{helper: cred.Helper, serverURL: helperServerURL(reg)}
+	}
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      cred.Username,
+		Password:      cred.Password,
+		IdentityToken: cred.IdentityToken,
+		RegistryToken: cred.RegistryToken,
+	})
+}
+
+// helperServerURL returns the server URL credential helpers store the credentials of the registry under
+func helperServerURL(reg name.Registry) string {
+	if reg.RegistryStr() == name.DefaultRegistry {
+		return authn.DefaultAuthKey
+	}
+	return reg.RegistryStr()
+}
+
+// clientKeychain resolves the credentials of a client. Per-registry credentials take precedence over the credentials
+// set with WithAuthConfig, which take precedence over the client's keychain.
+type clientKeychain struct {
+	client *Client
+}
+
+func (k clientKeychain) Resolve(resource authn.Resource) (authn.Authenticator, error) {
+	return k.ResolveContext(context.Background(), resource)
+}
+
+func (k clientKeychain) ResolveContext(ctx context.Context, resource authn.Resource) (authn.Authenticator, error) {
+	if auth, ok := k.client.credentials[resource.RegistryStr()]; ok {
+		return auth, nil
+	}
+	if k.client.auth != nil {
+		return k.client.auth, nil
+	}
+	return authn.Resolve(ctx, k.client.keychain, resource)
+}
+
+// resolveAuth returns the authenticator for the repository
+func (c *Client) resolveAuth(ctx context.Context, repo name.Repository) (authn.Authenticator, error) {
+	auth, err := clientKeychain{c}.ResolveContext(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("resolving credentials: %w", err)
+	}
+	return auth, nil
+}
+
+// helperAuthenticator gets credentials from a Docker credential helper, reusing them for a while so that the helper
+// does not run for every request
+type helperAuthenticator struct {
+	helper    string
+	serverURL string
+
+	mu      sync.Mutex
+	cfg     *authn.AuthConfig
+	expires time.Time
+}
+
+func (h *helperAuthenticator) Authorization() (*authn.AuthConfig, error) {
+	return h.AuthorizationContext(context.Background())
+}
+
+func (h *helperAuthenticator) AuthorizationContext(ctx context.Context) (*authn.AuthConfig, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cfg != nil && time.Now().Before(h.expires) {
+		return h.cfg, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker-credential-"+h.helper, "get")
+	cmd.Stdin = strings.NewReader(h.serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Helpers report missing credentials on stdout
+		msg := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(msg, "credentials not found") {
+			return &authn.AuthConfig{}, nil
+		}
+		return nil, fmt.Errorf("running credential helper %q: %w: %s", h.helper, err, msg)
+	}
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return nil, fmt.Errorf("parsing output of credential helper %q: %w", h.helper, err)
+	}
+
+	cfg := &authn.AuthConfig{Username: creds.Username, Password: creds.Secret}
+	if creds.Username == "<token>" {
+		cfg = &authn.AuthConfig{IdentityToken: creds.Secret}
+	}
+	h.cfg = cfg
+	h.expires = time.Now().Add(helperCacheTTL)
+	return cfg, nil
+}
+
+// tokenCacheTransport caches the registry tokens a client obtains from token services until they expire, so that
+// every operation does not exchange credentials again. Token services are recognized by the realm registries name in
+// their bearer challenges. A cached token the registry rejects is dropped, so that the next exchange gets a new one.
+type tokenCacheTransport struct {
+	inner http.RoundTripper
+
+	mu     sync.Mutex
+	realms map[string]bool
+	tokens map[string]cachedToken
+}
+
+// cachedToken is a token service response
+type cachedToken struct {
+	header  http.Header
+	body    []byte
+	token   string
+	expires time.Time
+}
+
+func (t *tokenCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	realm := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	t.mu.Lock()
+	isTokenRequest := t.realms[realm]
+	t.mu.Unlock()
+	if !isTokenRequest {
+		resp, err := t.inner.RoundTrip(req)
+		if err == nil {
+			t.observe(req, resp)
+		}
+		return resp, err
+	}
+
+	key, req, err := tokenKey(req)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	cached, ok := t.tokens[key]
+	t.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.response(req), nil
+	}
+
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var tok struct {
+		Token       string    `json:"token"`
+		AccessToken string    `json:"access_token"`
+		ExpiresIn   int       `json:"expires_in"`
+		IssuedAt    time.Time `json:"issued_at"`
+	}
+	if err := json.Unmarshal(body, &tok); err == nil && (tok.Token != "" || tok.AccessToken != "") {
+		issued := tok.IssuedAt
+		if issued.IsZero() {
+			issued = time.Now()
+		}
+		expiresIn := time.Duration(tok.ExpiresIn) * time.Second
+		if expiresIn <= 0 {
+			expiresIn = defaultTokenExpiry
+		}
+		token := tok.Token
+		if token == "" {
+			token = tok.AccessToken
+		}
+		t.mu.Lock()
+		t.tokens[key] = cachedToken{
+			header:  resp.Header.Clone(),
+			body:    body,
+			token:   token,
+			expires: issued.Add(expiresIn - tokenExpiryMargin),
+		}
+		t.mu.Unlock()
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// observe records the token service realms named in bearer challenges and drops cached tokens the registry rejected
+func (t *tokenCacheTransport) observe(req *http.Request, resp *http.Response) {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, challenge := range resp.Header.Values("WWW-Authenticate") {
+		if realm, ok := bearerRealm(challenge); ok {
+			t.realms[realm] = true
+		}
+	}
+	if rejected, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		for key, cached := range t.tokens {
+			if cached.token == rejected {
+				delete(t.tokens, key)
+			}
+		}
+	}
+}
+
+// response returns the cached token service response for req
+func (c cachedToken) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// tokenKey returns the cache key for a token request, which covers the requested scopes and the credentials sent
+// with it. The request is returned with its body restored.
+func tokenKey(req *http.Request) (string, *http.Request, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return strings.Join([]string{req.Method, req.URL.String(), req.Header.Get("Authorization"), string(body)}, "\n"), req, nil
+}
+
+// bearerRealm returns the realm of a bearer challenge of the form `Bearer realm="...",service="..."`
+func bearerRealm(challenge string) (string, bool) {
+	scheme, params, ok := strings.Cut(challenge, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return "", false
+	}
+	for _, param := range strings.Split(params, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(key, "realm") {
+			return strings.Trim(val, `"`), true
+		}
+	}
+	return "", false
+}
+
+// CheckCredentials verifies that the registry accepts the credentials by authenticating a request to its API root.
+// It fails with an error matching ErrUnauthorized if the registry rejects them.
+func (c *Client) CheckCredentials(ctx context.Context, host string, cred Credential) error {
+	reg, err := name.NewRegistry(host, c.nameOptions(host+"/")...)
+	if err != nil {
+		return NewReferenceError(host, err)
+	}
+	rt, err := transport.NewWithContext(ctx, reg, cred.authenticator(reg), transport.NewUserAgent(c.transport, c.userAgent), nil)
+	if err != nil {
+		return wrapRegistryError("log in", host, err)
+	}
+
+	url := fmt.Sprintf("%s://%s/v2/", reg.Scheme(), reg.RegistryStr())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		return wrapRegistryError("log in", host, err)
+	}
+	defer resp.Body.Close()
+	if err := transport.CheckError(resp, http.StatusOK); err != nil {
+		return wrapRegistryError("log in", host, err)
+	}
+	return nil
+}
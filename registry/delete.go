@@ -0,0 +1,39 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// DeleteManifest deletes the manifest the reference points to from the registry and returns its digest. Tags are
+// resolved to a digest first, as registries only delete manifests by digest, so every tag pointing to the same
+// manifest is removed along with it. Mirrors are never used. Registries that do not allow deletes fail with an error
+// matching ErrUnsupported or ErrDenied.
+func (c *Client) DeleteManifest(ctx context.Context, reference string) (v1.Hash, error) {
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
+	if err != nil {
+		return v1.Hash{}, NewReferenceError(reference, err)
+	}
+
+	digest, ok := ref.(name.Digest)
+	if !ok {
+		// GET rather than HEAD, so that failures carry the registry's error code
+		desc, err := remote.Get(ref, c.remoteOptions(ctx)...)
+		if err != nil {
+			return v1.Hash{}, wrapRegistryError("delete", reference, err)
+		}
+		digest = ref.Context().Digest(desc.Digest.String())
+	}
+	hash, err := v1.NewHash(digest.DigestStr())
+	if err != nil {
+		return v1.Hash{}, NewReferenceError(reference, err)
+	}
+
+	if err := remote.Delete(digest, c.remoteOptions(ctx)...); err != nil {
+		return v1.Hash{}, wrapRegistryError("delete", reference, err)
+	}
+	return hash, nil
+}
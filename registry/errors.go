@@ -1,8 +1,13 @@
 package registry
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 
 	"github.com/docker/model-distribution/types"
 )
@@ -11,12 +16,55 @@ var (
 	ErrInvalidReference     = errors.New("invalid model reference")
 	ErrModelNotFound        = errors.New("model not found")
 	ErrUnauthorized         = errors.New("unauthorized access to model")
+	ErrDenied               = errors.New("access to model denied")
+	ErrUnsupported          = errors.New("operation not supported by registry")
+	ErrRateLimited          = errors.New("registry rate limit exceeded")
+	ErrBlobUnknown          = errors.New("blob not found in registry")
+	ErrSizeInvalid          = errors.New("blob size does not match descriptor")
 	ErrUnsupportedMediaType = errors.New(fmt.Sprintf(
 		"client supports only models of type %q and older - try upgrading",
 		types.MediaTypeModelConfigV01,
 	))
 )
 
+// Error codes defined in the distribution spec, see
+// https://github.com/opencontainers/distribution-spec/blob/583e014d15418d839d67f68152bc2c83821770e0/spec.md#error-codes
+const (
+	codeBlobUnknown         = string(transport.BlobUnknownErrorCode)
+	codeManifestBlobUnknown = string(transport.ManifestBlobUnknownErrorCode)
+	codeManifestUnknown     = string(transport.ManifestUnknownErrorCode)
+	codeNameUnknown         = string(transport.NameUnknownErrorCode)
+	codeSizeInvalid         = string(transport.SizeInvalidErrorCode)
+	codeUnauthorized        = string(transport.UnauthorizedErrorCode)
+	codeDenied              = string(transport.DeniedErrorCode)
+	codeUnsupported         = string(transport.UnsupportedErrorCode)
+	codeTooManyRequests     = string(transport.TooManyRequestsErrorCode)
+	codeUnknown             = string(transport.UnknownErrorCode)
+)
+
+// codeMessages are the messages reported for error codes, replacing the registry's own message
+var codeMessages = map[string]string{
+	codeUnauthorized:        "Authentication required for this model",
+	codeDenied:              "Access to this model denied",
+	codeManifestUnknown:     "Model not found",
+	codeNameUnknown:         "Repository not found",
+	codeBlobUnknown:         "Model content not found",
+	codeManifestBlobUnknown: "Model content not found",
+	codeSizeInvalid:         "Model content size does not match its descriptor",
+	codeUnsupported:         "Operation not supported by the registry",
+	codeTooManyRequests:     "Too many requests to the registry",
+}
+
+// statusCodes are the error codes assumed for responses that carry no error envelope, e.g. responses to HEAD requests
+var statusCodes = map[int]string{
+	http.StatusUnauthorized:          codeUnauthorized,
+	http.StatusForbidden:             codeDenied,
+	http.StatusNotFound:              codeManifestUnknown,
+	http.StatusMethodNotAllowed:      codeUnsupported,
+	http.StatusRequestEntityTooLarge: codeSizeInvalid,
+	http.StatusTooManyRequests:       codeTooManyRequests,
+}
+
 // ReferenceError represents an error related to an invalid model reference
 type ReferenceError struct {
 	Reference string
@@ -39,15 +87,37 @@ func (e *ReferenceError) Is(target error) bool {
 // Error represents an error returned by an OCI registry
 type Error struct {
 	Reference string
+	// Operation is what the client was doing when the registry failed: "pull", "push", "inspect", "delete",
+	// "list tags" or "log in"
+	Operation string
 	// Code should be one of error codes defined in the distribution spec
 	// (see https://github.com/opencontainers/distribution-spec/blob/583e014d15418d839d67f68152bc2c83821770e0/spec.md#error-codes)
 	Code    string
 	Message string
-	Err     error
+	// StatusCode is the HTTP status of the failed response, or 0 if the request failed without a response
+	StatusCode int
+	// RetryAfter is how long the registry asked clients to wait before retrying a rate limited request
+	RetryAfter time.Duration
+	Err        error
 }
 
 func (e Error) Error() string {
-	return fmt.Sprintf("failed to pull model %q: %s - %s", e.Reference, e.Code, e.Message)
+	var action string
+	switch e.Operation {
+	case "":
+		action = "pull model"
+	case "list tags":
+		action = "list tags of"
+	case "log in":
+		action = "log in to"
+	default:
+		action = e.Operation + " model"
+	}
+	msg := fmt.Sprintf("failed to %s %q: %s - %s", action, e.Reference, e.Code, e.Message)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %s)", e.RetryAfter)
+	}
+	return msg
 }
 
 func (e Error) Unwrap() error {
@@ -58,9 +128,19 @@ func (e Error) Unwrap() error {
 func (e Error) Is(target error) bool {
 	switch target {
 	case ErrModelNotFound:
-		return e.Code == "MANIFEST_UNKNOWN" || e.Code == "NAME_UNKNOWN"
+		return e.Code == codeManifestUnknown || e.Code == codeNameUnknown
 	case ErrUnauthorized:
-		return e.Code == "UNAUTHORIZED"
+		return e.Code == codeUnauthorized
+	case ErrDenied:
+		return e.Code == codeDenied
+	case ErrUnsupported:
+		return e.Code == codeUnsupported
+	case ErrRateLimited:
+		return e.Code == codeTooManyRequests
+	case ErrBlobUnknown:
+		return e.Code == codeBlobUnknown || e.Code == codeManifestBlobUnknown
+	case ErrSizeInvalid:
+		return e.Code == codeSizeInvalid
 	default:
 		return false
 	}
@@ -83,3 +163,41 @@ func NewRegistryError(reference, code, message string, err error) error {
 		Err:       err,
 	}
 }
+
+// wrapRegistryError classifies an error returned by a registry request as an *Error. The code is taken from the
+// error envelope of the response if it has one, and from the HTTP status otherwise. Errors that are already
+// classified and context errors are returned unchanged.
+func wrapRegistryError(operation, reference string, err error) error {
+	var regErr *Error
+	if errors.As(err, &regErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	result := &Error{
+		Reference: reference,
+		Operation: operation,
+		Code:      codeUnknown,
+		Message:   err.Error(),
+		Err:       err,
+	}
+	var rateLimited *rateLimitError
+	if errors.As(err, &rateLimited) {
+		result.RetryAfter = rateLimited.retryAfter
+	}
+	var transportErr *transport.Error
+	if !errors.As(err, &transportErr) {
+		return result
+	}
+
+	result.StatusCode = transportErr.StatusCode
+	if len(transportErr.Errors) > 0 {
+		result.Code = string(transportErr.Errors[0].Code)
+		result.Message = transportErr.Errors[0].Message
+	} else if code, ok := statusCodes[transportErr.StatusCode]; ok {
+		result.Code = code
+	}
+	if msg, ok := codeMessages[result.Code]; ok {
+		result.Message = msg
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// WithMirrors configures mirrors for registries. Keys are registry hosts (e.g. "docker.io") and values are the hosts
+// of the mirrors to try, in order, before falling back to the registry itself. Mirrors are only used to read
+// manifests and blobs; tag listings and pushes always go to the registry named in the reference.
+func WithMirrors(mirrors map[string][]string) ClientOption {
+	return func(c *Client) {
+		for host, endpoints := range mirrors {
+			reg, err := name.NewRegistry(host)
+			if err != nil {
+				continue
+			}
+			if c.mirrors == nil {
+				c.mirrors = make(map[string][]string)
+			}
+			c.mirrors[reg.RegistryStr()] = append(c.mirrors[reg.RegistryStr()], endpoints...)
+		}
+	}
+}
+
+// endpoints returns the references to read from for the given reference: the reference rewritten to each configured
+// mirror, in order, followed by the reference itself.
+func (c *Client) endpoints(ref name.Reference) []name.Reference {
+	mirrors := c.mirrors[ref.Context().RegistryStr()]
+	refs := make([]name.Reference, 0, len(mirrors)+1)
+	for _, mirror := range mirrors {
+		mirrored := fmt.Sprintf("%s/%s%s%s", mirror, ref.Context().RepositoryStr(), separator(ref), ref.Identifier())
+		mirrorRef, err := name.ParseReference(mirrored, c.nameOptions(mirrored)...)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, mirrorRef)
+	}
+	return append(refs, ref)
+}
+
+// separator returns the separator between the repository and identifier of the reference
+func separator(ref name.Reference) string {
+	if _, ok := ref.(name.Digest); ok {
+		return "@"
+	}
+	return ":"
+}
+
+// readWithMirrors calls read with each endpoint for ref until one succeeds. If every mirror fails, the error from the
+// upstream registry is returned.
+func readWithMirrors[T any](ctx context.Context, c *Client, ref name.Reference, read func(name.Reference) (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+	for _, endpoint := range c.endpoints(ref) {
+		result, err = read(endpoint)
+		if err == nil || ctx.Err() != nil {
+			return result, err
+		}
+	}
+	return result, err
+}
+
+// verifyDigest checks that content served for a digest-pinned reference has the requested digest, regardless of
+// which endpoint served it.
+func verifyDigest(ref name.Reference, digest v1.Hash) error {
+	dgst, ok := ref.(name.Digest)
+	if !ok {
+		return nil
+	}
+	if digest.String() != dgst.DigestStr() {
+		return fmt.Errorf("manifest digest %s does not match requested digest %s", digest, dgst.DigestStr())
+	}
+	return nil
+}
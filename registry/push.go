@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/types"
+)
+
+// MissingBlobs returns the descriptors of the config and layer blobs of the model that the registry does not have
+// yet, i.e. the blobs a Write would upload. Nothing is written to the registry.
+func (t *Target) MissingBlobs(ctx context.Context, model types.ModelArtifact) ([]v1.Descriptor, error) {
+	manifest, err := model.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("getting manifest: %w", err)
+	}
+	up, err := t.client.newUploader(ctx, t.reference.Context(), nil)
+	if err != nil {
+		return nil, wrapRegistryError("push", t.reference.String(), err)
+	}
+	var missing []v1.Descriptor
+	for _, desc := range append([]v1.Descriptor{manifest.Config}, manifest.Layers...) {
+		exists, err := up.blobExists(ctx, desc.Digest)
+		if err != nil {
+			return nil, wrapRegistryError("push", t.reference.String(), err)
+		}
+		if !exists {
+			missing = append(missing, desc)
+		}
+	}
+	return missing, nil
+}
+
+// blobExists checks whether the repository contains the blob with the given digest using a HEAD request
+func (u *uploader) blobExists(ctx context.Context, digest v1.Hash) (bool, error) {
+	resp, err := u.do(ctx, http.MethodHead, u.url("/v2/"+u.repo.RepositoryStr()+"/blobs/"+digest.String()).String(),
+		nil, nil, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return false, err
+	}
+	return resp.StatusCode == http.StatusOK, nil
+}
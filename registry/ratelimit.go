@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// RateLimit describes the pull quota a registry reports in its RateLimit-Limit and RateLimit-Remaining headers
+type RateLimit struct {
+	// Enforced is false if the registry did not report a rate limit, in which case the other fields are zero.
+	Enforced bool
+	// Limit is the number of requests allowed per window.
+	Limit int
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+	// Window is the length of the window the limit applies to, if the registry reports it.
+	Window time.Duration
+	// Source identifies what the limit is counted against, e.g. an IP address or account, if the registry reports it.
+	Source string
+}
+
+// RateLimitStatus returns the rate limit the registry applies to pulls of the reference. The status is read from a
+// HEAD request for the manifest, which registries like Docker Hub do not count against the limit. Rate limited
+// requests are not retried, so the status is also returned once the quota is used up.
+func (c *Client) RateLimitStatus(ctx context.Context, reference string) (RateLimit, error) {
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
+	if err != nil {
+		return RateLimit{}, NewReferenceError(reference, err)
+	}
+	repo := ref.Context()
+
+	auth, err := c.resolveAuth(ctx, repo)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	ctx = context.WithValue(ctx, noRateLimitRetryKey{}, true)
+	rt, err := transport.NewWithContext(ctx, repo.Registry, auth, transport.NewUserAgent(c.transport, c.userAgent),
+		[]string{repo.Scope(transport.PullScope)})
+	if err != nil {
+		return RateLimit{}, wrapRegistryError("inspect", reference, err)
+	}
+
+	url := fmt.Sprintf("%s://%s/v2/%s/manifests/%s",
+		repo.Registry.Scheme(), repo.RegistryStr(), repo.RepositoryStr(), ref.Identifier())
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	var rateLimited *rateLimitError
+	if errors.As(err, &rateLimited) {
+		return parseRateLimit(rateLimited.header), nil
+	}
+	if err != nil {
+		return RateLimit{}, wrapRegistryError("inspect", reference, err)
+	}
+	defer resp.Body.Close()
+	if err := transport.CheckError(resp, http.StatusOK); err != nil {
+		return RateLimit{}, wrapRegistryError("inspect", reference, err)
+	}
+	return parseRateLimit(resp.Header), nil
+}
+
+// parseRateLimit reads the rate limit from response headers of the form "RateLimit-Limit: 100;w=21600"
+func parseRateLimit(header http.Header) RateLimit {
+	limit, window, okLimit := parseQuota(header.Get("RateLimit-Limit"))
+	remaining, _, okRemaining := parseQuota(header.Get("RateLimit-Remaining"))
+	if !okLimit || !okRemaining {
+		return RateLimit{}
+	}
+	return RateLimit{
+		Enforced:  true,
+		Limit:     limit,
+		Remaining: remaining,
+		Window:    window,
+		Source:    header.Get("Docker-RateLimit-Source"),
+	}
+}
+
+// parseQuota parses a quota header value made of a count and an optional ";w=<seconds>" window
+func parseQuota(value string) (int, time.Duration, bool) {
+	if value == "" {
+		return 0, 0, false
+	}
+	count, params, _ := strings.Cut(value, ";")
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return 0, 0, false
+	}
+	var window time.Duration
+	for _, param := range strings.Split(params, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if key != "w" {
+			continue
+		}
+		if seconds, err := strconv.Atoi(val); err == nil {
+			window = time.Duration(seconds) * time.Second
+		}
+	}
+	return n, window, true
+}
@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	mdpartial "github.com/docker/model-distribution/internal/partial"
+)
+
+// AnnotationTitle is the annotation holding the file name of a layer of an attached artifact
+const AnnotationTitle = "org.opencontainers.image.title"
+
+// emptyConfig is the config blob of referrer artifacts, which carry all their content in layers
+var emptyConfig = []byte("{}")
+
+// referrer is an artifact manifest that refers to a subject manifest. The artifact type is recorded as the config
+// media type, which registries without an artifactType field report as the referrer's artifact type.
+type referrer struct {
+	manifest []byte
+	layers   map[v1.Hash]v1.Layer
+}
+
+var _ partial.CompressedImageCore = &referrer{}
+
+// newReferrer returns an artifact of the given type, with the given layers, that refers to subject
+func newReferrer(subject v1.Descriptor, artifactType types.MediaType, layers ...v1.Layer) (v1.Image, error) {
+	configDigest, configSize, err := v1.SHA256(bytes.NewReader(emptyConfig))
+	if err != nil {
+		return nil, err
+	}
+	m := v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		Config: v1.Descriptor{
+			MediaType: artifactType,
+			Digest:    configDigest,
+			Size:      configSize,
+		},
+		Layers: []v1.Descriptor{},
+		Subject: &v1.Descriptor{
+			MediaType: subject.MediaType,
+			Digest:    subject.Digest,
+			Size:      subject.Size,
+		},
+	}
+	r := &referrer{layers: make(map[v1.Hash]v1.Layer)}
+	for _, layer := range layers {
+		desc, err := partial.Descriptor(layer)
+		if err != nil {
+			return nil, fmt.Errorf("getting layer descriptor: %w", err)
+		}
+		m.Layers = append(m.Layers, *desc)
+		r.layers[desc.Digest] = layer
+	}
+	if r.manifest, err = json.Marshal(m); err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+	return partial.CompressedToImage(r)
+}
+
+func (r *referrer) RawConfigFile() ([]byte, error) {
+	return emptyConfig, nil
+}
+
+func (r *referrer) MediaType() (types.MediaType, error) {
+	return types.OCIManifestSchema1, nil
+}
+
+func (r *referrer) RawManifest() ([]byte, error) {
+	return r.manifest, nil
+}
+
+func (r *referrer) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	layer, ok := r.layers[h]
+	if !ok {
+		return nil, fmt.Errorf("layer %s not found", h)
+	}
+	return layer, nil
+}
+
+// writeReferrer pushes a referrer to repo by digest. Registries without the referrers API get the referrer added to
+// the index tagged with the subject's fallback tag instead.
+func (c *Client) writeReferrer(ctx context.Context, repo name.Repository, img v1.Image) error {
+	digest, err := img.Digest()
+	if err != nil {
+		return fmt.Errorf("getting referrer digest: %w", err)
+	}
+	return remote.Write(repo.Digest(digest.String()), img, c.remoteOptions(ctx)...)
+}
+
+// referrers returns the descriptors of the artifacts of the given type that refer to digest in repo, or of all
+// artifacts if artifactType is empty. The referrers API is used if the registry supports it, the tag schema otherwise.
+func (c *Client) referrers(ctx context.Context, repo name.Repository, digest v1.Hash, artifactType types.MediaType) ([]v1.Descriptor, error) {
+	opts := c.remoteOptions(ctx)
+	if artifactType != "" {
+		opts = append(opts, remote.WithFilter("artifactType", string(artifactType)))
+	}
+	idx, err := remote.Referrers(repo.Digest(digest.String()), opts...)
+	if err != nil {
+		return nil, err
+	}
+	m, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("reading referrers index: %w", err)
+	}
+	return m.Manifests, nil
+}
+
+// subject returns the descriptor of the manifest the reference points to. Digest references are not resolved unless
+// resolve is true, leaving the media type and size of the descriptor unset.
+func (c *Client) subject(ctx context.Context, ref name.Reference, resolve bool) (v1.Descriptor, error) {
+	if digest, ok := ref.(name.Digest); ok && !resolve {
+		hash, err := v1.NewHash(digest.DigestStr())
+		if err != nil {
+			return v1.Descriptor{}, err
+		}
+		return v1.Descriptor{Digest: hash}, nil
+	}
+	// GET rather than HEAD, so that failures carry the registry's error code
+	desc, err := remote.Get(ref, c.remoteOptions(ctx)...)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	return desc.Descriptor, nil
+}
+
+// Referrers returns the descriptors of the artifacts of the given type, e.g. SBOMs or model cards, that refer to the
+// model the reference points to, or of all artifacts if artifactType is empty. The artifact type of each descriptor
+// is set. Registries without the referrers API are queried using the referrers tag schema. Mirrors are never used.
+func (c *Client) Referrers(ctx context.Context, reference string, artifactType string) ([]v1.Descriptor, error) {
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
+	if err != nil {
+		return nil, NewReferenceError(reference, err)
+	}
+	subject, err := c.subject(ctx, ref, false)
+	if err != nil {
+		return nil, wrapRegistryError("inspect", reference, err)
+	}
+	descs, err := c.referrers(ctx, ref.Context(), subject.Digest, types.MediaType(artifactType))
+	if err != nil {
+		return nil, wrapRegistryError("inspect", reference, err)
+	}
+	return descs, nil
+}
+
+// Referrer returns the artifact with the given digest from the repository of reference, e.g. one of the artifacts
+// returned by Referrers
+func (c *Client) Referrer(ctx context.Context, reference string, digest v1.Hash) (v1.Image, error) {
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
+	if err != nil {
+		return nil, NewReferenceError(reference, err)
+	}
+	img, err := remote.Image(ref.Context().Digest(digest.String()), c.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, wrapRegistryError("pull", reference, err)
+	}
+	return img, nil
+}
+
+// Attach pushes an artifact of the given type that refers to the model the reference points to, with one layer per
+// file. Layers have the artifact type as media type and are annotated with the base name of their file. The digest of
+// the artifact is returned. Registries without the referrers API get the artifact added to the referrers tag schema
+// index of the model.
+func (c *Client) Attach(ctx context.Context, reference string, artifactType string, files []string) (v1.Hash, error) {
+	if artifactType == "" {
+		return v1.Hash{}, fmt.Errorf("artifact type is required")
+	}
+	if len(files) == 0 {
+		return v1.Hash{}, fmt.Errorf("at least one file is required")
+	}
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
+	if err != nil {
+		return v1.Hash{}, NewReferenceError(reference, err)
+	}
+
+	var layers []v1.Layer
+	titles := make(map[string]bool)
+	for _, path := range files {
+		title := filepath.Base(path)
+		if titles[title] {
+			return v1.Hash{}, fmt.Errorf("duplicate file name %q", title)
+		}
+		titles[title] = true
+		layer, err := mdpartial.NewLayer(path, types.MediaType(artifactType))
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("layer from %q: %w", path, err)
+		}
+		layers = append(layers, &annotatedLayer{Layer: layer, annotations: map[string]string{AnnotationTitle: title}})
+	}
+
+	subject, err := c.subject(ctx, ref, true)
+	if err != nil {
+		return v1.Hash{}, wrapRegistryError("inspect", reference, err)
+	}
+	img, err := newReferrer(subject, types.MediaType(artifactType), layers...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("creating artifact: %w", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("getting artifact digest: %w", err)
+	}
+	if err := c.writeReferrer(ctx, ref.Context(), img); err != nil {
+		return v1.Hash{}, wrapRegistryError("push", reference, err)
+	}
+	return digest, nil
+}
+
+// annotatedLayer is a layer with annotations in its descriptor
+type annotatedLayer struct {
+	*mdpartial.Layer
+	annotations map[string]string
+}
+
+func (l *annotatedLayer) Descriptor() (*v1.Descriptor, error) {
+	desc := l.Layer.Descriptor
+	desc.Annotations = l.annotations
+	return &desc, nil
+}
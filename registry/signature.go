@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+
+	"github.com/docker/model-distribution/signing"
+	"github.com/docker/model-distribution/types"
+)
+
+// maxSignatureSize bounds the size of signature blobs read from a registry
+const maxSignatureSize = 64 * 1024
+
+// WriteSignature signs the digest of the model's manifest with signer and pushes the signature to the target
+// repository as a referrer of the model. The model must have been written to the target.
+func (t *Target) WriteSignature(ctx context.Context, model types.ModelArtifact, signer signing.Signer) error {
+	subject, err := descriptorFor(model)
+	if err != nil {
+		return err
+	}
+	sig, err := signing.Sign(ctx, signer, subject.Digest)
+	if err != nil {
+		return fmt.Errorf("signing model: %w", err)
+	}
+	data, err := json.Marshal(sig)
+	if err != nil {
+		return fmt.Errorf("encoding signature: %w", err)
+	}
+	img, err := newReferrer(subject, types.MediaTypeModelSignature, static.NewLayer(data, types.MediaTypeModelSignature))
+	if err != nil {
+		return fmt.Errorf("creating signature artifact: %w", err)
+	}
+	if err := t.client.writeReferrer(ctx, t.reference.Context(), img); err != nil {
+		return fmt.Errorf("write signature to registry %q: %w", t.reference.String(), wrapRegistryError("push", t.reference.String(), err))
+	}
+	return nil
+}
+
+// Signatures returns the signatures attached to the manifest with the given digest in the repository of reference.
+// Signature artifacts that cannot be decoded are ignored.
+func (c *Client) Signatures(ctx context.Context, reference string, digest v1.Hash) ([]signing.Signature, error) {
+	ref, err := name.ParseReference(reference, c.nameOptions(reference)...)
+	if err != nil {
+		return nil, NewReferenceError(reference, err)
+	}
+	repo := ref.Context()
+
+	descs, err := c.referrers(ctx, repo, digest, types.MediaTypeModelSignature)
+	if err != nil {
+		return nil, wrapRegistryError("inspect", reference, err)
+	}
+	var sigs []signing.Signature
+	for _, desc := range descs {
+		img, err := remote.Image(repo.Digest(desc.Digest.String()), c.remoteOptions(ctx)...)
+		if err != nil {
+			return nil, wrapRegistryError("inspect", reference, err)
+		}
+		layers, err := img.Layers()
+		if err != nil {
+			return nil, fmt.Errorf("getting signature layers: %w", err)
+		}
+		for _, layer := range layers {
+			if mt, err := layer.MediaType(); err != nil || mt != types.MediaTypeModelSignature {
+				continue
+			}
+			sig, err := readSignature(layer)
+			if err != nil {
+				continue
+			}
+			sigs = append(sigs, *sig)
+		}
+	}
+	return sigs, nil
+}
+
+func readSignature(layer v1.Layer) (*signing.Signature, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var sig signing.Signature
+	if err := json.NewDecoder(io.LimitReader(rc, maxSignatureSize)).Decode(&sig); err != nil {
+		return nil, err
+	}
+	return &sig, nil
+}
+
+// descriptorFor returns the descriptor of the model's manifest
+func descriptorFor(model types.ModelArtifact) (v1.Descriptor, error) {
+	mt, err := model.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("getting media type: %w", err)
+	}
+	digest, err := model.Digest()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("getting digest: %w", err)
+	}
+	size, err := model.Size()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("getting manifest size: %w", err)
+	}
+	return v1.Descriptor{MediaType: mt, Digest: digest, Size: size}, nil
+}
@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// RemoteTag describes a tag in a remote repository
+type RemoteTag struct {
+	// Name is the tag name, without the repository.
+	Name string
+	// Digest is the digest of the manifest the tag points to. It is only set when details are requested.
+	Digest v1.Hash
+	// Size is the combined size of the config and layers in bytes. It is only set when details are requested.
+	Size int64
+}
+
+// ListTags returns all tags in the given repository, following pagination links until every page has been read.
+func (c *Client) ListTags(ctx context.Context, repository string) ([]string, error) {
+	repo, err := name.NewRepository(repository, c.nameOptions(repository)...)
+	if err != nil {
+		return nil, NewReferenceError(repository, err)
+	}
+
+	opts := c.remoteOptions(ctx)
+	if c.pageSize > 0 {
+		opts = append(opts, remote.WithPageSize(c.pageSize))
+	}
+	puller, err := remote.NewPuller(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating puller: %w", err)
+	}
+
+	lister, err := puller.Lister(ctx, repo)
+	if err != nil {
+		return nil, wrapRegistryError("list tags", repository, err)
+	}
+	tags := []string{}
+	for lister.HasNext() {
+		page, err := lister.Next(ctx)
+		if err != nil {
+			return nil, wrapRegistryError("list tags", repository, err)
+		}
+		tags = append(tags, page.Tags...)
+	}
+	return tags, nil
+}
+
+// TagDetails resolves the given tag reference and returns the digest and size of the model it points to.
+func (c *Client) TagDetails(ctx context.Context, reference string) (RemoteTag, error) {
+	tag, err := name.NewTag(reference, c.nameOptions(reference)...)
+	if err != nil {
+		return RemoteTag{}, NewReferenceError(reference, err)
+	}
+
+	desc, err := readWithMirrors(ctx, c, tag, func(endpoint name.Reference) (*remote.Descriptor, error) {
+		return remote.Get(endpoint, c.remoteOptions(ctx)...)
+	})
+	if err != nil {
+		return RemoteTag{}, wrapRegistryError("inspect", reference, err)
+	}
+	manifest, err := v1.ParseManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return RemoteTag{}, fmt.Errorf("parsing manifest for %q: %w", reference, err)
+	}
+
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return RemoteTag{
+		Name:   tag.TagStr(),
+		Digest: desc.Digest,
+		Size:   size,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// tlsOptions configures how the client connects to registries
+type tlsOptions struct {
+	// insecure holds the hosts of registries that may be reached over plain HTTP or HTTPS without verification
+	insecure    map[string]bool
+	rootCAs     *x509.CertPool
+	clientCerts []tls.Certificate
+}
+
+// WithInsecureRegistries marks registries, given by host (e.g. "localhost:5000"), as insecure. They are reached over
+// HTTPS without verifying their certificate, falling back to plain HTTP.
+func WithInsecureRegistries(hosts ...string) ClientOption {
+	return func(c *Client) {
+		for _, host := range hosts {
+			reg, err := name.NewRegistry(host, name.Insecure)
+			if err != nil {
+				continue
+			}
+			if c.tls.insecure == nil {
+				c.tls.insecure = make(map[string]bool)
+			}
+			c.tls.insecure[reg.RegistryStr()] = true
+		}
+	}
+}
+
+// WithCACertPool sets the certificate authorities registry certificates are verified with, instead of the system's.
+// Like the other TLS options, it only applies if the client's transport is an *http.Transport, as the default one is.
+func WithCACertPool(pool *x509.CertPool) ClientOption {
+	return func(c *Client) {
+		if pool != nil {
+			c.tls.rootCAs = pool
+		}
+	}
+}
+
+// WithClientCertificate sets a certificate to present to registries that require TLS client authentication.
+func WithClientCertificate(cert tls.Certificate) ClientOption {
+	return func(c *Client) {
+		if cert.Certificate != nil {
+			c.tls.clientCerts = append(c.tls.clientCerts, cert)
+		}
+	}
+}
+
+// nameOptions returns the options to parse the reference, repository or registry with, so that references to
+// insecure registries use plain HTTP when HTTPS is not available
+func (c *Client) nameOptions(s string) []name.Option {
+	if len(c.tls.insecure) == 0 {
+		return nil
+	}
+	// The first path component is a registry host if it looks like one, as in the Docker reference grammar
+	host, _, ok := strings.Cut(s, "/")
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host = name.DefaultRegistry
+	}
+	if reg, err := name.NewRegistry(host); err == nil && c.tls.insecure[reg.RegistryStr()] {
+		return []name.Option{name.Insecure}
+	}
+	return nil
+}
+
+// configureTLS returns the transport configured with the client's TLS options. Transports that are not an
+// *http.Transport are returned unchanged.
+func (c *Client) configureTLS(rt http.RoundTripper) http.RoundTripper {
+	base, ok := rt.(*http.Transport)
+	if !ok || (len(c.tls.insecure) == 0 && c.tls.rootCAs == nil && len(c.tls.clientCerts) == 0) {
+		return rt
+	}
+
+	secure := base.Clone()
+	if secure.TLSClientConfig == nil {
+		secure.TLSClientConfig = &tls.Config{}
+	}
+	if c.tls.rootCAs != nil {
+		secure.TLSClientConfig.RootCAs = c.tls.rootCAs
+	}
+	secure.TLSClientConfig.Certificates = append(secure.TLSClientConfig.Certificates, c.tls.clientCerts...)
+	if len(c.tls.insecure) == 0 {
+		return secure
+	}
+
+	insecure := secure.Clone()
+	insecure.TLSClientConfig.InsecureSkipVerify = true //nolint:gosec // only for registries marked insecure
+	return &insecureTransport{secure: secure, insecure: insecure, hosts: c.tls.insecure}
+}
+
+// insecureTransport skips certificate verification for requests to insecure registries
+type insecureTransport struct {
+	secure   http.RoundTripper
+	insecure http.RoundTripper
+	hosts    map[string]bool
+}
+
+func (t *insecureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.hosts[req.URL.Host] {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.secure.RoundTrip(req)
+}
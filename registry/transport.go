@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	// DefaultRateLimitRetries is the number of times a rate limited request is retried by default
+	DefaultRateLimitRetries = 3
+	// DefaultRateLimitMaxWait is the total time spent waiting for rate limits to reset by default
+	DefaultRateLimitMaxWait = time.Minute
+)
+
+// rateLimitOptions configures how rate limited requests are retried
+type rateLimitOptions struct {
+	retries int
+	maxWait time.Duration
+	// backoff is the delay before a retry when the registry did not send a Retry-After header
+	backoff func(attempt int) time.Duration
+}
+
+func defaultRateLimitOptions() rateLimitOptions {
+	return rateLimitOptions{
+		retries: DefaultRateLimitRetries,
+		maxWait: DefaultRateLimitMaxWait,
+		backoff: func(attempt int) time.Duration {
+			return time.Second << attempt
+		},
+	}
+}
+
+// WithRateLimitRetries sets the number of times a request the registry rejected with 429 Too Many Requests is retried
+// after waiting for the delay given in its Retry-After header. Zero disables retries.
+func WithRateLimitRetries(n int) ClientOption {
+	return func(c *Client) {
+		if n >= 0 {
+			c.rateLimit.retries = n
+		}
+	}
+}
+
+// WithRateLimitMaxWait sets the total time a request may spend waiting for rate limits to reset. A request the
+// registry asks to wait longer than the remaining time fails immediately.
+func WithRateLimitMaxWait(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.rateLimit.maxWait = d
+		}
+	}
+}
+
+// rateLimitTransport retries rate limited requests once the delay the registry asked for in its Retry-After header
+// has passed, as long as the retry budget allows. Requests that are still rate limited fail with an error carrying
+// the delay, which is lost once a response has been turned into a transport.Error.
+type rateLimitTransport struct {
+	inner http.RoundTripper
+	opts  rateLimitOptions
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		resp, err := t.inner.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+
+		retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		wait := retryAfter
+		if !ok {
+			wait = t.opts.backoff(attempt)
+		}
+		// Requests with a body can only be retried if the body can be read again
+		noRetry, _ := req.Context().Value(noRateLimitRetryKey{}).(bool)
+		if noRetry || attempt >= t.opts.retries || waited+wait > t.opts.maxWait || (req.Body != nil && req.GetBody == nil) {
+			defer resp.Body.Close()
+			return nil, &rateLimitError{err: transport.CheckError(resp), retryAfter: retryAfter, header: resp.Header}
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		waited += wait
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// noRateLimitRetryKey is the context key marking requests that fail as soon as they are rate limited
+type noRateLimitRetryKey struct{}
+
+// rateLimitError is the error returned for a request that is still rate limited after retrying
+type rateLimitError struct {
+	err        error
+	retryAfter time.Duration
+	header     http.Header
+}
+
+func (e *rateLimitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rateLimitError) Unwrap() error {
+	return e.err
+}
+
+// Temporary reports rate limited requests as permanent failures, as the transport already retried them as long as
+// the retry budget allowed
+func (e *rateLimitError) Temporary() bool {
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date. It returns false if the
+// header is missing or invalid.
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,411 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/docker/model-distribution/progress"
+)
+
+const (
+	// DefaultUploadChunkSize is the size of the chunks blobs are uploaded in by default
+	DefaultUploadChunkSize = 64 * 1024 * 1024 // 64MB
+	// DefaultUploadRetries is the number of times an upload is resumed after an error by default
+	DefaultUploadRetries = 3
+)
+
+// uploadOptions configures how blobs are uploaded
+type uploadOptions struct {
+	chunkSize   int64
+	concurrency int
+	retries     int
+	backoff     func(attempt int) time.Duration
+}
+
+func defaultUploadOptions() uploadOptions {
+	return uploadOptions{
+		chunkSize:   DefaultUploadChunkSize,
+		concurrency: 1,
+		retries:     DefaultUploadRetries,
+		backoff: func(attempt int) time.Duration {
+			// 200ms * 2^attempt, capped at 5s
+			d := time.Duration(float64(200*time.Millisecond) * math.Pow(2, float64(attempt)))
+			return min(d, 5*time.Second)
+		},
+	}
+}
+
+// WithUploadChunkSize sets the size of the chunks blobs are uploaded in. Each chunk is sent with a separate PATCH
+// request, and an interrupted upload is resumed from the last chunk the registry received.
+func WithUploadChunkSize(size int64) ClientOption {
+	return func(c *Client) {
+		if size > 0 {
+			c.upload.chunkSize = size
+		}
+	}
+}
+
+// WithUploadConcurrency sets the number of blobs that are uploaded in parallel. The default is 1.
+func WithUploadConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.upload.concurrency = n
+		}
+	}
+}
+
+// WithUploadRetries sets the number of times an upload is resumed after an error before giving up. Zero disables
+// retries.
+func WithUploadRetries(n int) ClientOption {
+	return func(c *Client) {
+		if n >= 0 {
+			c.upload.retries = n
+		}
+	}
+}
+
+// uploader uploads blobs to a repository using the chunked upload protocol of the OCI distribution spec
+type uploader struct {
+	repo name.Repository
+	http *http.Client
+	opts uploadOptions
+}
+
+// newUploader returns an uploader for repo, authorized to push to it and to mount blobs from the given repositories
+func (c *Client) newUploader(ctx context.Context, repo name.Repository, mountFrom []name.Repository) (*uploader, error) {
+	auth, err := c.resolveAuth(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	scopes := []string{repo.Scope(transport.PushScope)}
+	for _, from := range mountFrom {
+		if from.RegistryStr() == repo.RegistryStr() {
+			scopes = append(scopes, from.Scope(transport.PullScope))
+		}
+	}
+	rt, err := transport.NewWithContext(ctx, repo.Registry, auth, transport.NewUserAgent(c.transport, c.userAgent), scopes)
+	if err != nil {
+		return nil, err
+	}
+	return &uploader{
+		repo: repo,
+		http: &http.Client{Transport: rt},
+		opts: c.upload,
+	}, nil
+}
+
+// uploadLayers uploads the layers, running up to the configured number of uploads in parallel. The first error
+// cancels the remaining uploads.
+func (u *uploader) uploadLayers(ctx context.Context, layers []v1.Layer, tracker *progress.Tracker) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, u.opts.concurrency)
+	for _, layer := range layers {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := u.uploadLayer(ctx, layer, tracker); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// uploadLayer uploads the layer, reporting its progress to the tracker. Chunks that fail are retried after asking the
+// registry how much of the upload it received.
+func (u *uploader) uploadLayer(ctx context.Context, layer v1.Layer, tracker *progress.Tracker) error {
+	digest, err := layer.Digest()
+	if err != nil {
+		return fmt.Errorf("getting layer digest: %w", err)
+	}
+	diffID, err := layer.DiffID()
+	if err != nil {
+		return fmt.Errorf("getting layer diffID: %w", err)
+	}
+	size, err := layer.Size()
+	if err != nil {
+		return fmt.Errorf("getting layer size: %w", err)
+	}
+
+	exists, err := u.blobExists(ctx, digest)
+	if err != nil {
+		return fmt.Errorf("checking for blob %s: %w", digest, err)
+	}
+	info := progress.Layer{ID: diffID.String(), Size: size}
+	if exists {
+		tracker.Skip(info)
+		return nil
+	}
+
+	lt := tracker.Start(info)
+	location, mounted, err := u.start(ctx, layer, digest)
+	if err != nil {
+		return fmt.Errorf("starting upload of blob %s: %w", digest, err)
+	}
+	if mounted {
+		lt.Update(size)
+		lt.Done()
+		return nil
+	}
+
+	var offset int64
+	for attempt := 0; ; attempt++ {
+		location, err = u.upload(ctx, layer, location, offset, size, lt)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil || attempt >= u.opts.retries {
+			return fmt.Errorf("uploading blob %s: %w", digest, err)
+		}
+		select {
+		case <-time.After(u.opts.backoff(attempt)):
+		case <-ctx.Done():
+			return fmt.Errorf("uploading blob %s: %w", digest, ctx.Err())
+		}
+		// Resume from what the registry received, or start over if it cannot tell
+		if offset, err = u.status(ctx, location); err != nil {
+			offset = 0
+			if location, mounted, err = u.start(ctx, layer, digest); err != nil {
+				return fmt.Errorf("restarting upload of blob %s: %w", digest, err)
+			}
+			if mounted {
+				lt.Update(size)
+				lt.Done()
+				return nil
+			}
+		}
+		lt.Update(offset)
+	}
+
+	if err := u.commit(ctx, location, digest); err != nil {
+		return fmt.Errorf("committing blob %s: %w", digest, err)
+	}
+	lt.Done()
+	return nil
+}
+
+// start starts an upload session and returns its location. Blobs of layers read from another repository on the same
+// registry are mounted instead, in which case mounted is true and there is no upload session.
+func (u *uploader) start(ctx context.Context, layer v1.Layer, digest v1.Hash) (location string, mounted bool, err error) {
+	query := url.Values{}
+	if ml, ok := layer.(*remote.MountableLayer); ok {
+		from := ml.Reference.Context()
+		// Docker Hub rejects mounts from other registries, so only ask it to mount from its own repositories
+		if u.repo.RegistryStr() != name.DefaultRegistry || from.RegistryStr() == u.repo.RegistryStr() {
+			query.Set("mount", digest.String())
+			query.Set("from", from.RepositoryStr())
+			query.Set("origin", from.RegistryStr())
+		}
+	}
+	uploadURL := u.url("/v2/" + u.repo.RepositoryStr() + "/blobs/uploads/")
+	uploadURL.RawQuery = query.Encode()
+
+	resp, err := u.do(ctx, http.MethodPost, uploadURL.String(), nil, nil, http.StatusCreated, http.StatusAccepted)
+	if err != nil && len(query) > 0 && ctx.Err() == nil {
+		// Some registries fail mount requests instead of falling back to an upload, so try again without mounting
+		uploadURL.RawQuery = ""
+		resp, err = u.do(ctx, http.MethodPost, uploadURL.String(), nil, nil, http.StatusCreated, http.StatusAccepted)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if resp.StatusCode == http.StatusCreated {
+		return "", true, nil
+	}
+	location, err = u.location(resp)
+	return location, false, err
+}
+
+// upload sends the layer contents from offset on in chunks and returns the location to continue the upload at
+func (u *uploader) upload(ctx context.Context, layer v1.Layer, location string, offset, size int64, lt *progress.LayerTracker) (string, error) {
+	if offset >= size {
+		return location, nil
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return location, fmt.Errorf("opening layer: %w", err)
+	}
+	defer rc.Close()
+	if err := skip(rc, offset); err != nil {
+		return location, fmt.Errorf("seeking to offset %d: %w", offset, err)
+	}
+
+	for offset < size {
+		n := min(u.opts.chunkSize, size-offset)
+		body := &countingReader{r: io.LimitReader(rc, n), onRead: func(read int64) { lt.Update(offset + read) }}
+		header := http.Header{
+			"Content-Type":   {"application/octet-stream"},
+			"Content-Range":  {fmt.Sprintf("%d-%d", offset, offset+n-1)},
+			"Content-Length": {strconv.FormatInt(n, 10)},
+		}
+		resp, err := u.do(ctx, http.MethodPatch, location, header, body, http.StatusAccepted, http.StatusNoContent)
+		if err != nil {
+			return location, err
+		}
+		if location, err = u.location(resp); err != nil {
+			return location, err
+		}
+		offset += n
+		if end, ok := rangeEnd(resp.Header.Get("Range")); ok && end+1 != offset {
+			return location, fmt.Errorf("registry received %d bytes, expected %d", end+1, offset)
+		}
+	}
+	return location, nil
+}
+
+// status asks the registry how many bytes of the upload at location it has received
+func (u *uploader) status(ctx context.Context, location string) (int64, error) {
+	resp, err := u.do(ctx, http.MethodGet, location, nil, nil, http.StatusNoContent)
+	if err != nil {
+		return 0, err
+	}
+	end, ok := rangeEnd(resp.Header.Get("Range"))
+	if !ok {
+		return 0, errors.New("registry did not report the upload offset")
+	}
+	// Registries report "0-0" both for empty uploads and for uploads of a single byte, so the offset is unknown
+	if end == 0 {
+		return 0, errors.New("registry reported an ambiguous upload offset")
+	}
+	return end + 1, nil
+}
+
+// commit completes the upload at location
+func (u *uploader) commit(ctx context.Context, location string, digest v1.Hash) error {
+	commitURL, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	query := commitURL.Query()
+	query.Set("digest", digest.String())
+	commitURL.RawQuery = query.Encode()
+	_, err = u.do(ctx, http.MethodPut, commitURL.String(), http.Header{"Content-Length": {"0"}}, nil, http.StatusCreated)
+	return err
+}
+
+// do sends a request and checks that the response has one of the expected status codes. The response body is
+// drained and closed.
+func (u *uploader) do(ctx context.Context, method, target string, header http.Header, body io.Reader, codes ...int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if cl := header.Get("Content-Length"); cl != "" {
+		req.ContentLength, _ = strconv.ParseInt(cl, 10, 64)
+	}
+	resp, err := u.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := transport.CheckError(resp, codes...); err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp, nil
+}
+
+// url returns the URL of the given path on the registry
+func (u *uploader) url(path string) *url.URL {
+	return &url.URL{
+		Scheme: u.repo.Registry.Scheme(),
+		Host:   u.repo.RegistryStr(),
+		Path:   path,
+	}
+}
+
+// location resolves the Location header of the response against the registry
+func (u *uploader) location(resp *http.Response) (string, error) {
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return "", errors.New("registry did not return an upload location")
+	}
+	ref, err := url.Parse(loc)
+	if err != nil {
+		return "", fmt.Errorf("parsing upload location: %w", err)
+	}
+	return u.url("/").ResolveReference(ref).String(), nil
+}
+
+// rangeEnd parses the end offset of a Range header of the form "0-<end>"
+func rangeEnd(header string) (int64, bool) {
+	_, end, ok := strings.Cut(strings.TrimPrefix(header, "bytes="), "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(end, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// skip discards the first n bytes of r, seeking if r supports it
+func skip(r io.Reader, n int64) error {
+	if n == 0 {
+		return nil
+	}
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+// countingReader calls onRead with the total number of bytes read after every read
+type countingReader struct {
+	r      io.Reader
+	n      int64
+	onRead func(int64)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if n > 0 {
+		c.onRead(c.n)
+	}
+	return n, err
+}
@@ -0,0 +1,181 @@
+// Package signing creates and verifies detached signatures over model manifest digests.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/internal/keys"
+)
+
+const (
+	// AlgorithmEd25519 signs the payload with an ed25519 key
+	AlgorithmEd25519 = "ed25519"
+	// AlgorithmECDSASHA256 signs the SHA-256 hash of the payload with an ECDSA key, encoding the signature in ASN.1
+	AlgorithmECDSASHA256 = "ecdsa-sha256"
+)
+
+var (
+	ErrNoSignature      = errors.New("no signature found")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrUntrustedKey     = errors.New("signature is not from a trusted key")
+	ErrUnsupportedKey   = errors.New("unsupported key type: must be ed25519 or ECDSA")
+)
+
+// Signer signs payloads. Implementations backed by a KMS or a hardware token can be plugged in instead of the key
+// file based signers returned by LoadSigner and NewSigner.
+type Signer interface {
+	// Sign returns the signature over payload. ed25519 keys sign the payload itself, ECDSA keys sign its SHA-256
+	// hash and return an ASN.1 encoded signature.
+	Sign(ctx context.Context, payload []byte) ([]byte, error)
+	// PublicKey returns the ed25519.PublicKey or *ecdsa.PublicKey that verifies the signatures
+	PublicKey() crypto.PublicKey
+}
+
+// Signature is a detached signature over the digest of a model manifest
+type Signature struct {
+	// Digest is the signed manifest digest
+	Digest string `json:"digest"`
+	// Algorithm is AlgorithmEd25519 or AlgorithmECDSASHA256
+	Algorithm string `json:"algorithm"`
+	// KeyID identifies the public key that verifies the signature, see KeyID
+	KeyID string `json:"keyId"`
+	// Signature is the signature over the digest string
+	Signature []byte `json:"signature"`
+}
+
+// Sign signs the manifest digest with signer
+func Sign(ctx context.Context, signer Signer, digest v1.Hash) (*Signature, error) {
+	pub := signer.PublicKey()
+	algorithm, err := algorithmFor(pub)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := KeyID(pub)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(ctx, []byte(digest.String()))
+	if err != nil {
+		return nil, fmt.Errorf("signing %s: %w", digest, err)
+	}
+	return &Signature{
+		Digest:    digest.String(),
+		Algorithm: algorithm,
+		KeyID:     keyID,
+		Signature: sig,
+	}, nil
+}
+
+// Verify checks that the signature is a valid signature over digest by one of keys. It returns ErrUntrustedKey if
+// none of the keys has the signature's key ID and ErrInvalidSignature if the signature by a trusted key does not
+// verify or is over another digest.
+func (s *Signature) Verify(digest v1.Hash, keys []crypto.PublicKey) error {
+	for _, key := range keys {
+		keyID, err := KeyID(key)
+		if err != nil {
+			return err
+		}
+		if keyID != s.KeyID {
+			continue
+		}
+		if s.Digest != digest.String() {
+			return fmt.Errorf("%w: signed digest %s does not match %s", ErrInvalidSignature, s.Digest, digest)
+		}
+		if !verify(key, []byte(s.Digest), s.Signature) {
+			return fmt.Errorf("%w: signature by key %s does not verify", ErrInvalidSignature, s.KeyID)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUntrustedKey, s.KeyID)
+}
+
+// KeyID returns the identifier of a public key, the SHA-256 digest of its PKIX encoding
+func KeyID(pub crypto.PublicKey) (string, error) {
+	if _, err := algorithmFor(pub); err != nil {
+		return "", err
+	}
+	return keys.ID(pub)
+}
+
+func algorithmFor(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case ed25519.PublicKey:
+		return AlgorithmEd25519, nil
+	case *ecdsa.PublicKey:
+		return AlgorithmECDSASHA256, nil
+	default:
+		return "", fmt.Errorf("%w: %T", ErrUnsupportedKey, pub)
+	}
+}
+
+func verify(pub crypto.PublicKey, payload, sig []byte) bool {
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, sig)
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(payload)
+		return ecdsa.VerifyASN1(key, sum[:], sig)
+	default:
+		return false
+	}
+}
+
+// keySigner signs payloads with a private key held in memory
+type keySigner struct {
+	key crypto.Signer
+}
+
+// NewSigner returns a Signer for an ed25519.PrivateKey or an *ecdsa.PrivateKey
+func NewSigner(key crypto.Signer) (Signer, error) {
+	if _, err := algorithmFor(key.Public()); err != nil {
+		return nil, err
+	}
+	return &keySigner{key: key}, nil
+}
+
+func (s *keySigner) Sign(_ context.Context, payload []byte) ([]byte, error) {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		return s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	sum := sha256.Sum256(payload)
+	return s.key.Sign(rand.Reader, sum[:], crypto.SHA256)
+}
+
+func (s *keySigner) PublicKey() crypto.PublicKey {
+	return s.key.Public()
+}
+
+// LoadSigner returns a Signer for the PEM encoded ed25519 or ECDSA private key in the file at path. Both PKCS #8
+// ("PRIVATE KEY") and SEC 1 ("EC PRIVATE KEY") encodings are supported.
+func LoadSigner(path string) (Signer, error) {
+	key, err := keys.LoadPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+	}
+	return NewSigner(signer)
+}
+
+// LoadPublicKey returns the PEM encoded ed25519 or ECDSA public key ("PUBLIC KEY") in the file at path
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	pub, err := keys.LoadPublicKey(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := algorithmFor(pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
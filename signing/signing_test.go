@@ -0,0 +1,119 @@
+package signing_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/docker/model-distribution/signing"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	return path
+}
+
+func TestSignAndVerify(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("Failed to encode ed25519 key: %v", err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to encode ECDSA key: %v", err)
+	}
+
+	digest, err := v1.NewHash("sha256:0000000000000000000000000000000000000000000000000000000000000001")
+	if err != nil {
+		t.Fatalf("Failed to parse digest: %v", err)
+	}
+	other, err := v1.NewHash("sha256:0000000000000000000000000000000000000000000000000000000000000002")
+	if err != nil {
+		t.Fatalf("Failed to parse digest: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		keyFile   string
+		pub       crypto.PublicKey
+		algorithm string
+	}{
+		{name: "ed25519 PKCS #8", keyFile: writePEM(t, "PRIVATE KEY", edDER), pub: edKey.Public(), algorithm: signing.AlgorithmEd25519},
+		{name: "ECDSA SEC 1", keyFile: writePEM(t, "EC PRIVATE KEY", ecDER), pub: &ecKey.PublicKey, algorithm: signing.AlgorithmECDSASHA256},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := signing.LoadSigner(tc.keyFile)
+			if err != nil {
+				t.Fatalf("Failed to load signer: %v", err)
+			}
+			pubDER, err := x509.MarshalPKIXPublicKey(tc.pub)
+			if err != nil {
+				t.Fatalf("Failed to encode public key: %v", err)
+			}
+			pub, err := signing.LoadPublicKey(writePEM(t, "PUBLIC KEY", pubDER))
+			if err != nil {
+				t.Fatalf("Failed to load public key: %v", err)
+			}
+
+			sig, err := signing.Sign(t.Context(), signer, digest)
+			if err != nil {
+				t.Fatalf("Failed to sign: %v", err)
+			}
+			if sig.Algorithm != tc.algorithm {
+				t.Fatalf("Expected algorithm %q, got %q", tc.algorithm, sig.Algorithm)
+			}
+			if err := sig.Verify(digest, []crypto.PublicKey{pub}); err != nil {
+				t.Fatalf("Failed to verify signature: %v", err)
+			}
+
+			_, unknown, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatalf("Failed to generate key: %v", err)
+			}
+			if err := sig.Verify(digest, []crypto.PublicKey{unknown.Public()}); !errors.Is(err, signing.ErrUntrustedKey) {
+				t.Fatalf("Expected %v, got %v", signing.ErrUntrustedKey, err)
+			}
+			if err := sig.Verify(other, []crypto.PublicKey{pub}); !errors.Is(err, signing.ErrInvalidSignature) {
+				t.Fatalf("Expected %v for other digest, got %v", signing.ErrInvalidSignature, err)
+			}
+			if err := sig.Verify(other, []crypto.PublicKey{unknown.Public()}); !errors.Is(err, signing.ErrUntrustedKey) {
+				t.Fatalf("Expected %v for other digest by untrusted key, got %v", signing.ErrUntrustedKey, err)
+			}
+
+			tampered := *sig
+			tampered.Signature = append([]byte{}, sig.Signature...)
+			tampered.Signature[len(tampered.Signature)-1] ^= 0xff
+			if err := tampered.Verify(digest, []crypto.PublicKey{pub}); !errors.Is(err, signing.ErrInvalidSignature) {
+				t.Fatalf("Expected %v for tampered signature, got %v", signing.ErrInvalidSignature, err)
+			}
+		})
+	}
+
+	t.Run("invalid key file", func(t *testing.T) {
+		if _, err := signing.LoadSigner(writePEM(t, "CERTIFICATE", []byte("not a key"))); err == nil {
+			t.Fatalf("Expected error for invalid key file")
+		}
+	})
+}
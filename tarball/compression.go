@@ -0,0 +1,70 @@
+package tarball
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/compression"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// WithCompression compresses the archive with gzip or zstd. Readers detect the compression automatically.
+func WithCompression(c compression.Compression) TargetOption {
+	return func(t *Target) {
+		t.compression = c
+	}
+}
+
+// compress returns a writer that compresses to w with c, and a function that flushes and closes it
+func compress(w io.Writer, c compression.Compression) (io.Writer, func() error, error) {
+	switch c {
+	case "", compression.None:
+		return w, func() error { return nil }, nil
+	case compression.GZip:
+		zw := gzip.NewWriter(w)
+		return zw, zw.Close, nil
+	case compression.ZStd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create zstd writer: %w", err)
+		}
+		return zw, zw.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported compression %q", c)
+	}
+}
+
+// decompress returns a reader for the decompressed contents of r if r starts with the magic bytes of gzip or zstd, or
+// for r itself otherwise. The returned function releases the resources of the decompressor.
+func decompress(r io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read gzip header: %w", err)
+		}
+		return zr, func() { zr.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		// Without concurrency the stream is decoded synchronously as the archive is read
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("create zstd reader: %w", err)
+		}
+		return zr, zr.Close, nil
+	default:
+		return br, func() {}, nil
+	}
+}
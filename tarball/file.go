@@ -6,31 +6,60 @@ import (
 	"io"
 	"os"
 
+	"github.com/docker/model-distribution/progress"
 	"github.com/docker/model-distribution/types"
 )
 
 // FileTarget writes an artifact tarball to a local file.
 type FileTarget struct {
 	path string
+	opts []TargetOption
 }
 
-// NewFileTarget returns a *FileTarget for the given path.
-func NewFileTarget(path string) *FileTarget {
+// NewFileTarget returns a *FileTarget for the given path. The options configure the archive as for NewTarget.
+func NewFileTarget(path string, opts ...TargetOption) *FileTarget {
 	return &FileTarget{
 		path: path,
+		opts: opts,
 	}
 }
 
-// Write writes the given artifact to the target.
+// Write writes the given artifact to the target. Completion is only reported once the file has been closed. If the
+// artifact cannot be written, the incomplete file is removed.
 func (t *FileTarget) Write(ctx context.Context, mdl types.ModelArtifact, pw io.Writer) error {
+	return reportSave(pw, t.path, func(pw progress.Writer) error {
+		return t.write(func(target *Target) error {
+			return target.write(ctx, []Model{{Artifact: mdl, Tags: target.refNames}}, pw)
+		})
+	})
+}
+
+// WriteModels writes several models with their tags to the file, see Target.WriteModels.
+func (t *FileTarget) WriteModels(ctx context.Context, models []Model, pw io.Writer) error {
+	return reportSave(pw, t.path, func(pw progress.Writer) error {
+		return t.write(func(target *Target) error {
+			return target.write(ctx, models, pw)
+		})
+	})
+}
+
+// write creates the file and writes the archive to it with write, removing the file if write fails
+func (t *FileTarget) write(write func(*Target) error) (err error) {
 	f, err := os.Create(t.path)
 	if err != nil {
 		return fmt.Errorf("create file for archive: %w", err)
 	}
-	defer f.Close()
-	target, err := NewTarget(f)
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close archive: %w", closeErr)
+		}
+		if err != nil {
+			os.Remove(t.path)
+		}
+	}()
+	target, err := NewTarget(f, t.opts...)
 	if err != nil {
 		return fmt.Errorf("create target: %w", err)
 	}
-	return target.Write(ctx, mdl, pw)
+	return write(target)
 }
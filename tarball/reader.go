@@ -2,20 +2,114 @@ package tarball
 
 import (
 	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"path/filepath"
 	"strings"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/docker/model-distribution/oci"
+)
+
+const (
+	// DefaultMaxEntries is the default limit on the number of entries in an archive
+	DefaultMaxEntries = 10000
+	// DefaultMaxMetadataSize is the default limit on the size of manifest.json, index.json and manifest blobs. Blobs up
+	// to this size are read into memory to check whether they are manifests.
+	DefaultMaxMetadataSize = 4 << 20
+)
+
+var (
+	ErrInvalidArchive = errors.New("invalid archive")
+	ErrLimitExceeded  = errors.New("archive limit exceeded")
 )
 
+// hexLengths are the lengths of the hex encoded digests of the supported digest algorithms
+var hexLengths = map[string]int{
+	"sha256": 64,
+	"sha512": 128,
+}
+
+// Reader reads the blobs and the manifest of a model archive. Both the archives written by Target and OCI image
+// layout archives (with an index.json, e.g. from skopeo's oci-archive transport) are supported, uncompressed or
+// compressed with gzip or zstd. Manifest blobs listed in the index.json of OCI image layouts are not returned by Next.
+//
+// Archives are validated as they are read: blob names must be digests of a supported algorithm, entries must not be
+// duplicated, blob contents must match their digests and the blobs referenced by the manifests must be in the
+// archive. Errors for malformed archives wrap ErrInvalidArchive, errors for archives exceeding the reader's limits wrap
+// ErrLimitExceeded.
 type Reader struct {
-	tr          *tar.Reader
-	rawManifest []byte
-	digest      v1.Hash
-	done        bool
+	src     io.Reader
+	release func()
+	tr      *tar.Reader
+	cur     io.Reader
+	err     error
+
+	rawManifest       []byte
+	digest            v1.Hash
+	index             *v1.IndexManifest
+	manifests         map[v1.Hash][]byte
+	pending           []pendingBlob
+	pendingSize       int64
+	seen              map[v1.Hash]struct{}
+	blobAfterManifest bool
+	eof               bool
+	done              bool
+	size              int64
+
+	entries         int
+	total           int64
+	maxEntries      int
+	maxBlobSize     int64
+	maxTotalSize    int64
+	maxMetadataSize int64
+}
+
+// ReaderOption configures a Reader
+type ReaderOption func(*Reader)
+
+// WithMaxEntries limits the number of entries in the archive, including directories and ignored files. It defaults to
+// DefaultMaxEntries, n <= 0 removes the limit.
+func WithMaxEntries(n int) ReaderOption {
+	return func(r *Reader) {
+		r.maxEntries = n
+	}
+}
+
+// WithMaxBlobSize limits the size of each blob in the archive. Blobs are not limited by default, n <= 0 removes the
+// limit.
+func WithMaxBlobSize(n int64) ReaderOption {
+	return func(r *Reader) {
+		r.maxBlobSize = n
+	}
+}
+
+// WithMaxTotalSize limits the total size of the entries in the archive, after decompression. The total size is not
+// limited by default, n <= 0 removes the limit.
+func WithMaxTotalSize(n int64) ReaderOption {
+	return func(r *Reader) {
+		r.maxTotalSize = n
+	}
+}
+
+// WithMaxMetadataSize limits the size of manifest.json, index.json and manifest blobs, and the combined size of the
+// blobs that look like manifests and are read before index.json. It defaults to DefaultMaxMetadataSize, n <= 0 is
+// ignored.
+func WithMaxMetadataSize(n int64) ReaderOption {
+	return func(r *Reader) {
+		if n > 0 {
+			r.maxMetadataSize = n
+		}
+	}
 }
 
 type Blob struct {
@@ -31,63 +125,390 @@ func (b Blob) Uncompressed() (io.ReadCloser, error) {
 	return b.rc, nil
 }
 
+// Next advances to the next blob in the archive and returns its digest. The blob's contents are read with Read, and
+// Read fails at the end of the blob if the contents do not match the digest. Next returns io.EOF at the end of the
+// archive. Once Next has returned an error, it returns the same error on every call.
 func (r *Reader) Next() (v1.Hash, error) {
+	if r.err != nil {
+		return v1.Hash{}, r.err
+	}
+	if r.tr == nil {
+		dr, release, err := decompress(r.src)
+		if err != nil {
+			r.err = err
+			return v1.Hash{}, err
+		}
+		r.tr = tar.NewReader(dr)
+		r.release = release
+	}
+	hash, err := r.next()
+	if err != nil {
+		if err == io.EOF {
+			r.done = true
+		}
+		r.err = err
+		r.cur = nil
+		r.release()
+	}
+	return hash, err
+}
+
+func (r *Reader) next() (v1.Hash, error) {
 	for {
+		if r.eof {
+			return r.nextPending()
+		}
 		hdr, err := r.tr.Next()
+		if err == io.EOF {
+			r.eof = true
+			r.resolvePending()
+			continue
+		}
 		if err != nil {
-			if err == io.EOF {
-				r.done = true
-			}
 			return v1.Hash{}, err
 		}
-		//fi := hdr.FileInfo()
-		if !(hdr.Typeflag == tar.TypeReg) {
+		r.entries++
+		if r.maxEntries > 0 && r.entries > r.maxEntries {
+			return v1.Hash{}, fmt.Errorf("%w: more than %d entries", ErrLimitExceeded, r.maxEntries)
+		}
+		// Entries that are skipped are read through as well, so they count towards the total size
+		r.total += hdr.Size
+		if r.maxTotalSize > 0 && r.total > r.maxTotalSize {
+			return v1.Hash{}, fmt.Errorf("%w: files larger than %d bytes in total", ErrLimitExceeded, r.maxTotalSize)
+		}
+		if hdr.Typeflag != tar.TypeReg {
 			continue
 		}
-		if hdr.Name == "manifest.json" {
-			// save the manifest
-			hasher, err := v1.Hasher("sha256")
+		name := filepath.Clean(hdr.Name)
+		if name == "index.json" {
+			if r.index != nil {
+				return v1.Hash{}, fmt.Errorf("%w: duplicate index.json", ErrInvalidArchive)
+			}
+			raw, err := r.readMetadata(hdr)
 			if err != nil {
 				return v1.Hash{}, err
 			}
-			rm, err := io.ReadAll(io.TeeReader(r.tr, hasher))
+			var index v1.IndexManifest
+			if err := json.Unmarshal(raw, &index); err != nil {
+				return v1.Hash{}, fmt.Errorf("%w: parse index.json: %v", ErrInvalidArchive, err)
+			}
+			r.index = &index
+			continue
+		}
+		if name == "manifest.json" {
+			if r.rawManifest != nil {
+				return v1.Hash{}, fmt.Errorf("%w: duplicate manifest.json", ErrInvalidArchive)
+			}
+			rm, err := r.readMetadata(hdr)
 			if err != nil {
 				return v1.Hash{}, err
 			}
+			sum := sha256.Sum256(rm)
 			r.rawManifest = rm
 			r.digest = v1.Hash{
 				Algorithm: "sha256",
-				Hex:       hex.EncodeToString(hasher.Sum(make([]byte, 0, hasher.Size()))),
+				Hex:       hex.EncodeToString(sum[:]),
 			}
 			continue
 		}
-		parts := strings.Split(filepath.Clean(hdr.Name), "/")
+		parts := strings.Split(name, "/")
 		if len(parts) != 3 || parts[0] != "blobs" && parts[0] != "manifests" {
 			continue
 		}
-		return v1.Hash{
-			Algorithm: parts[1],
-			Hex:       parts[2],
-		}, nil
+		hash, err := parseDigest(parts[1], parts[2])
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("%w: entry %q: %v", ErrInvalidArchive, hdr.Name, err)
+		}
+		if _, ok := r.seen[hash]; ok {
+			return v1.Hash{}, fmt.Errorf("%w: duplicate blob %s", ErrInvalidArchive, hash)
+		}
+		if r.seen == nil {
+			r.seen = make(map[v1.Hash]struct{})
+		}
+		r.seen[hash] = struct{}{}
+		if r.rawManifest != nil {
+			r.blobAfterManifest = true
+		}
+		if r.maxBlobSize > 0 && hdr.Size > r.maxBlobSize {
+			return v1.Hash{}, fmt.Errorf("%w: blob %s is larger than %d bytes", ErrLimitExceeded, hash, r.maxBlobSize)
+		}
+
+		r.size = hdr.Size
+		if hdr.Size > r.maxMetadataSize {
+			r.cur = &verifyingReader{r: r.tr, hasher: newHasher(hash.Algorithm), digest: hash}
+			return hash, nil
+		}
+		contents, err := io.ReadAll(r.tr)
+		if err != nil {
+			return v1.Hash{}, err
+		}
+		if err := verify(contents, hash); err != nil {
+			return v1.Hash{}, err
+		}
+		// Blobs are only manifests if index.json lists them. Blobs that look like manifests are held back until
+		// index.json has been read.
+		if r.index != nil && r.listed(hash) {
+			r.addManifest(hash, contents)
+			continue
+		}
+		if r.index == nil && isManifest(contents) {
+			r.pendingSize += int64(len(contents))
+			if r.pendingSize > r.maxMetadataSize {
+				return v1.Hash{}, fmt.Errorf("%w: blobs that look like manifests are larger than %d bytes in total",
+					ErrLimitExceeded, r.maxMetadataSize)
+			}
+			r.pending = append(r.pending, pendingBlob{hash: hash, contents: contents})
+			continue
+		}
+		r.cur = bytes.NewReader(contents)
+		return hash, nil
 	}
 }
 
+// pendingBlob is a blob that looks like a manifest, held back until it is known whether index.json lists it
+type pendingBlob struct {
+	hash     v1.Hash
+	contents []byte
+}
+
+// resolvePending keeps the held back blobs listed in index.json as manifests. The others are returned by Next.
+func (r *Reader) resolvePending() {
+	var blobs []pendingBlob
+	for _, b := range r.pending {
+		if r.index != nil && r.listed(b.hash) {
+			r.addManifest(b.hash, b.contents)
+			continue
+		}
+		blobs = append(blobs, b)
+	}
+	r.pending = blobs
+}
+
+// nextPending advances to the next held back blob that is not a manifest, or returns io.EOF if there is none
+func (r *Reader) nextPending() (v1.Hash, error) {
+	if len(r.pending) == 0 {
+		return v1.Hash{}, io.EOF
+	}
+	b := r.pending[0]
+	r.pending = r.pending[1:]
+	r.size = int64(len(b.contents))
+	r.cur = bytes.NewReader(b.contents)
+	return b.hash, nil
+}
+
+// listed returns true if index.json lists the manifest with the given digest
+func (r *Reader) listed(digest v1.Hash) bool {
+	for _, desc := range r.index.Manifests {
+		if desc.Digest == digest {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reader) addManifest(digest v1.Hash, contents []byte) {
+	if r.manifests == nil {
+		r.manifests = make(map[v1.Hash][]byte)
+	}
+	r.manifests[digest] = contents
+}
+
+// readMetadata returns the contents of the metadata file with the given header
+func (r *Reader) readMetadata(hdr *tar.Header) ([]byte, error) {
+	if hdr.Size > r.maxMetadataSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrLimitExceeded, hdr.Name, r.maxMetadataSize)
+	}
+	return io.ReadAll(r.tr)
+}
+
+// parseDigest returns the digest of a blob stored as blobs/<algorithm>/<hex>
+func parseDigest(algorithm, encoded string) (v1.Hash, error) {
+	length, ok := hexLengths[algorithm]
+	if !ok {
+		return v1.Hash{}, fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+	if len(encoded) != length || strings.Trim(encoded, "0123456789abcdef") != "" {
+		return v1.Hash{}, fmt.Errorf("invalid %s digest %q", algorithm, encoded)
+	}
+	return v1.Hash{Algorithm: algorithm, Hex: encoded}, nil
+}
+
+func newHasher(algorithm string) hash.Hash {
+	if algorithm == "sha512" {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// verify checks that contents match digest
+func verify(contents []byte, digest v1.Hash) error {
+	hasher := newHasher(digest.Algorithm)
+	hasher.Write(contents)
+	if hex.EncodeToString(hasher.Sum(nil)) != digest.Hex {
+		return fmt.Errorf("%w: blob %s does not match its digest", ErrInvalidArchive, digest)
+	}
+	return nil
+}
+
+// verifyingReader reads a blob, failing at its end if the contents do not match its digest
+type verifyingReader struct {
+	r      io.Reader
+	hasher hash.Hash
+	digest v1.Hash
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hasher.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(v.hasher.Sum(nil)) != v.digest.Hex {
+		return n, fmt.Errorf("%w: blob %s does not match its digest", ErrInvalidArchive, v.digest)
+	}
+	return n, err
+}
+
+// isManifest returns true if contents looks like an image manifest or index, as stored in the blobs of OCI image
+// layouts
+func isManifest(contents []byte) bool {
+	if len(contents) == 0 || contents[0] != '{' {
+		return false
+	}
+	var m struct {
+		MediaType ggcrtypes.MediaType `json:"mediaType"`
+		Config    json.RawMessage     `json:"config"`
+		Layers    json.RawMessage     `json:"layers"`
+		Manifests json.RawMessage     `json:"manifests"`
+	}
+	if err := json.Unmarshal(contents, &m); err != nil {
+		return false
+	}
+	if m.MediaType != "" {
+		return m.MediaType.IsImage() || m.MediaType.IsIndex()
+	}
+	return m.Config != nil && m.Layers != nil || m.Manifests != nil
+}
+
+// Size returns the size of the blob returned by the last call to Next, as recorded in its tar header.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
 func (r *Reader) Read(p []byte) (n int, err error) {
-	return r.tr.Read(p)
+	if r.cur == nil {
+		return 0, io.EOF
+	}
+	return r.cur.Read(p)
 }
 
+// Manifest is the manifest of a model in an archive
+type Manifest struct {
+	Raw    []byte
+	Digest v1.Hash
+	// Tags are the ref names under which the index.json of an OCI image layout archive lists the model
+	Tags []string
+}
+
+// Manifest returns the raw manifest of the model in the archive and its digest. It must be called after Next has
+// returned io.EOF. It fails if the archive holds several models, see Manifests.
 func (r *Reader) Manifest() ([]byte, v1.Hash, error) {
+	manifests, err := r.Manifests()
+	if err != nil {
+		return nil, v1.Hash{}, err
+	}
+	if len(manifests) > 1 {
+		return nil, v1.Hash{}, fmt.Errorf("archive holds %d models", len(manifests))
+	}
+	return manifests[0].Raw, manifests[0].Digest, nil
+}
+
+// Manifests returns the manifests of the models in the archive with their tags, in the order of the archive's
+// index.json. It must be called after Next has returned io.EOF. It fails if a manifest references a blob that is not
+// in the archive.
+func (r *Reader) Manifests() ([]Manifest, error) {
 	if !r.done {
-		return nil, v1.Hash{}, errors.New("must read all blobs first before getting manifest")
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, errors.New("must read all blobs first before getting manifest")
+	}
+	var manifests []Manifest
+	if r.index != nil {
+		var err error
+		if manifests, err = r.indexManifests(); err != nil {
+			return nil, err
+		}
+	} else {
+		if r.rawManifest == nil {
+			return nil, fmt.Errorf("%w: manifest not found", ErrInvalidArchive)
+		}
+		if r.blobAfterManifest {
+			return nil, fmt.Errorf("%w: blobs after manifest.json", ErrInvalidArchive)
+		}
+		manifests = []Manifest{{Raw: r.rawManifest, Digest: r.digest}}
 	}
-	if r.done && r.rawManifest == nil {
-		return nil, v1.Hash{}, errors.New("manifest not found")
+	for _, m := range manifests {
+		if err := r.checkBlobs(m); err != nil {
+			return nil, err
+		}
 	}
-	return r.rawManifest, r.digest, nil
+	return manifests, nil
 }
 
-func NewReader(r io.Reader) *Reader {
-	return &Reader{
-		tr: tar.NewReader(r),
+// checkBlobs checks that the config and the layers referenced by the manifest are in the archive
+func (r *Reader) checkBlobs(m Manifest) error {
+	manifest, err := v1.ParseManifest(bytes.NewReader(m.Raw))
+	if err != nil {
+		return fmt.Errorf("%w: parse manifest %s: %v", ErrInvalidArchive, m.Digest, err)
+	}
+	for _, desc := range append([]v1.Descriptor{manifest.Config}, manifest.Layers...) {
+		if _, ok := r.seen[desc.Digest]; !ok {
+			return fmt.Errorf("%w: manifest %s references blob %s that is not in the archive", ErrInvalidArchive,
+				m.Digest, desc.Digest)
+		}
+	}
+	return nil
+}
+
+// indexManifests returns the manifests listed in the archive's index.json
+func (r *Reader) indexManifests() ([]Manifest, error) {
+	var manifests []Manifest
+	positions := make(map[v1.Hash]int)
+	for _, desc := range r.index.Manifests {
+		if !desc.MediaType.IsImage() {
+			continue
+		}
+		i, ok := positions[desc.Digest]
+		if !ok {
+			// Manifest blobs were checked against their digests when they were read
+			raw, ok := r.manifests[desc.Digest]
+			if !ok {
+				return nil, fmt.Errorf("%w: manifest %s not found in archive", ErrInvalidArchive, desc.Digest)
+			}
+			i = len(manifests)
+			positions[desc.Digest] = i
+			manifests = append(manifests, Manifest{Raw: raw, Digest: desc.Digest})
+		}
+		if tag, ok := desc.Annotations[oci.AnnotationRefName]; ok {
+			manifests[i].Tags = append(manifests[i].Tags, tag)
+		}
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("%w: index.json does not list a model manifest", ErrInvalidArchive)
+	}
+	return manifests, nil
+}
+
+// NewReader returns a *Reader for the archive in r, validated within the limits set by opts. The compression of the
+// archive is detected on the first call to Next.
+func NewReader(r io.Reader, opts ...ReaderOption) *Reader {
+	reader := &Reader{
+		src:             r,
+		maxEntries:      DefaultMaxEntries,
+		maxMetadataSize: DefaultMaxMetadataSize,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(reader)
+		}
 	}
+	return reader
 }
@@ -1,14 +1,27 @@
 package tarball_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/compression"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
 
+	"github.com/docker/model-distribution/internal/gguf"
 	"github.com/docker/model-distribution/tarball"
+	"github.com/docker/model-distribution/types"
 )
 
 func TestStream(t *testing.T) {
@@ -34,14 +47,21 @@ func TestStream(t *testing.T) {
 	}
 
 	// Read manifest
+	expectedManifest, err := os.ReadFile(filepath.Join("testdata", "archive", "manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
 	rawManifest, digest, err := r.Manifest()
-	if string(rawManifest) != "some-manifest-contents" {
-		t.Errorf("Unexpected manifest contents: got %q expected %q", string(rawManifest), "some-manifest-contents")
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if string(rawManifest) != string(expectedManifest) {
+		t.Errorf("Unexpected manifest contents: got %q expected %q", string(rawManifest), string(expectedManifest))
 	}
 	if digest.Algorithm != "sha256" {
 		t.Errorf("Unexpected digest algorithm: %s", digest.Algorithm)
 	}
-	if digest.Hex != "a069ed344ddcd0ce7091471826d225dd080ccb53a4483c3d0364c16c63508955" {
+	if digest.Hex != "075f4d3533cab7742d5dc049198dda847d1b212562627779a06595c532cafa95" {
 		t.Errorf("Unexpected digest: %s", digest.Algorithm)
 	}
 }
@@ -65,3 +85,383 @@ func assertNextBlob(t *testing.T, r *tarball.Reader, expectedDiffID v1.Hash, exp
 		t.Fatalf("Expected blob contents %q but got %q", expectedContents, string(contents))
 	}
 }
+
+func TestCompressedArchive(t *testing.T) {
+	mdl, err := gguf.NewModel(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+	for _, tc := range []struct {
+		compression compression.Compression
+		magic       []byte
+	}{
+		{compression.None, nil},
+		{compression.GZip, []byte{0x1f, 0x8b}},
+		{compression.ZStd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	} {
+		t.Run(string(tc.compression), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "model.tar")
+			target := tarball.NewFileTarget(path, tarball.WithCompression(tc.compression))
+			if err := target.Write(t.Context(), mdl, nil); err != nil {
+				t.Fatalf("Failed to write archive: %v", err)
+			}
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read archive: %v", err)
+			}
+			if !bytes.HasPrefix(contents, tc.magic) {
+				t.Fatalf("Expected archive to start with %x, got %x", tc.magic, contents[:4])
+			}
+
+			r := tarball.NewReader(bytes.NewReader(contents))
+			var blobs int
+			for {
+				if _, err := r.Next(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("Failed to read blob: %v", err)
+				}
+				blobs++
+			}
+			if blobs != 2 {
+				t.Fatalf("Expected 2 blobs, got %d", blobs)
+			}
+			if _, d, err := r.Manifest(); err != nil || d != digest {
+				t.Fatalf("Expected manifest %s, got %s (%v)", digest, d, err)
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		if _, err := tarball.NewTarget(io.Discard, tarball.WithCompression("lz4")); err == nil {
+			t.Fatalf("Expected unsupported compression to fail")
+		}
+	})
+}
+
+// entry is a file in a test archive
+type entry struct {
+	name     string
+	contents string
+}
+
+func sha256Hex(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+// testManifest returns a manifest with the given config and layer contents
+func testManifest(config string, layers ...string) string {
+	manifest := v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     ggcrtypes.OCIManifestSchema1,
+		Config: v1.Descriptor{
+			MediaType: types.MediaTypeModelConfigV01,
+			Size:      int64(len(config)),
+			Digest:    v1.Hash{Algorithm: "sha256", Hex: sha256Hex(config)},
+		},
+	}
+	for _, layer := range layers {
+		manifest.Layers = append(manifest.Layers, v1.Descriptor{
+			MediaType: types.MediaTypeGGUF,
+			Size:      int64(len(layer)),
+			Digest:    v1.Hash{Algorithm: "sha256", Hex: sha256Hex(layer)},
+		})
+	}
+	raw, _ := json.Marshal(manifest)
+	return string(raw)
+}
+
+func blob(contents string) entry {
+	return entry{name: "blobs/sha256/" + sha256Hex(contents), contents: contents}
+}
+
+func buildArchive(t testing.TB, entries ...entry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.contents))}); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(e.contents)); err != nil {
+			t.Fatalf("Failed to write contents: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// readArchive reads every blob and the manifests of the archive
+func readArchive(r *tarball.Reader) ([]tarball.Manifest, error) {
+	for {
+		if _, err := r.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, err
+		}
+	}
+	return r.Manifests()
+}
+
+func TestReaderValidation(t *testing.T) {
+	const config, layer = `{"config":{}}`, "some-layer-contents"
+	manifest := testManifest(config, layer)
+	large := strings.Repeat("x", 64)
+
+	for _, tc := range []struct {
+		name     string
+		entries  []entry
+		opts     []tarball.ReaderOption
+		expected error
+	}{
+		{
+			name:    "valid",
+			entries: []entry{blob(layer), blob(config), {"manifest.json", manifest}},
+		},
+		{
+			name:     "unsupported algorithm",
+			entries:  []entry{{"blobs/md5/" + strings.Repeat("a", 32), layer}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "short digest",
+			entries:  []entry{{"blobs/sha256/abcd", layer}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "upper case digest",
+			entries:  []entry{{"blobs/sha256/" + strings.ToUpper(sha256Hex(layer)), layer}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "digest mismatch",
+			entries:  []entry{{"blobs/sha256/" + sha256Hex(config), layer}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "streamed digest mismatch",
+			entries:  []entry{{"blobs/sha256/" + sha256Hex(layer), large}},
+			opts:     []tarball.ReaderOption{tarball.WithMaxMetadataSize(8)},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "duplicate blob",
+			entries:  []entry{blob(layer), blob(layer), blob(config), {"manifest.json", manifest}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "duplicate manifest",
+			entries:  []entry{blob(layer), blob(config), {"manifest.json", manifest}, {"manifest.json", manifest}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "blob after manifest",
+			entries:  []entry{blob(config), {"manifest.json", manifest}, blob(layer)},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "missing blob",
+			entries:  []entry{blob(config), {"manifest.json", manifest}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "missing manifest",
+			entries:  []entry{blob(layer), blob(config)},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "invalid manifest",
+			entries:  []entry{blob(layer), {"manifest.json", "not a manifest"}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "invalid index",
+			entries:  []entry{blob(layer), {"index.json", "not an index"}},
+			expected: tarball.ErrInvalidArchive,
+		},
+		{
+			name:     "too many entries",
+			entries:  []entry{blob(layer), blob(config), {"manifest.json", manifest}},
+			opts:     []tarball.ReaderOption{tarball.WithMaxEntries(2)},
+			expected: tarball.ErrLimitExceeded,
+		},
+		{
+			name:     "blob too large",
+			entries:  []entry{blob(layer), blob(config), {"manifest.json", manifest}},
+			opts:     []tarball.ReaderOption{tarball.WithMaxBlobSize(int64(len(layer) - 1))},
+			expected: tarball.ErrLimitExceeded,
+		},
+		{
+			name:     "archive too large",
+			entries:  []entry{blob(layer), blob(config), {"manifest.json", manifest}},
+			opts:     []tarball.ReaderOption{tarball.WithMaxTotalSize(int64(len(layer) + len(config)))},
+			expected: tarball.ErrLimitExceeded,
+		},
+		{
+			name:     "manifest too large",
+			entries:  []entry{blob(layer), blob(config), {"manifest.json", manifest}},
+			opts:     []tarball.ReaderOption{tarball.WithMaxMetadataSize(int64(len(manifest) - 1))},
+			expected: tarball.ErrLimitExceeded,
+		},
+		{
+			name:     "too many blobs that look like manifests",
+			entries:  []entry{blob(testManifest(config)), blob(testManifest(layer)), {"manifest.json", manifest}},
+			opts:     []tarball.ReaderOption{tarball.WithMaxMetadataSize(int64(len(manifest)))},
+			expected: tarball.ErrLimitExceeded,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tarball.NewReader(bytes.NewReader(buildArchive(t, tc.entries...)), tc.opts...)
+			_, err := readArchive(r)
+			if tc.expected == nil && err != nil {
+				t.Fatalf("Failed to read archive: %v", err)
+			}
+			if tc.expected != nil && !errors.Is(err, tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestReaderManifestLikeLayer(t *testing.T) {
+	const config = `{"config":{}}`
+	// A JSON layer that has the shape of a manifest but is not listed in an index.json
+	layer := testManifest(`{"other":{}}`)
+	manifest := testManifest(config, layer)
+	index := `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+		`"size":` + strconv.Itoa(len(manifest)) + `,"digest":"sha256:` + sha256Hex(manifest) + `"}]}`
+
+	for _, tc := range []struct {
+		name    string
+		entries []entry
+	}{
+		{
+			name:    "legacy archive",
+			entries: []entry{blob(layer), blob(config), {"manifest.json", manifest}},
+		},
+		{
+			name:    "index after blobs",
+			entries: []entry{blob(layer), blob(config), blob(manifest), {"index.json", index}},
+		},
+		{
+			name:    "index before blobs",
+			entries: []entry{{"index.json", index}, blob(layer), blob(config), blob(manifest)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tarball.NewReader(bytes.NewReader(buildArchive(t, tc.entries...)))
+			blobs := make(map[string]string)
+			for {
+				hash, err := r.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Failed to read blob: %v", err)
+				}
+				contents, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("Failed to read blob contents: %v", err)
+				}
+				if r.Size() != int64(len(contents)) {
+					t.Errorf("Expected size %d for blob %s, got %d", len(contents), hash, r.Size())
+				}
+				blobs[hash.Hex] = string(contents)
+			}
+			expected := map[string]string{sha256Hex(layer): layer, sha256Hex(config): config}
+			if len(blobs) != len(expected) {
+				t.Fatalf("Expected blobs %v, got %v", expected, blobs)
+			}
+			for hex, contents := range expected {
+				if blobs[hex] != contents {
+					t.Errorf("Expected blob %s to be %q, got %q", hex, contents, blobs[hex])
+				}
+			}
+			raw, digest, err := r.Manifest()
+			if err != nil {
+				t.Fatalf("Failed to get manifest: %v", err)
+			}
+			if string(raw) != manifest || digest.Hex != sha256Hex(manifest) {
+				t.Errorf("Expected manifest %s, got %s (%s)", manifest, raw, digest)
+			}
+		})
+	}
+}
+
+func TestReaderNonRegularEntries(t *testing.T) {
+	const config, layer = `{"config":{}}`, "some-layer-contents"
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	// Entries of unknown types are skipped, but their contents are still read
+	if err := tw.WriteHeader(&tar.Header{Name: "padding", Typeflag: 'Z', Size: 1024}); err != nil {
+		t.Fatalf("Failed to write header: %v", err)
+	}
+	if _, err := tw.Write(make([]byte, 1024)); err != nil {
+		t.Fatalf("Failed to write contents: %v", err)
+	}
+	for _, e := range []entry{blob(layer), blob(config), {"manifest.json", testManifest(config, layer)}} {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.contents))}); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(e.contents)); err != nil {
+			t.Fatalf("Failed to write contents: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+
+	if _, err := readArchive(tarball.NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	r := tarball.NewReader(bytes.NewReader(buf.Bytes()), tarball.WithMaxTotalSize(1024))
+	if _, err := readArchive(r); !errors.Is(err, tarball.ErrLimitExceeded) {
+		t.Fatalf("Expected %v, got %v", tarball.ErrLimitExceeded, err)
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	const config, layer = `{"config":{}}`, "some-layer-contents"
+	manifest := testManifest(config, layer)
+	archive, err := os.ReadFile(filepath.Join("testdata", "archive.tar"))
+	if err != nil {
+		f.Fatalf("Failed to read archive: %v", err)
+	}
+	f.Add(archive)
+	f.Add(buildArchive(f, blob(layer), blob(config), entry{"manifest.json", manifest}))
+	f.Add(buildArchive(f, entry{"oci-layout", `{"imageLayoutVersion":"1.0.0"}`}, blob(layer), blob(config), blob(manifest),
+		entry{"index.json", `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+			`"size":` + strconv.Itoa(len(manifest)) + `,"digest":"sha256:` + sha256Hex(manifest) + `",` +
+			`"annotations":{"org.opencontainers.image.ref.name":"some/model:v1"}}]}`}))
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(buildArchive(f, blob(layer), blob(config), entry{"manifest.json", manifest}))
+	zw.Close()
+	f.Add(gz.Bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := tarball.NewReader(bytes.NewReader(data), tarball.WithMaxEntries(64), tarball.WithMaxTotalSize(1<<20))
+		manifests, err := readArchive(r)
+		if err != nil {
+			return
+		}
+		// A successfully read archive has at least one manifest, each matching its digest
+		if len(manifests) == 0 {
+			t.Fatalf("Expected at least one manifest")
+		}
+		for _, m := range manifests {
+			if m.Digest.Hex != sha256Hex(string(m.Raw)) {
+				t.Fatalf("Manifest %s does not match its digest", m.Digest)
+			}
+		}
+	})
+}
@@ -3,84 +3,175 @@ package tarball
 import (
 	"archive/tar"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
 
+	"github.com/google/go-containerregistry/pkg/compression"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
 
-	"github.com/docker/model-distribution/internal/progress"
+	"github.com/docker/model-distribution/internal/utils"
+	"github.com/docker/model-distribution/oci"
+	"github.com/docker/model-distribution/progress"
 	"github.com/docker/model-distribution/types"
 )
 
 // Target stores an artifact as a TAR archive
 type Target struct {
-	reference name.Tag
-	writer    io.Writer
-	dirs      map[string]struct{}
+	reference   name.Tag
+	writer      io.Writer
+	dirs        map[string]struct{}
+	ociLayout   bool
+	refNames    []string
+	compression compression.Compression
+}
+
+// TargetOption configures a Target
+type TargetOption func(*Target)
+
+// WithOCILayout writes the archive as an OCI image layout (oci-layout, index.json and blobs/), which tools like skopeo
+// read as an oci-archive. The artifact is listed in index.json under each of the given ref names.
+func WithOCILayout(refNames ...string) TargetOption {
+	return func(t *Target) {
+		t.ociLayout = true
+		t.refNames = append(t.refNames, refNames...)
+	}
 }
 
 // NewTarget returns a *Target for the given writer
-func NewTarget(w io.Writer) (*Target, error) {
-	return &Target{
+func NewTarget(w io.Writer, opts ...TargetOption) (*Target, error) {
+	t := &Target{
 		writer: w,
 		dirs:   make(map[string]struct{}),
-	}, nil
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	if _, _, err := compress(io.Discard, t.compression); err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
-// Write writes the artifact in archive format to the configured io.Writer
+// Model is a model to write to an archive with WriteModels
+type Model struct {
+	Artifact types.ModelArtifact
+	// Tags are the references under which the model is tagged when the archive is loaded
+	Tags []string
+}
+
+// Write writes the artifact in archive format to the configured io.Writer, reporting progress to progressWriter as a
+// save operation. Completion is only reported once the archive has been finished.
 func (t *Target) Write(ctx context.Context, mdl types.ModelArtifact, progressWriter io.Writer) error {
-	tw := tar.NewWriter(t.writer)
-	defer tw.Close()
+	return reportSave(progressWriter, "", func(pw progress.Writer) error {
+		return t.write(ctx, []Model{{Artifact: mdl, Tags: t.refNames}}, pw)
+	})
+}
 
-	rm, err := mdl.RawManifest()
+// WriteModels writes several models with their tags to a single archive, like Write. It stops once ctx is done. Blobs
+// shared between the models are written once. Archives with several models or with tags are written as OCI image
+// layouts, listing each model in index.json under each of its tags as a ref name.
+func (t *Target) WriteModels(ctx context.Context, models []Model, progressWriter io.Writer) error {
+	return reportSave(progressWriter, "", func(pw progress.Writer) error {
+		return t.write(ctx, models, pw)
+	})
+}
+
+// write writes the models in archive format, reporting the progress of the layers to pw. It stops once ctx is done.
+func (t *Target) write(ctx context.Context, models []Model, pw progress.Writer) (err error) {
+	if len(models) == 0 {
+		return errors.New("no models to write")
+	}
+	w, closeCompressor, err := compress(t.writer, t.compression)
 	if err != nil {
 		return err
 	}
+	tw := tar.NewWriter(w)
+	defer func() {
+		if closeErr := tw.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("finish archive: %w", closeErr)
+		}
+		if closeErr := closeCompressor(); closeErr != nil && err == nil {
+			err = fmt.Errorf("finish compressed archive: %w", closeErr)
+		}
+	}()
 
+	ociLayout := t.ociLayout || len(models) > 1 || len(models[0].Tags) > 0
+	if ociLayout {
+		if err := writeFile(tw, "oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+			return err
+		}
+	}
 	if err := t.ensureDir("blobs", tw); err != nil {
 		return err
 	}
 
-	ls, err := mdl.Layers()
-	if err != nil {
-		return fmt.Errorf("get layers: %w", err)
-	}
-
+	// Layers shared between models are only counted and written once
+	var layers []v1.Layer
+	seen := make(map[v1.Hash]struct{})
 	layersSize := int64(0)
-	for _, layer := range ls {
-		size, err := layer.Size()
+	for _, m := range models {
+		ls, err := m.Artifact.Layers()
 		if err != nil {
-			return fmt.Errorf("get layer size: %w", err)
+			return fmt.Errorf("get layers: %w", err)
+		}
+		for _, layer := range ls {
+			diffID, err := layer.DiffID()
+			if err != nil {
+				return fmt.Errorf("get layer diffID: %w", err)
+			}
+			if _, ok := seen[diffID]; ok {
+				continue
+			}
+			seen[diffID] = struct{}{}
+			size, err := layer.Size()
+			if err != nil {
+				return fmt.Errorf("get layer size: %w", err)
+			}
+			layersSize += size
+			layers = append(layers, layer)
 		}
-		layersSize += size
 	}
 
-	for _, layer := range ls {
-		if err := t.addLayer(layer, tw, progressWriter, layersSize); err != nil {
+	tracker := progress.NewTracker(pw, layersSize)
+	for _, layer := range layers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := t.addLayer(ctx, layer, tw, tracker); err != nil {
 			return fmt.Errorf("add layer entry: %w", err)
 		}
 	}
-	rcf, err := mdl.RawConfigFile()
-	if err != nil {
-		return err
+	for _, m := range models {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rcf, err := m.Artifact.RawConfigFile()
+		if err != nil {
+			return err
+		}
+		cn, err := m.Artifact.ConfigName()
+		if err != nil {
+			return err
+		}
+		if err := t.addBlob(tw, cn, rcf, seen); err != nil {
+			return fmt.Errorf("write config blob: %w", err)
+		}
 	}
-	cn, err := mdl.ConfigName()
-	if err != nil {
-		return err
+
+	if ociLayout {
+		return t.writeIndex(ctx, models, tw, seen)
 	}
-	if err = tw.WriteHeader(&tar.Header{
-		Name: filepath.Join("blobs", cn.Algorithm, cn.Hex),
-		Mode: 0666,
-		Size: int64(len(rcf)),
-	}); err != nil {
+	rm, err := models[0].Artifact.RawManifest()
+	if err != nil {
 		return err
 	}
-	if _, err = tw.Write(rcf); err != nil {
-		return fmt.Errorf("write config blob contents: %w", err)
-	}
-
 	if err := tw.WriteHeader(&tar.Header{
 		Name: "manifest.json",
 		Size: int64(len(rm)),
@@ -95,7 +186,76 @@ func (t *Target) Write(ctx context.Context, mdl types.ModelArtifact, progressWri
 	return nil
 }
 
-func (t *Target) addLayer(layer v1.Layer, tw *tar.Writer, progressWriter io.Writer, imageSize int64) error {
+// writeIndex writes the manifest blobs of the models and an index.json listing each model under its tags
+func (t *Target) writeIndex(ctx context.Context, models []Model, tw *tar.Writer, seen map[v1.Hash]struct{}) error {
+	index := v1.IndexManifest{SchemaVersion: 2, MediaType: ggcrtypes.OCIImageIndex}
+	for _, m := range models {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rm, err := m.Artifact.RawManifest()
+		if err != nil {
+			return fmt.Errorf("get manifest: %w", err)
+		}
+		digest, err := m.Artifact.Digest()
+		if err != nil {
+			return fmt.Errorf("get digest: %w", err)
+		}
+		mt, err := m.Artifact.MediaType()
+		if err != nil {
+			return fmt.Errorf("get media type: %w", err)
+		}
+		if err := t.addBlob(tw, digest, rm, seen); err != nil {
+			return fmt.Errorf("write manifest blob: %w", err)
+		}
+
+		desc := v1.Descriptor{MediaType: mt, Size: int64(len(rm)), Digest: digest}
+		if len(m.Tags) == 0 {
+			index.Manifests = append(index.Manifests, desc)
+		}
+		for _, tag := range m.Tags {
+			desc.Annotations = map[string]string{oci.AnnotationRefName: tag}
+			index.Manifests = append(index.Manifests, desc)
+		}
+	}
+	raw, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("encode index: %w", err)
+	}
+	if err := writeFile(tw, "index.json", raw); err != nil {
+		return fmt.Errorf("write index.json: %w", err)
+	}
+	return nil
+}
+
+// addBlob adds a blob with the given contents to the archive unless it is in seen
+func (t *Target) addBlob(tw *tar.Writer, hash v1.Hash, contents []byte, seen map[v1.Hash]struct{}) error {
+	if _, ok := seen[hash]; ok {
+		return nil
+	}
+	seen[hash] = struct{}{}
+	if err := t.ensureDir(filepath.Join("blobs", hash.Algorithm), tw); err != nil {
+		return err
+	}
+	return writeFile(tw, filepath.Join("blobs", hash.Algorithm, hash.Hex), contents)
+}
+
+// writeFile adds a regular file with the given contents to the archive
+func writeFile(tw *tar.Writer, name string, contents []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0666,
+		Size: int64(len(contents)),
+	}); err != nil {
+		return fmt.Errorf("write %s header: %w", name, err)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		return fmt.Errorf("write %s contents: %w", name, err)
+	}
+	return nil
+}
+
+func (t *Target) addLayer(ctx context.Context, layer v1.Layer, tw *tar.Writer, tracker *progress.Tracker) error {
 	diffID, err := layer.DiffID()
 	if err != nil {
 		return fmt.Errorf("get layer diffID: %w", err)
@@ -115,29 +275,16 @@ func (t *Target) addLayer(layer v1.Layer, tw *tar.Writer, progressWriter io.Writ
 		return fmt.Errorf("write blob file header: %w", err)
 	}
 
-	var pr *progress.Reporter
-	var progressChan chan<- v1.Update
-	if progressWriter != nil {
-		pr = progress.NewProgressReporter(progressWriter, func(update v1.Update) string {
-			return fmt.Sprintf("Transferred: %.2f MB", float64(update.Complete)/1024/1024)
-		}, imageSize, layer)
-		progressChan = pr.Updates()
-		defer func() {
-			close(progressChan)
-			if err := pr.Wait(); err != nil {
-				fmt.Printf("reporter finished with non-fatal error: %v\n", err)
-			}
-		}()
-	}
-
 	rc, err := layer.Uncompressed()
 	if err != nil {
 		return fmt.Errorf("open layer %q: %w", diffID, err)
 	}
 	defer rc.Close()
-	if _, err = io.Copy(tw, progress.NewReader(rc, progressChan)); err != nil {
+	lt := tracker.Start(progress.Layer{ID: diffID.String(), Size: sz})
+	if _, err = io.Copy(tw, lt.Reader(&utils.ContextReader{Ctx: ctx, Reader: rc})); err != nil {
 		return fmt.Errorf("copy layer %q: %w", diffID, err)
 	}
+	lt.Done()
 	return nil
 }
 
@@ -153,3 +300,22 @@ func (t *Target) ensureDir(path string, tw *tar.Writer) error {
 	t.dirs[path] = struct{}{}
 	return nil
 }
+
+// reportSave runs write as a save operation, reporting its start and end to progressWriter
+func reportSave(progressWriter io.Writer, reference string, write func(pw progress.Writer) error) (err error) {
+	pw := progress.NewWriter(progressWriter)
+	if err := pw.Handle(progress.PhaseStart{Phase: progress.PhaseSave, Reference: reference}); err != nil {
+		fmt.Printf("reporter finished with non-fatal error: %v\n", err)
+		pw = progress.NewWriter(nil)
+	}
+	defer func() {
+		end := progress.PhaseEnd{Phase: progress.PhaseSave, Reference: reference, Err: err}
+		if err == nil {
+			end.Message = "Model saved successfully"
+		}
+		if err := pw.Handle(end); err != nil {
+			fmt.Printf("reporter finished with non-fatal error: %v\n", err)
+		}
+	}()
+	return write(pw)
+}
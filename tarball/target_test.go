@@ -3,15 +3,23 @@ package tarball_test
 import (
 	"archive/tar"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 
 	"github.com/docker/model-distribution/internal/gguf"
+	"github.com/docker/model-distribution/internal/mutate"
+	"github.com/docker/model-distribution/internal/progress"
+	"github.com/docker/model-distribution/oci"
 	"github.com/docker/model-distribution/tarball"
+	"github.com/docker/model-distribution/types"
 )
 
 func TestTarget(t *testing.T) {
@@ -105,3 +113,271 @@ func hasDir(t *testing.T, tr *tar.Reader, name string) {
 		t.Fatalf("Unexpected entry with name %q to be a directory got type %v", name, hdr.Typeflag)
 	}
 }
+
+func TestTargetProgress(t *testing.T) {
+	mdl, err := gguf.NewModel(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	parse := func(t *testing.T, buf *bytes.Buffer) []progress.Message {
+		t.Helper()
+		var messages []progress.Message
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			var msg progress.Message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				t.Fatalf("Failed to parse progress message %q: %v", line, err)
+			}
+			messages = append(messages, msg)
+		}
+		return messages
+	}
+
+	t.Run("Target", func(t *testing.T) {
+		target, err := tarball.NewTarget(io.Discard)
+		if err != nil {
+			t.Fatalf("Failed to create tar target: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := target.Write(t.Context(), mdl, &buf); err != nil {
+			t.Fatalf("Failed to write model: %v", err)
+		}
+		messages := parse(t, &buf)
+		if len(messages) < 2 {
+			t.Fatalf("Expected progress and success messages, got %d", len(messages))
+		}
+		if msg := messages[len(messages)-2]; msg.Type != "progress" || !strings.HasPrefix(msg.Message, "Transferred:") || msg.Layer.Current != msg.Layer.Size {
+			t.Errorf("Expected completed layer progress message, got %+v", msg)
+		}
+		if msg := messages[len(messages)-1]; msg.Type != "success" || msg.Message != "Model saved successfully" {
+			t.Errorf("Expected success message, got %+v", msg)
+		}
+	})
+
+	t.Run("FileTarget", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "model.tar")
+		var buf bytes.Buffer
+		if err := tarball.NewFileTarget(path).Write(t.Context(), mdl, &buf); err != nil {
+			t.Fatalf("Failed to write model: %v", err)
+		}
+		messages := parse(t, &buf)
+		if msg := messages[len(messages)-1]; msg.Type != "success" || msg.Message != "Model saved successfully" {
+			t.Errorf("Expected a single success message at the end, got %+v", msg)
+		}
+		var successes int
+		for _, msg := range messages {
+			if msg.Type == "success" {
+				successes++
+			}
+		}
+		if successes != 1 {
+			t.Errorf("Expected a single success message, got %d", successes)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected archive to exist: %v", err)
+		}
+	})
+
+	t.Run("FileTarget error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "model.tar")
+		var buf bytes.Buffer
+		broken := &brokenLayerModel{ModelArtifact: mdl}
+		err := tarball.NewFileTarget(path).Write(t.Context(), broken, &buf)
+		if err == nil {
+			t.Fatalf("Expected error writing model")
+		}
+		messages := parse(t, &buf)
+		if msg := messages[len(messages)-1]; msg.Type != "error" || msg.Message != "Error: "+err.Error() {
+			t.Errorf("Expected error message, got %+v", msg)
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected incomplete archive to be removed, got %v", err)
+		}
+	})
+}
+
+func TestTargetCancel(t *testing.T) {
+	mdl, err := gguf.NewModel(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	t.Run("Target", func(t *testing.T) {
+		target, err := tarball.NewTarget(io.Discard)
+		if err != nil {
+			t.Fatalf("Failed to create tar target: %v", err)
+		}
+		if err := target.Write(ctx, mdl, nil); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected %v, got %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("FileTarget", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "model.tar")
+		if err := tarball.NewFileTarget(path).Write(ctx, mdl, nil); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected %v, got %v", context.Canceled, err)
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected incomplete archive to be removed, got %v", err)
+		}
+	})
+}
+
+// brokenLayerModel is a model whose layers cannot be read
+type brokenLayerModel struct {
+	types.ModelArtifact
+}
+
+func (m *brokenLayerModel) Layers() ([]v1.Layer, error) {
+	return nil, errors.New("layers unavailable")
+}
+
+func TestTargetOCILayout(t *testing.T) {
+	mdl, err := gguf.NewModel(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	var buf bytes.Buffer
+	target, err := tarball.NewTarget(&buf, tarball.WithOCILayout("v1", "latest"))
+	if err != nil {
+		t.Fatalf("Failed to create tar target: %v", err)
+	}
+	if err := target.Write(t.Context(), mdl, nil); err != nil {
+		t.Fatalf("Failed to write model to tar file: %v", err)
+	}
+	manifestContents, err := mdl.RawManifest()
+	if err != nil {
+		t.Fatalf("Failed to get raw manifest contents: %v", err)
+	}
+	digest, err := mdl.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get digest: %v", err)
+	}
+
+	t.Run("read as image layout", func(t *testing.T) {
+		// Extract the archive and read it like any OCI image layout directory
+		dir := t.TempDir()
+		tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to read header: %v", err)
+			}
+			path := filepath.Join(dir, hdr.Name)
+			if hdr.Typeflag == tar.TypeDir {
+				if err := os.MkdirAll(path, 0755); err != nil {
+					t.Fatalf("Failed to create directory: %v", err)
+				}
+				continue
+			}
+			contents, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatalf("Failed to read contents: %v", err)
+			}
+			if err := os.WriteFile(path, contents, 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+		img, err := oci.Image(dir, "latest")
+		if err != nil {
+			t.Fatalf("Failed to read image layout: %v", err)
+		}
+		if d, err := img.Digest(); err != nil || d != digest {
+			t.Fatalf("Expected digest %s, got %s (%v)", digest, d, err)
+		}
+	})
+
+	t.Run("read with Reader", func(t *testing.T) {
+		r := tarball.NewReader(bytes.NewReader(buf.Bytes()))
+		var blobs []v1.Hash
+		for {
+			diffID, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to read blob: %v", err)
+			}
+			blobs = append(blobs, diffID)
+		}
+		if len(blobs) != 2 {
+			t.Fatalf("Expected the layer and config blobs but not the manifest, got %v", blobs)
+		}
+		rawManifest, d, err := r.Manifest()
+		if err != nil {
+			t.Fatalf("Failed to get manifest: %v", err)
+		}
+		if d != digest || !bytes.Equal(rawManifest, manifestContents) {
+			t.Fatalf("Expected manifest %s, got %s", digest, d)
+		}
+	})
+}
+
+func TestTargetWriteModels(t *testing.T) {
+	mdl, err := gguf.NewModel(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	other := mutate.ContextSize(mdl, 2048)
+	var buf bytes.Buffer
+	target, err := tarball.NewTarget(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create tar target: %v", err)
+	}
+	if err := target.WriteModels(t.Context(), []tarball.Model{
+		{Artifact: mdl, Tags: []string{"some/model:v1", "some/model:latest"}},
+		{Artifact: other},
+	}, nil); err != nil {
+		t.Fatalf("Failed to write models: %v", err)
+	}
+
+	r := tarball.NewReader(bytes.NewReader(buf.Bytes()))
+	var blobs []v1.Hash
+	for {
+		diffID, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read blob: %v", err)
+		}
+		blobs = append(blobs, diffID)
+	}
+	// The GGUF layer is shared, the configs differ
+	if len(blobs) != 3 {
+		t.Fatalf("Expected one layer and two config blobs, got %v", blobs)
+	}
+	if _, _, err := r.Manifest(); err == nil {
+		t.Fatalf("Expected Manifest to fail for an archive with several models")
+	}
+	manifests, err := r.Manifests()
+	if err != nil {
+		t.Fatalf("Failed to get manifests: %v", err)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("Expected 2 manifests, got %d", len(manifests))
+	}
+	for i, expected := range []struct {
+		mdl  v1.Image
+		tags []string
+	}{
+		{mdl, []string{"some/model:v1", "some/model:latest"}},
+		{other, nil},
+	} {
+		digest, err := expected.mdl.Digest()
+		if err != nil {
+			t.Fatalf("Failed to get digest: %v", err)
+		}
+		if manifests[i].Digest != digest {
+			t.Fatalf("Expected manifest %d to be %s, got %s", i, digest, manifests[i].Digest)
+		}
+		if strings.Join(manifests[i].Tags, ",") != strings.Join(expected.tags, ",") {
+			t.Fatalf("Expected tags %v for manifest %d, got %v", expected.tags, i, manifests[i].Tags)
+		}
+	}
+}
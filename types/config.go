@@ -17,15 +17,24 @@ const (
 	// MediaTypeGGUF indicates a file in GGUF version 3 format, containing a tensor model.
 	MediaTypeGGUF = types.MediaType("application/vnd.docker.ai.gguf.v3")
 
+	// MediaTypeGGUFEncrypted indicates a GGUF file encrypted for one or more recipients, see package encryption
+	MediaTypeGGUFEncrypted = MediaTypeGGUF + "+encrypted"
+
 	// MediaTypeLicense indicates a plain text file containing a license
 	MediaTypeLicense = types.MediaType("application/vnd.docker.ai.license")
 
 	// MediaTypeMultimodalProjector indicates a Multimodal projector file
 	MediaTypeMultimodalProjector = types.MediaType("application/vnd.docker.ai.mmproj")
 
+	// MediaTypeMultimodalProjectorEncrypted indicates a Multimodal projector file encrypted for one or more recipients
+	MediaTypeMultimodalProjectorEncrypted = MediaTypeMultimodalProjector + "+encrypted"
+
 	// MediaTypeChatTemplate indicates a Jinja chat template
 	MediaTypeChatTemplate = types.MediaType("application/vnd.docker.ai.chat.template.jinja")
 
+	// MediaTypeModelSignature is the artifact type of referrers holding a detached signature over a model manifest
+	MediaTypeModelSignature = types.MediaType("application/vnd.docker.ai.model.signature.v1+json")
+
 	FormatGGUF = Format("gguf")
 )
 
@@ -50,5 +59,41 @@ type Config struct {
 
 // Descriptor provides metadata about the provenance of the model.
 type Descriptor struct {
-	Created *time.Time `json:"created,omitempty"`
+	Created    *time.Time  `json:"created,omitempty"`
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance records how a model artifact was built.
+type Provenance struct {
+	// Tool is the tool that built the artifact
+	Tool Tool `json:"tool"`
+	// Host is the name of the host the artifact was built on
+	Host string `json:"host,omitempty"`
+	// Sources are the files the artifact was built from
+	Sources []Source `json:"sources,omitempty"`
+	// Recipe identifies the build recipe, e.g. a script in a git repository
+	Recipe *Recipe `json:"recipe,omitempty"`
+	// Licenses are the SPDX identifiers of the model's licenses, e.g. Apache-2.0
+	Licenses []string `json:"licenses,omitempty"`
+}
+
+// Tool identifies a build tool.
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Source is a file a model artifact was built from. The digest is left out for encrypted files.
+type Source struct {
+	Name      string `json:"name"`
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+// Recipe identifies the recipe a model artifact was built with.
+type Recipe struct {
+	// URI locates the recipe, e.g. a git repository URL or a path
+	URI string `json:"uri,omitempty"`
+	// Commit is the git commit of the recipe
+	Commit string `json:"commit,omitempty"`
 }
@@ -28,3 +28,13 @@ type ModelBundle interface {
 	MMPROJPath() string
 	RuntimeConfig() Config
 }
+
+// Referrer is an artifact that refers to a model, such as a model card, an SBOM or evaluation results
+type Referrer struct {
+	// Digest is the digest of the artifact's manifest
+	Digest string `json:"digest"`
+	// ArtifactType is the type of the artifact
+	ArtifactType string `json:"artifactType"`
+	// Files are the paths of the artifact's files
+	Files []string `json:"files"`
+}